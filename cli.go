@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"gopkg.in/yaml.v3"
+)
+
+// Exit codes returned by goinit so scripts can tell failures apart.
+const (
+	exitOK            = 0
+	exitFailure       = 1 // generation failed after the configuration was accepted
+	exitUsage         = 2 // unknown flag or malformed command line
+	exitInvalidConfig = 3 // spec file could not be read or the answers failed validation
+)
+
+var supportedDatabaseDrivers = []string{"sqlite", "mysql", "postgres"}
+
+var projectNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// cliOptions holds everything parsed from the command line.
+type cliOptions struct {
+	ConfigPath  string
+	Yes         bool
+	DryRun      bool
+	Force       bool
+	Merge       bool
+	Generate    generateOptions
+	ShowVersion bool
+	ShowHelp    bool
+
+	// Flags holds project values given as flags; empty fields were not set.
+	Flags ProjectConfig
+}
+
+// parseFlags parses goinit's command line flags.
+func parseFlags(args []string) (cliOptions, error) {
+	var opts cliOptions
+
+	fs := flag.NewFlagSet("goinit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+
+	fs.StringVar(&opts.Flags.ProjectName, "name", "", "project name")
+	fs.StringVar(&opts.Flags.ModuleName, "module", "", "Go module path")
+	fs.StringVar(&opts.Flags.DatabaseDriver, "db", "", "database driver (sqlite, mysql, postgres)")
+	fs.StringVar(&opts.Flags.Port, "port", "", "HTTP port")
+	fs.StringVar(&opts.Flags.OutputDir, "out", "", "output directory")
+	fs.StringVar(&opts.Flags.Template, "template", "", "project template or template directory")
+	fs.Func("features", "comma separated features to include", func(value string) error {
+		opts.Flags.Features = parseFeatureList(value)
+		return nil
+	})
+	fs.StringVar(&opts.ConfigPath, "config", "", "project spec file (YAML)")
+	fs.BoolVar(&opts.Generate.Offline, "offline", false, "generate without network access")
+	fs.StringVar(&opts.Generate.GoProxy, "goproxy", "", "local GOPROXY directory or module cache for --offline")
+	fs.BoolVar(&opts.Generate.SkipTidy, "skip-tidy", false, "do not run go mod tidy")
+	fs.BoolVar(&opts.Generate.SkipVerify, "skip-verify", false, "do not build, vet and test the generated project")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "preview the generated files without writing")
+	fs.BoolVar(&opts.Force, "force", false, "replace the contents of a non-empty output directory, keeping version control directories")
+	fs.BoolVar(&opts.Merge, "merge", false, "write into a non-empty output directory")
+	fs.BoolVar(&opts.Yes, "yes", false, "accept defaults and never prompt")
+	fs.BoolVar(&opts.Yes, "y", false, "shorthand for --yes")
+	fs.BoolVar(&opts.ShowVersion, "version", false, "show version information")
+	fs.BoolVar(&opts.ShowVersion, "v", false, "shorthand for --version")
+	fs.BoolVar(&opts.ShowHelp, "help", false, "show help")
+	fs.BoolVar(&opts.ShowHelp, "h", false, "shorthand for --help")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if opts.Force && opts.Merge {
+		return opts, errors.New("--force and --merge cannot be used together")
+	}
+	if opts.Generate.GoProxy != "" && !opts.Generate.Offline {
+		return opts, errors.New("--goproxy requires --offline")
+	}
+
+	return opts, nil
+}
+
+// loadSpecFile reads a goinit.yaml spec file into a ProjectConfig.
+// Unknown keys are rejected so typos do not silently fall back to defaults.
+func loadSpecFile(path string) (ProjectConfig, error) {
+	var spec ProjectConfig
+
+	f, err := os.Open(path)
+	if err != nil {
+		return spec, fmt.Errorf("failed to open spec file: %v", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return spec, fmt.Errorf("failed to parse spec file %s: %v", path, err)
+	}
+
+	return spec, nil
+}
+
+// mergeProjectConfig overlays the non-empty fields of override onto base.
+func mergeProjectConfig(base, override ProjectConfig) ProjectConfig {
+	if override.ProjectName != "" {
+		base.ProjectName = override.ProjectName
+	}
+	if override.ModuleName != "" {
+		base.ModuleName = override.ModuleName
+	}
+	if override.DatabaseDriver != "" {
+		base.DatabaseDriver = override.DatabaseDriver
+	}
+	if override.Port != "" {
+		base.Port = override.Port
+	}
+	if override.OutputDir != "" {
+		base.OutputDir = override.OutputDir
+	}
+	if override.Template != "" {
+		base.Template = override.Template
+	}
+	if len(override.Features) > 0 {
+		base.Features = override.Features
+	}
+	return base
+}
+
+// applyDefaults fills in every field that is still empty.
+func applyDefaults(config ProjectConfig) ProjectConfig {
+	if config.ModuleName == "" && config.ProjectName != "" {
+		config.ModuleName = fmt.Sprintf("github.com/user/%s", config.ProjectName)
+	}
+	if config.DatabaseDriver == "" {
+		config.DatabaseDriver = "sqlite"
+	}
+	if config.Port == "" {
+		config.Port = "8080"
+	}
+	if config.OutputDir == "" {
+		config.OutputDir = config.ProjectName
+	}
+	if config.Template == "" {
+		config.Template = defaultTemplate
+	}
+	// Local templates are recorded by absolute path so upgrades find them from anywhere
+	if isLocalTemplate(config.Template) {
+		if abs, err := filepath.Abs(config.Template); err == nil {
+			config.Template = abs
+		}
+	}
+	if tmpl, err := lookupTemplate(config.Template); err == nil && tmpl.Features {
+		if len(config.Features) == 0 {
+			config.Features = FeatureSet(knownFeatures)
+		}
+		config.Features = config.Features.normalized()
+	}
+	return config
+}
+
+// validateProjectConfig reports every invalid field at once.
+func validateProjectConfig(config ProjectConfig) error {
+	var errs []error
+
+	switch {
+	case config.ProjectName == "":
+		errs = append(errs, errors.New("project name is required"))
+	case len(config.ProjectName) > 64:
+		errs = append(errs, fmt.Errorf("project name %q is longer than 64 characters", config.ProjectName))
+	case !projectNamePattern.MatchString(config.ProjectName):
+		errs = append(errs, fmt.Errorf("project name %q must start with a letter and contain only letters, digits, '-' or '_'", config.ProjectName))
+	}
+
+	if config.ModuleName == "" {
+		errs = append(errs, errors.New("module name is required"))
+	} else if err := module.CheckImportPath(config.ModuleName); err != nil {
+		errs = append(errs, fmt.Errorf("invalid module name: %v", err))
+	}
+
+	if !isSupportedDatabaseDriver(config.DatabaseDriver) {
+		errs = append(errs, fmt.Errorf("unsupported database driver %q (choose one of %s)", config.DatabaseDriver, strings.Join(supportedDatabaseDrivers, ", ")))
+	}
+
+	if port, err := strconv.Atoi(config.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("invalid port %q: must be a number between 1 and 65535", config.Port))
+	}
+
+	tmpl, err := lookupTemplate(config.Template)
+	switch {
+	case err != nil:
+		errs = append(errs, err)
+	case tmpl.Features:
+		errs = append(errs, config.Features.validate()...)
+	case len(config.Features) > 0:
+		errs = append(errs, fmt.Errorf("template %q does not support --features", config.Template))
+	}
+
+	return errors.Join(errs...)
+}
+
+func isSupportedDatabaseDriver(driver string) bool {
+	for _, d := range supportedDatabaseDrivers {
+		if d == driver {
+			return true
+		}
+	}
+	return false
+}
+
+// promptLine prints a prompt and returns the trimmed answer, or fallback when the answer is empty.
+func promptLine(reader *bufio.Reader, prompt, fallback string) string {
+	fmt.Print(prompt)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return fallback
+	}
+	return answer
+}
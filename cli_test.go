@@ -0,0 +1,348 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeSpec writes a spec file with content and returns its path
+func writeSpec(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "goinit.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    cliOptions
+		wantErr string // a substring of the error; empty for success
+	}{
+		{
+			name: "project flags",
+			args: []string{"--name", "shop", "--module", "example.com/shop", "--db", "postgres", "--port", "9000", "--out", "build/shop", "--template", "worker"},
+			want: cliOptions{Flags: ProjectConfig{
+				ProjectName:    "shop",
+				ModuleName:     "example.com/shop",
+				DatabaseDriver: "postgres",
+				Port:           "9000",
+				OutputDir:      "build/shop",
+				Template:       "worker",
+			}},
+		},
+		{
+			name: "features",
+			args: []string{"--features", " auth, email,,storage:s3 "},
+			want: cliOptions{Flags: ProjectConfig{Features: FeatureSet{"auth", "email", "storage:s3"}}},
+		},
+		{
+			name: "shorthands",
+			args: []string{"-y", "-v", "-h"},
+			want: cliOptions{Yes: true, ShowVersion: true, ShowHelp: true},
+		},
+		{
+			name: "generation options",
+			args: []string{"--config", "goinit.yaml", "--dry-run", "--force", "--offline", "--goproxy", "/proxy", "--skip-tidy", "--skip-verify"},
+			want: cliOptions{
+				ConfigPath: "goinit.yaml",
+				DryRun:     true,
+				Force:      true,
+				Generate:   generateOptions{Offline: true, SkipTidy: true, SkipVerify: true, GoProxy: "/proxy"},
+			},
+		},
+		{name: "unknown flag", args: []string{"--colour"}, wantErr: "colour"},
+		{name: "missing flag value", args: []string{"--name"}, wantErr: "name"},
+		{name: "argument", args: []string{"--yes", "shop"}, wantErr: `unexpected argument "shop"`},
+		{name: "force and merge", args: []string{"--force", "--merge"}, wantErr: "cannot be used together"},
+		{name: "goproxy without offline", args: []string{"--goproxy", "/proxy"}, wantErr: "--goproxy requires --offline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFlags(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseFlags = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFlags = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFlags =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSpecFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    ProjectConfig
+		wantErr string
+	}{
+		{
+			name: "every key",
+			spec: "name: shop\nmodule: example.com/shop\ndb: mysql\nport: \"9000\"\nout: build/shop\ntemplate: gin-full\nfeatures: [auth, email]\n",
+			want: ProjectConfig{
+				ProjectName:    "shop",
+				ModuleName:     "example.com/shop",
+				DatabaseDriver: "mysql",
+				Port:           "9000",
+				OutputDir:      "build/shop",
+				Template:       "gin-full",
+				Features:       FeatureSet{"auth", "email"},
+			},
+		},
+		{
+			name: "features as a string",
+			spec: "name: shop\nfeatures: auth, redis\n",
+			want: ProjectConfig{ProjectName: "shop", Features: FeatureSet{"auth", "redis"}},
+		},
+		{name: "empty file", spec: ""},
+		{name: "unknown key", spec: "name: shop\ndatabase: mysql\n", wantErr: "field database not found"},
+		{name: "malformed YAML", spec: "name: [shop\n", wantErr: "failed to parse spec file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadSpecFile(writeSpec(t, tt.spec))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadSpecFile = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadSpecFile = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadSpecFile = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadSpecFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadSpecFile read a missing file")
+	}
+}
+
+func TestMergeProjectConfig(t *testing.T) {
+	spec := ProjectConfig{
+		ProjectName:    "spec",
+		ModuleName:     "example.com/spec",
+		DatabaseDriver: "mysql",
+		Port:           "9000",
+		OutputDir:      "spec-out",
+		Template:       "gin-full",
+		Features:       FeatureSet{"auth", "email"},
+	}
+
+	tests := []struct {
+		name  string
+		flags ProjectConfig
+		want  ProjectConfig
+	}{
+		{name: "no flags", want: spec},
+		{
+			name:  "flags win",
+			flags: ProjectConfig{ProjectName: "flag", DatabaseDriver: "postgres", Features: FeatureSet{"redis"}},
+			want: ProjectConfig{
+				ProjectName:    "flag",
+				ModuleName:     "example.com/spec",
+				DatabaseDriver: "postgres",
+				Port:           "9000",
+				OutputDir:      "spec-out",
+				Template:       "gin-full",
+				Features:       FeatureSet{"redis"},
+			},
+		},
+		{
+			name: "every flag",
+			flags: ProjectConfig{
+				ProjectName:    "flag",
+				ModuleName:     "example.com/flag",
+				DatabaseDriver: "sqlite",
+				Port:           "3000",
+				OutputDir:      "flag-out",
+				Template:       "worker",
+				Features:       FeatureSet{"auth"},
+			},
+			want: ProjectConfig{
+				ProjectName:    "flag",
+				ModuleName:     "example.com/flag",
+				DatabaseDriver: "sqlite",
+				Port:           "3000",
+				OutputDir:      "flag-out",
+				Template:       "worker",
+				Features:       FeatureSet{"auth"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeProjectConfig(spec, tt.flags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeProjectConfig = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ProjectConfig
+		want   ProjectConfig
+	}{
+		{
+			name:   "name only",
+			config: ProjectConfig{ProjectName: "shop"},
+			want: ProjectConfig{
+				ProjectName:    "shop",
+				ModuleName:     "github.com/user/shop",
+				DatabaseDriver: "sqlite",
+				Port:           "8080",
+				OutputDir:      "shop",
+				Template:       defaultTemplate,
+				Features:       FeatureSet(knownFeatures).normalized(),
+			},
+		},
+		{
+			name:   "given values are kept",
+			config: ProjectConfig{ProjectName: "shop", ModuleName: "example.com/shop", DatabaseDriver: "postgres", Port: "9000", OutputDir: "out", Features: FeatureSet{"email"}},
+			want: ProjectConfig{
+				ProjectName:    "shop",
+				ModuleName:     "example.com/shop",
+				DatabaseDriver: "postgres",
+				Port:           "9000",
+				OutputDir:      "out",
+				Template:       defaultTemplate,
+				Features:       FeatureSet{"auth", "email"},
+			},
+		},
+		{
+			name:   "template without features",
+			config: ProjectConfig{ProjectName: "jobs", Template: "worker"},
+			want: ProjectConfig{
+				ProjectName:    "jobs",
+				ModuleName:     "github.com/user/jobs",
+				DatabaseDriver: "sqlite",
+				Port:           "8080",
+				OutputDir:      "jobs",
+				Template:       "worker",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyDefaults(tt.config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyDefaults =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateProjectConfig(t *testing.T) {
+	valid := applyDefaults(ProjectConfig{ProjectName: "shop", ModuleName: "example.com/shop"})
+
+	tests := []struct {
+		name    string
+		change  func(c *ProjectConfig)
+		wantErr []string // substrings of the error; none for a valid config
+	}{
+		{name: "valid", change: func(c *ProjectConfig) {}},
+		{name: "name with dashes and digits", change: func(c *ProjectConfig) { c.ProjectName = "my-shop_2" }},
+		{name: "missing name", change: func(c *ProjectConfig) { c.ProjectName = "" }, wantErr: []string{"project name is required"}},
+		{name: "name starting with a digit", change: func(c *ProjectConfig) { c.ProjectName = "2shop" }, wantErr: []string{"must start with a letter"}},
+		{name: "name with a space", change: func(c *ProjectConfig) { c.ProjectName = "my shop" }, wantErr: []string{"must start with a letter"}},
+		{name: "long name", change: func(c *ProjectConfig) { c.ProjectName = "s" + strings.Repeat("h", 64) }, wantErr: []string{"longer than 64 characters"}},
+		{name: "missing module", change: func(c *ProjectConfig) { c.ModuleName = "" }, wantErr: []string{"module name is required"}},
+		{name: "module with a space", change: func(c *ProjectConfig) { c.ModuleName = "example.com/my shop" }, wantErr: []string{"invalid module name"}},
+		{name: "module with a trailing slash", change: func(c *ProjectConfig) { c.ModuleName = "example.com/shop/" }, wantErr: []string{"invalid module name"}},
+		{name: "unknown driver", change: func(c *ProjectConfig) { c.DatabaseDriver = "oracle" }, wantErr: []string{`unsupported database driver "oracle"`}},
+		{name: "port out of range", change: func(c *ProjectConfig) { c.Port = "70000" }, wantErr: []string{`invalid port "70000"`}},
+		{name: "port not a number", change: func(c *ProjectConfig) { c.Port = "http" }, wantErr: []string{`invalid port "http"`}},
+		{name: "unknown template", change: func(c *ProjectConfig) { c.Template = "rails" }, wantErr: []string{`unknown template "rails"`}},
+		{name: "unknown feature", change: func(c *ProjectConfig) { c.Features = FeatureSet{"auth", "graphql"} }, wantErr: []string{`unknown feature "graphql"`}},
+		{
+			name:    "features on a template without them",
+			change:  func(c *ProjectConfig) { c.Template = "worker" },
+			wantErr: []string{`template "worker" does not support --features`},
+		},
+		{
+			name: "every error at once",
+			change: func(c *ProjectConfig) {
+				c.ProjectName, c.ModuleName, c.DatabaseDriver, c.Port = "", "", "oracle", "0"
+			},
+			wantErr: []string{"project name is required", "module name is required", "unsupported database driver", "invalid port"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			config.Features = append(FeatureSet(nil), valid.Features...)
+			tt.change(&config)
+
+			err := validateProjectConfig(config)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("validateProjectConfig = %v, want the config accepted", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validateProjectConfig accepted %+v", config)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("validateProjectConfig = %v, want an error containing %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	nonEmpty := t.TempDir()
+	if err := os.WriteFile(filepath.Join(nonEmpty, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "version", args: []string{"--version"}, want: exitOK},
+		{name: "help", args: []string{"--help"}, want: exitOK},
+		{name: "unknown flag", args: []string{"--colour"}, want: exitUsage},
+		{name: "argument", args: []string{"shop"}, want: exitUsage},
+		{name: "force and merge", args: []string{"--force", "--merge"}, want: exitUsage},
+		{name: "missing spec file", args: []string{"--yes", "--config", filepath.Join(t.TempDir(), "missing.yaml")}, want: exitInvalidConfig},
+		{name: "unknown spec key", args: []string{"--yes", "--config", writeSpec(t, "name: shop\ndatabase: mysql\n")}, want: exitInvalidConfig},
+		{name: "invalid name", args: []string{"--yes", "--name", "2shop"}, want: exitInvalidConfig},
+		{name: "invalid module", args: []string{"--yes", "--name", "shop", "--module", "example.com/my shop"}, want: exitInvalidConfig},
+		{name: "invalid spec value overridden by a flag", args: []string{"--yes", "--config", writeSpec(t, "name: 2shop\n"), "--name", "shop", "--out", nonEmpty}, want: exitFailure},
+		{name: "output directory not empty", args: []string{"--yes", "--name", "shop", "--out", nonEmpty}, want: exitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := run(tt.args, strings.NewReader("")); got != tt.want {
+				t.Errorf("run(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
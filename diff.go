@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns a unified diff from oldText to newText, or "" if they are equal.
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk until a run of unchanged lines is long enough to split on
+		from := max(start-diffContext, 0)
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = run
+		}
+
+		oldStart, newStart := lineNumbers(ops, from)
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[from:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(withNewline(op.line))
+		}
+		start = end
+	}
+	return b.String()
+}
+
+// diffLines turns the line matching of a and b into an edit script.
+func diffLines(a, b []string) []diffOp {
+	match := matchLines(a, b)
+	var ops []diffOp
+	j := 0
+	for i, line := range a {
+		if match[i] < 0 {
+			ops = append(ops, diffOp{'-', line})
+			continue
+		}
+		for ; j < match[i]; j++ {
+			ops = append(ops, diffOp{'+', b[j]})
+		}
+		ops = append(ops, diffOp{' ', line})
+		j++
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// lineNumbers returns the 1-based old and new line numbers at ops[index].
+func lineNumbers(ops []diffOp, index int) (int, int) {
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:index] {
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+	return oldLine, newLine
+}
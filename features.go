@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// knownFeatures lists every selectable feature in the order they are reported.
+// Grouped features use "group:variant" names; the bare group name selects the
+// default variants listed in featureGroups.
+var knownFeatures = []string{
+	"auth",
+	"admin",
+	"email",
+	"pwreset",
+	"redis",
+	"storage:local",
+	"storage:s3",
+	"realtime:sse",
+	"realtime:ws",
+}
+
+// featureGroups maps a bare group name to the variants it selects.
+var featureGroups = map[string][]string{
+	"storage":  {"storage:local"},
+	"realtime": {"realtime:sse", "realtime:ws"},
+}
+
+// requiredFeatures are always enabled; the rest of the template is built on them.
+var requiredFeatures = []string{"auth"}
+
+// featureDependencies lists features that only work together with others.
+var featureDependencies = map[string][]string{
+	"pwreset": {"email"},
+}
+
+// featurePaths lists generated files and directories that only belong to a feature.
+// They are removed when the feature is disabled.
+var featurePaths = map[string][]string{
+	"admin": {
+		"api/protocol/http/handler/admin_handler.go",
+	},
+	"email": {
+		"internal/lib/email",
+		"ENV_CONFIG_EXAMPLE.md",
+	},
+	"pwreset": {
+		"internal/lib/pwreset",
+		"internal/app/user/reset.go",
+		"api/protocol/http/handler/password_reset.go",
+		"internal/db/migrations/sqlite/0003_create_password_reset_tokens.up.sql",
+		"internal/db/migrations/sqlite/0003_create_password_reset_tokens.down.sql",
+		"internal/db/migrations/mysql/0003_create_password_reset_tokens.up.sql",
+		"internal/db/migrations/mysql/0003_create_password_reset_tokens.down.sql",
+		"internal/db/migrations/postgres/0003_create_password_reset_tokens.up.sql",
+		"internal/db/migrations/postgres/0003_create_password_reset_tokens.down.sql",
+	},
+	"redis": {
+		"internal/lib/jwt/redis_blacklist.go",
+		"internal/lib/jwt/redis_refresh_store.go",
+		"internal/lib/jwt/redis_refresh_store_test.go",
+		"internal/lib/jwt/redis_valid_after_store.go",
+	},
+	"storage": {
+		"internal/lib/storage",
+		"api/protocol/http/handler/user_image.go",
+	},
+	"storage:local": {
+		"internal/lib/storage/local.go",
+	},
+	"storage:s3": {
+		"internal/lib/storage/s3.go",
+	},
+	"realtime": {
+		"api/protocol/http/routes/realtime_routes.go",
+	},
+	"realtime:sse": {
+		"api/protocol/sse",
+	},
+	"realtime:ws": {
+		"api/protocol/ws",
+	},
+}
+
+// FeatureSet is the list of features enabled for a generated project.
+type FeatureSet []string
+
+// parseFeatureList splits a comma separated feature list such as "auth,email,storage:s3".
+func parseFeatureList(value string) FeatureSet {
+	var features FeatureSet
+	for _, f := range strings.Split(value, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// UnmarshalYAML accepts either a YAML list or a comma separated string.
+func (f *FeatureSet) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*f = parseFeatureList(node.Value)
+		return nil
+	}
+
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*f = parseFeatureList(strings.Join(list, ","))
+	return nil
+}
+
+// Has reports whether name is enabled. A bare group name such as "storage"
+// matches when any of its variants is enabled.
+func (f FeatureSet) Has(name string) bool {
+	for _, feature := range f {
+		if feature == name || strings.HasPrefix(feature, name+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the features as a comma separated list.
+func (f FeatureSet) String() string {
+	return strings.Join(f, ",")
+}
+
+// normalized expands group names, adds required features, drops duplicates
+// and sorts the result in knownFeatures order. Unknown names are kept so that
+// validate can report them.
+func (f FeatureSet) normalized() FeatureSet {
+	seen := make(map[string]bool)
+	var result FeatureSet
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+
+	for _, name := range requiredFeatures {
+		add(name)
+	}
+	for _, name := range f {
+		name = strings.ToLower(name)
+		if variants, ok := featureGroups[name]; ok {
+			for _, v := range variants {
+				add(v)
+			}
+			continue
+		}
+		add(name)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return featureIndex(result[i]) < featureIndex(result[j])
+	})
+	return result
+}
+
+// validate reports unknown features and missing dependencies.
+func (f FeatureSet) validate() []error {
+	var errs []error
+	for _, name := range f {
+		if featureIndex(name) == len(knownFeatures) {
+			errs = append(errs, fmt.Errorf("unknown feature %q (choose from %s)", name, strings.Join(knownFeatures, ", ")))
+		}
+	}
+	for _, name := range f {
+		for _, dep := range featureDependencies[name] {
+			if !f.Has(dep) {
+				errs = append(errs, fmt.Errorf("feature %q requires %q", name, dep))
+			}
+		}
+	}
+	return errs
+}
+
+func featureIndex(name string) int {
+	for i, known := range knownFeatures {
+		if known == name {
+			return i
+		}
+	}
+	return len(knownFeatures)
+}
+
+// isFeatureName reports whether name is a feature or a feature group.
+func isFeatureName(name string) bool {
+	if _, ok := featureGroups[name]; ok {
+		return true
+	}
+	return featureIndex(name) < len(knownFeatures)
+}
+
+// databasePaths lists generated files that only belong to some database
+// drivers. They are removed for every other driver.
+var databasePaths = map[string][]string{
+	"init-db.sql": {"mysql", "postgres"},
+}
+
+// pruneFeatures removes the files of disabled features and of other database
+// drivers from the generated project, and strips the code between
+// "// goinit:if <feature>" and "// goinit:end" markers for every feature that
+// is not enabled.
+func pruneFeatures(projectPath string, config ProjectConfig) error {
+	if len(config.Features) > 0 {
+		fmt.Printf("✂️  Enabled features: %s\n", config.Features)
+	}
+
+	for feature, paths := range featurePaths {
+		if config.Features.Has(feature) {
+			continue
+		}
+		for _, path := range paths {
+			if err := os.RemoveAll(filepath.Join(projectPath, filepath.FromSlash(path))); err != nil {
+				return fmt.Errorf("failed to remove %s: %v", path, err)
+			}
+		}
+	}
+
+	for path, drivers := range databasePaths {
+		if slices.Contains(drivers, config.DatabaseDriver) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(projectPath, filepath.FromSlash(path))); err != nil {
+			return fmt.Errorf("failed to remove %s: %v", path, err)
+		}
+	}
+
+	return filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(content, []byte("goinit:")) {
+			return nil
+		}
+
+		pruned, err := applyFeatureMarkers(content, config.Features)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		formatted, err := format.Source(pruned)
+		if err != nil {
+			return fmt.Errorf("failed to format %s after pruning: %v", path, err)
+		}
+		return os.WriteFile(path, formatted, info.Mode())
+	})
+}
+
+var featureMarkerPattern = regexp.MustCompile(`^\s*//\s*goinit:(if|end)\b\s*(.*?)\s*$`)
+
+// applyFeatureMarkers drops the marker lines from src together with every
+// block whose feature is disabled. Blocks may be nested.
+func applyFeatureMarkers(src []byte, features FeatureSet) ([]byte, error) {
+	var out bytes.Buffer
+	var stack []bool // whether each open block is kept
+	keep := true
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(src)+1)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+
+		m := featureMarkerPattern.FindStringSubmatch(line)
+		if m == nil {
+			if keep {
+				out.WriteString(line)
+				out.WriteByte('\n')
+			}
+			continue
+		}
+
+		switch m[1] {
+		case "if":
+			if !isFeatureName(m[2]) {
+				return nil, fmt.Errorf("line %d: unknown feature %q in marker", lineNo, m[2])
+			}
+			stack = append(stack, keep)
+			keep = keep && features.Has(m[2])
+		case "end":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: goinit:end without goinit:if", lineNo)
+			}
+			keep = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("%d goinit:if marker(s) without goinit:end", len(stack))
+	}
+
+	return out.Bytes(), nil
+}
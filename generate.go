@@ -0,0 +1,738 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"golang.org/x/mod/modfile"
+)
+
+//go:embed scaffold
+var scaffoldFS embed.FS
+
+// Anchor comments in a generated project where scaffolded code is wired in.
+const (
+	scaffoldAnchorProviders = "// goinit:scaffold:providers"
+	scaffoldAnchorDI        = "// goinit:scaffold:di"
+	scaffoldAnchorRoutes    = "// goinit:scaffold:routes"
+	scaffoldAnchorModels    = "// goinit:scaffold:models"
+)
+
+// providersFile lists the constructors wired by cmd/diwire. Projects
+// generated before it existed register resources in container.go instead.
+var providersFile = filepath.Join("internal", "di", "providers.go")
+
+var (
+	resourceNamePattern  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+	fieldNamePattern     = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	migrationFilePattern = regexp.MustCompile(`^(\d+)_[a-z0-9_]+\.(up|down)\.sql$`)
+)
+
+// scaffoldFieldTypes maps the field types accepted on the command line to Go types.
+var scaffoldFieldTypes = map[string]string{
+	"string":  "string",
+	"text":    "string",
+	"int":     "int",
+	"int32":   "int32",
+	"int64":   "int64",
+	"uint":    "uint",
+	"float32": "float32",
+	"float64": "float64",
+	"bool":    "bool",
+	"time":    "time.Time",
+}
+
+// reservedFieldNames are provided by model.Base on every resource.
+var reservedFieldNames = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+// commonInitialisms are kept upper case when building Go identifiers.
+var commonInitialisms = map[string]bool{
+	"ID": true, "URL": true, "URI": true, "API": true, "HTTP": true, "JSON": true,
+	"IP": true, "UUID": true, "SQL": true, "HTML": true, "SKU": true,
+}
+
+// resourceSpec describes the resource being scaffolded; it is the data passed to the scaffold templates.
+type resourceSpec struct {
+	Module  string // module path of the target project
+	Name    string // exported type name, e.g. "Product"
+	Package string // package and directory name, e.g. "product"
+	Var     string // local variable name, e.g. "product"
+	Plural  string // table name, e.g. "order_items"
+	Path    string // URL segment under /api, e.g. "order-items"
+	Fields  []resourceField
+}
+
+type resourceField struct {
+	Name     string // Go field name, e.g. "OwnerID"
+	Column   string // column and JSON name, e.g. "owner_id"
+	Kind     string // type given on the command line, e.g. "string" or "ref"
+	GoType   string // Go type, e.g. "string"
+	Ref      string // referenced resource package for ref fields, e.g. "user"
+	RefType  string // referenced resource type for ref fields, e.g. "User"
+	RefTable string // referenced table for ref fields, e.g. "users"
+	Assoc    string // association field name for ref fields, e.g. "Owner"
+	SelfRef  bool   // the field references the resource being generated
+}
+
+// NeedsTime reports whether any field is a time.Time.
+func (r resourceSpec) NeedsTime() bool {
+	for _, f := range r.Fields {
+		if f.GoType == "time.Time" {
+			return true
+		}
+	}
+	return false
+}
+
+// Refs returns the distinct other resources referenced by ref fields.
+func (r resourceSpec) Refs() []resourceField {
+	seen := make(map[string]bool)
+	var refs []resourceField
+	for _, f := range r.Fields {
+		if f.Ref != "" && !f.SelfRef && !seen[f.Ref] {
+			seen[f.Ref] = true
+			refs = append(refs, f)
+		}
+	}
+	return refs
+}
+
+// RefFields returns the ref fields, including references to the resource itself.
+func (r resourceSpec) RefFields() []resourceField {
+	var refs []resourceField
+	for _, f := range r.Fields {
+		if f.Ref != "" {
+			refs = append(refs, f)
+		}
+	}
+	return refs
+}
+
+// GormTag returns the gorm struct tag options for the field.
+func (f resourceField) GormTag() string {
+	switch f.Kind {
+	case "string":
+		return "size:255"
+	case "text":
+		return "type:text"
+	case "ref":
+		return "type:varchar(32);index"
+	default:
+		return ""
+	}
+}
+
+// Required reports whether the field is required when creating a resource.
+func (f resourceField) Required() bool {
+	return f.Kind == "string" || f.Kind == "ref"
+}
+
+// sqlFieldTypes maps the field types accepted on the command line to column
+// types for each database driver.
+var sqlFieldTypes = map[string]map[string]string{
+	"sqlite": {
+		"string": "VARCHAR(255)", "text": "TEXT", "ref": "VARCHAR(32)",
+		"int": "INTEGER", "int32": "INTEGER", "int64": "INTEGER", "uint": "INTEGER",
+		"float32": "REAL", "float64": "REAL", "bool": "BOOLEAN", "time": "DATETIME",
+	},
+	"mysql": {
+		"string": "VARCHAR(255)", "text": "TEXT", "ref": "VARCHAR(32)",
+		"int": "BIGINT", "int32": "INT", "int64": "BIGINT", "uint": "BIGINT UNSIGNED",
+		"float32": "FLOAT", "float64": "DOUBLE", "bool": "BOOLEAN", "time": "DATETIME(3)",
+	},
+	"postgres": {
+		"string": "VARCHAR(255)", "text": "TEXT", "ref": "VARCHAR(32)",
+		"int": "BIGINT", "int32": "INTEGER", "int64": "BIGINT", "uint": "BIGINT",
+		"float32": "REAL", "float64": "DOUBLE PRECISION", "bool": "BOOLEAN", "time": "TIMESTAMPTZ",
+	},
+}
+
+// migrationSpec is the data passed to the migration scaffold templates.
+type migrationSpec struct {
+	resourceSpec
+	Driver string
+}
+
+// SQLType returns the column type of f for the driver.
+func (m migrationSpec) SQLType(f resourceField) string {
+	return sqlFieldTypes[m.Driver][f.Kind]
+}
+
+// TimeType returns the column type of the created_at and updated_at columns.
+func (m migrationSpec) TimeType() string {
+	return sqlFieldTypes[m.Driver]["time"]
+}
+
+// scaffoldFile maps a scaffold template to the file it produces.
+type scaffoldFile struct {
+	template string
+	path     string
+}
+
+// migrationFile is a scaffolded migration for one database driver.
+type migrationFile struct {
+	scaffoldFile
+	driver string
+}
+
+func (r resourceSpec) files() []scaffoldFile {
+	p := r.Package
+	return []scaffoldFile{
+		{"domain_model.go.tmpl", filepath.Join("internal", "domain", p, "model", p+".go")},
+		{"domain_repo.go.tmpl", filepath.Join("internal", "domain", p, "repo", p+".go")},
+		{"data_gorm.go.tmpl", filepath.Join("internal", "data", p, "model", "gorm", p+"_gorm.go")},
+		{"data_repo.go.tmpl", filepath.Join("internal", "data", p, "repo", p+"_repo.go")},
+		{"app_service.go.tmpl", filepath.Join("internal", "app", p, p+"_service.go")},
+		{"dto.go.tmpl", filepath.Join("api", "common", "dto", p+"_dto.go")},
+		{"handler.go.tmpl", filepath.Join("api", "protocol", "http", "handler", p+"_handler.go")},
+		{"routes.go.tmpl", filepath.Join("api", "protocol", "http", "routes", p+"_routes.go")},
+	}
+}
+
+// migrationFiles returns the up and down migration of every driver, numbered
+// after the newest migration in the project.
+func (r resourceSpec) migrationFiles(dir string) ([]migrationFile, error) {
+	migrationsDir := filepath.Join(dir, "internal", "db", "migrations")
+	version, err := nextMigrationVersion(migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []migrationFile
+	for _, driver := range []string{"sqlite", "mysql", "postgres"} {
+		for _, direction := range []string{"up", "down"} {
+			name := fmt.Sprintf("%04d_create_%s.%s.sql", version, r.Plural, direction)
+			files = append(files, migrationFile{
+				scaffoldFile: scaffoldFile{
+					template: "migration." + direction + ".sql.tmpl",
+					path:     filepath.Join("internal", "db", "migrations", driver, name),
+				},
+				driver: driver,
+			})
+		}
+	}
+	return files, nil
+}
+
+// nextMigrationVersion returns the version after the newest migration of any driver under dir.
+func nextMigrationVersion(dir string) (int, error) {
+	latest := 0
+	for _, driver := range []string{"sqlite", "mysql", "postgres"} {
+		entries, err := os.ReadDir(filepath.Join(dir, driver))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range entries {
+			m := migrationFilePattern.FindStringSubmatch(e.Name())
+			if m == nil {
+				continue
+			}
+			if v, err := strconv.Atoi(m[1]); err == nil && v > latest {
+				latest = v
+			}
+		}
+	}
+	return latest + 1, nil
+}
+
+// migrationExists reports whether a create migration for the table is already in dir.
+func migrationExists(dir, table string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "internal", "db", "migrations", "*", "*_create_"+table+".up.sql"))
+	return len(matches) > 0
+}
+
+// runGenerate implements "goinit generate <kind> ...".
+func runGenerate(args []string) int {
+	if len(args) == 0 || args[0] != "resource" {
+		fmt.Fprintln(os.Stderr, "❌ usage: goinit generate resource <Name> [field:type ...]")
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("goinit generate resource", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("dir", ".", "project directory")
+	force := fs.Bool("force", false, "overwrite existing files")
+
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitUsage
+	}
+	if len(positional) == 0 {
+		fmt.Fprintln(os.Stderr, "❌ usage: goinit generate resource <Name> [field:type ...]")
+		return exitUsage
+	}
+
+	modulePath, err := readModulePath(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFailure
+	}
+
+	spec, err := parseResourceSpec(modulePath, positional[0], positional[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid resource:\n%v\n", err)
+		return exitInvalidConfig
+	}
+
+	if err := generateResource(*dir, spec, *force); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error generating resource: %v\n", err)
+		return exitFailure
+	}
+
+	regenerateWiring(*dir)
+	regenerateSwagger(*dir)
+
+	fmt.Printf("\n✅ Resource %s generated\n", spec.Name)
+	fmt.Printf("📚 Endpoints: /api/%s/\n", spec.Path)
+	fmt.Println("🗄️  Apply the new migration with 'go run ./cmd/api migrate up'")
+	return exitOK
+}
+
+// parseInterspersed parses flags that may appear before, between or after positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// readModulePath returns the module path declared in dir/go.mod.
+func readModulePath(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("no go.mod found in %s; run this command inside a generated project", dir)
+	}
+	modulePath := modfile.ModulePath(data)
+	if modulePath == "" {
+		return "", fmt.Errorf("go.mod in %s has no module declaration", dir)
+	}
+	return modulePath, nil
+}
+
+// parseResourceSpec validates the resource name and field definitions.
+func parseResourceSpec(modulePath, name string, fieldArgs []string) (resourceSpec, error) {
+	var errs []error
+	if !resourceNamePattern.MatchString(name) {
+		errs = append(errs, fmt.Errorf("resource name %q must start with a letter and contain only letters and digits", name))
+	}
+
+	spec := resourceSpec{
+		Module:  modulePath,
+		Name:    upperFirst(name),
+		Package: strings.ToLower(name),
+		Var:     lowerFirst(name),
+		Plural:  pluralize(toSnake(name)),
+	}
+	spec.Path = strings.ReplaceAll(spec.Plural, "_", "-")
+
+	seen := make(map[string]bool)
+	for _, arg := range fieldArgs {
+		field, err := parseResourceField(arg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if seen[field.Column] {
+			errs = append(errs, fmt.Errorf("field %q is defined more than once", field.Column))
+			continue
+		}
+		seen[field.Column] = true
+		if field.Ref == spec.Package {
+			field.SelfRef = true
+			field.RefType = spec.Name
+		}
+		spec.Fields = append(spec.Fields, field)
+	}
+
+	return spec, errors.Join(errs...)
+}
+
+// parseResourceField parses "name:type" or "name_id:ref:resource".
+func parseResourceField(arg string) (resourceField, error) {
+	parts := strings.Split(arg, ":")
+	if len(parts) < 2 {
+		return resourceField{}, fmt.Errorf("field %q must be written as name:type", arg)
+	}
+
+	column, kind := parts[0], parts[1]
+	if !fieldNamePattern.MatchString(column) {
+		return resourceField{}, fmt.Errorf("field name %q must be lower snake_case", column)
+	}
+	if reservedFieldNames[column] {
+		return resourceField{}, fmt.Errorf("field %q is provided by every resource", column)
+	}
+
+	field := resourceField{Name: toCamel(column), Column: column, Kind: kind}
+
+	if kind == "ref" {
+		if len(parts) != 3 || !resourceNamePattern.MatchString(parts[2]) {
+			return resourceField{}, fmt.Errorf("field %q must be written as name_id:ref:resource", arg)
+		}
+		if !strings.HasSuffix(column, "_id") {
+			return resourceField{}, fmt.Errorf("reference field %q must end in _id", column)
+		}
+		field.GoType = "string"
+		field.Ref = strings.ToLower(parts[2])
+		field.RefType = upperFirst(parts[2])
+		field.RefTable = pluralize(toSnake(parts[2]))
+		field.Assoc = toCamel(strings.TrimSuffix(column, "_id"))
+		return field, nil
+	}
+
+	if len(parts) != 2 {
+		return resourceField{}, fmt.Errorf("field %q must be written as name:type", arg)
+	}
+	goType, ok := scaffoldFieldTypes[kind]
+	if !ok {
+		return resourceField{}, fmt.Errorf("field %q has unsupported type %q (choose from string, text, int, int32, int64, uint, float32, float64, bool, time or ref:<resource>)", column, kind)
+	}
+	field.GoType = goType
+	return field, nil
+}
+
+// generateResource writes every layer of the resource and wires it into the project.
+func generateResource(dir string, spec resourceSpec, force bool) error {
+	// Check everything up front so a failure leaves the project untouched
+	for _, ref := range spec.Refs() {
+		refModel := filepath.Join(dir, "internal", "data", ref.Ref, "model", "gorm")
+		if _, err := os.Stat(refModel); err != nil {
+			return fmt.Errorf("field %s references %q but %s does not exist", ref.Column, ref.Ref, refModel)
+		}
+	}
+
+	files := spec.files()
+	if !force {
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(dir, f.path)); err == nil {
+				return fmt.Errorf("%s already exists (use --force to overwrite)", f.path)
+			}
+		}
+	}
+
+	// A resource regenerated with --force keeps its existing migration
+	var migrations []migrationFile
+	if !migrationExists(dir, spec.Plural) {
+		var err error
+		if migrations, err = spec.migrationFiles(dir); err != nil {
+			return err
+		}
+	}
+
+	type wire struct {
+		path   string
+		anchor string
+		insert func(src []byte) ([]byte, error)
+	}
+	wiring := []wire{
+		{providersFile, scaffoldAnchorProviders, spec.wireProviders},
+		{filepath.Join("api", "protocol", "http", "router", "router.go"), scaffoldAnchorRoutes, spec.wireRoutes},
+		{filepath.Join("cmd", "api", "migrate.go"), scaffoldAnchorModels, spec.wireModels},
+	}
+	if _, err := os.Stat(filepath.Join(dir, providersFile)); err != nil {
+		wiring[0] = wire{filepath.Join("internal", "di", "container.go"), scaffoldAnchorDI, spec.wireDI}
+	}
+
+	wired := make(map[string][]byte)
+	for _, w := range wiring {
+		src, err := os.ReadFile(filepath.Join(dir, w.path))
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(src, []byte(w.anchor)) {
+			return fmt.Errorf("%s has no %q anchor; add it where generated code should be wired in", w.path, w.anchor)
+		}
+		out, err := w.insert(src)
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %v", w.path, err)
+		}
+		wired[w.path] = out
+	}
+
+	// Render the new files
+	for _, f := range files {
+		content, err := renderScaffold(f.template, spec)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dir, f.path)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, content, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("  ✨ created %s\n", f.path)
+	}
+
+	for _, f := range migrations {
+		content, err := renderMigration(f.template, migrationSpec{resourceSpec: spec, Driver: f.driver})
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dir, f.path)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, content, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("  ✨ created %s\n", f.path)
+	}
+
+	for _, w := range wiring {
+		if err := os.WriteFile(filepath.Join(dir, w.path), wired[w.path], 0644); err != nil {
+			return err
+		}
+		fmt.Printf("  🔧 updated %s\n", w.path)
+	}
+
+	return nil
+}
+
+func renderScaffold(name string, spec resourceSpec) ([]byte, error) {
+	out, err := executeScaffold(name, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("scaffold %s produced invalid Go: %v", name, err)
+	}
+	return formatted, nil
+}
+
+func renderMigration(name string, spec migrationSpec) ([]byte, error) {
+	out, err := executeScaffold(name, spec)
+	if err != nil {
+		return nil, err
+	}
+	return append(bytes.TrimSpace(out), '\n'), nil
+}
+
+func executeScaffold(name string, data any) ([]byte, error) {
+	text, err := scaffoldFS.ReadFile("scaffold/" + name)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scaffold %s: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render scaffold %s: %v", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// The wire functions leave src unchanged when the resource is already wired in,
+// so regenerating with --force does not register it twice.
+
+func (r resourceSpec) wireProviders(src []byte) ([]byte, error) {
+	if bytes.Contains(src, []byte(r.Package+"App.New"+r.Name+"Service,")) {
+		return src, nil
+	}
+	code := fmt.Sprintf("%[1]sData.New%[2]sRepositoryGORM,\n\t%[1]sApp.New%[2]sService,\n\t", r.Package, r.Name)
+	src = insertBeforeAnchor(src, scaffoldAnchorProviders, code)
+	src = addImport(src, r.Package+"App", r.Module+"/internal/app/"+r.Package)
+	src = addImport(src, r.Package+"Data", r.Module+"/internal/data/"+r.Package+"/repo")
+	return format.Source(src)
+}
+
+// wireDI registers the resource in container.go of projects without providers.go.
+func (r resourceSpec) wireDI(src []byte) ([]byte, error) {
+	if bytes.Contains(src, []byte(r.Package+"App.New"+r.Name+"Service(")) {
+		return src, nil
+	}
+	code := fmt.Sprintf(`// Register %[1]s repository
+	if err := Register[%[1]sRepo.%[2]sRepository](c, func(db *gorm.DB) %[1]sRepo.%[2]sRepository {
+		return %[1]sData.New%[2]sRepositoryGORM(db)
+	}, Singleton); err != nil {
+		return err
+	}
+
+	// Register %[1]s service
+	if err := Register[*%[1]sApp.%[2]sService](c, func(repo %[1]sRepo.%[2]sRepository) *%[1]sApp.%[2]sService {
+		return %[1]sApp.New%[2]sService(repo)
+	}, Singleton); err != nil {
+		return err
+	}
+
+	`, r.Package, r.Name)
+
+	src = insertBeforeAnchor(src, scaffoldAnchorDI, code)
+	src = addImport(src, r.Package+"App", r.Module+"/internal/app/"+r.Package)
+	src = addImport(src, r.Package+"Data", r.Module+"/internal/data/"+r.Package+"/repo")
+	src = addImport(src, r.Package+"Repo", r.Module+"/internal/domain/"+r.Package+"/repo")
+	return format.Source(src)
+}
+
+func (r resourceSpec) wireRoutes(src []byte) ([]byte, error) {
+	if bytes.Contains(src, []byte("routes.Setup"+r.Name+"Routes(")) {
+		return src, nil
+	}
+	code := fmt.Sprintf("// %s routes\n\troutes.Setup%sRoutes(router, jwtSvc)\n\n\t", r.Name, r.Name)
+	return format.Source(insertBeforeAnchor(src, scaffoldAnchorRoutes, code))
+}
+
+func (r resourceSpec) wireModels(src []byte) ([]byte, error) {
+	if bytes.Contains(src, []byte("&"+r.Package+"Gorm."+r.Name+"GORM{}")) {
+		return src, nil
+	}
+	code := fmt.Sprintf("&%sGorm.%sGORM{},\n\t\t", r.Package, r.Name)
+	src = insertBeforeAnchor(src, scaffoldAnchorModels, code)
+	src = addImport(src, r.Package+"Gorm", r.Module+"/internal/data/"+r.Package+"/model/gorm")
+	return format.Source(src)
+}
+
+// insertBeforeAnchor inserts code right before the anchor comment, keeping the anchor for the next resource.
+func insertBeforeAnchor(src []byte, anchor, code string) []byte {
+	return bytes.Replace(src, []byte(anchor), []byte(code+anchor), 1)
+}
+
+// addImport adds an import to the first import block of src unless it is already imported.
+func addImport(src []byte, name, path string) []byte {
+	quoted := []byte(`"` + path + `"`)
+	if bytes.Contains(src, quoted) {
+		return src
+	}
+
+	start := bytes.Index(src, []byte("import ("))
+	if start < 0 {
+		return src
+	}
+	end := bytes.Index(src[start:], []byte("\n)"))
+	if end < 0 {
+		return src
+	}
+	end += start + 1
+
+	line := fmt.Sprintf("\t%s %s\n", name, quoted)
+	out := make([]byte, 0, len(src)+len(line))
+	out = append(out, src[:end]...)
+	out = append(out, line...)
+	out = append(out, src[end:]...)
+	return out
+}
+
+// regenerateWiring regenerates internal/di/wire_gen.go from providers.go.
+func regenerateWiring(dir string) {
+	if _, err := os.Stat(filepath.Join(dir, providersFile)); err != nil {
+		return
+	}
+
+	fmt.Println("🔌 Regenerating DI wiring...")
+	cmd := exec.Command("go", "generate", "./internal/di")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  go generate failed: %v\nOutput: %s\n", err, string(output))
+		fmt.Println("   fix the error and run 'go generate ./internal/di' to register the resource")
+		return
+	}
+	fmt.Println("✅ DI wiring updated")
+}
+
+// regenerateSwagger refreshes docs/ with swag when it is installed.
+func regenerateSwagger(dir string) {
+	if _, err := exec.LookPath("swag"); err != nil {
+		fmt.Println("ℹ️  swag not found; install it with 'go install github.com/swaggo/swag/cmd/swag@latest'")
+		fmt.Println("   and run 'swag init -g cmd/api/main.go -o ./docs' to update the API docs")
+		return
+	}
+
+	fmt.Println("📚 Regenerating swagger docs...")
+	cmd := exec.Command("swag", "init", "-g", "cmd/api/main.go", "-o", "./docs")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  swag init failed: %v\nOutput: %s\n", err, string(output))
+		return
+	}
+	fmt.Println("✅ Swagger docs updated")
+}
+
+// toCamel converts snake_case to an exported Go identifier, e.g. "owner_id" -> "OwnerID".
+func toCamel(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+		if upper := strings.ToUpper(part); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(upperFirst(part))
+	}
+	return b.String()
+}
+
+// toSnake converts CamelCase to snake_case, e.g. "OrderItem" -> "order_item".
+func toSnake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// pluralize returns a simple English plural, e.g. "category" -> "categories".
+func pluralize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "z"),
+		strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	default:
+		return s + "s"
+	}
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
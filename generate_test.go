@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseResourceSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		want resourceSpec
+	}{
+		{name: "Product", want: resourceSpec{Name: "Product", Package: "product", Var: "product", Plural: "products", Path: "products"}},
+		{name: "OrderItem", want: resourceSpec{Name: "OrderItem", Package: "orderitem", Var: "orderItem", Plural: "order_items", Path: "order-items"}},
+		{name: "category", want: resourceSpec{Name: "Category", Package: "category", Var: "category", Plural: "categories", Path: "categories"}},
+		{name: "Box", want: resourceSpec{Name: "Box", Package: "box", Var: "box", Plural: "boxes", Path: "boxes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseResourceSpec("example.com/shop", tt.name, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.Module = "example.com/shop"
+			if got.Module != tt.want.Module || got.Name != tt.want.Name || got.Package != tt.want.Package ||
+				got.Var != tt.want.Var || got.Plural != tt.want.Plural || got.Path != tt.want.Path {
+				t.Errorf("parseResourceSpec = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResourceSpecInvalidName(t *testing.T) {
+	for _, name := range []string{"order_item", "2fa", "Order-Item", ""} {
+		if _, err := parseResourceSpec("example.com/shop", name, nil); err == nil {
+			t.Errorf("parseResourceSpec accepted the name %q", name)
+		}
+	}
+}
+
+func TestParseResourceField(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    resourceField
+		wantErr string
+	}{
+		{arg: "name:string", want: resourceField{Name: "Name", Column: "name", Kind: "string", GoType: "string"}},
+		{arg: "price:float64", want: resourceField{Name: "Price", Column: "price", Kind: "float64", GoType: "float64"}},
+		{arg: "released_at:time", want: resourceField{Name: "ReleasedAt", Column: "released_at", Kind: "time", GoType: "time.Time"}},
+		{arg: "image_url:text", want: resourceField{Name: "ImageURL", Column: "image_url", Kind: "text", GoType: "string"}},
+		{
+			arg: "owner_id:ref:user",
+			want: resourceField{
+				Name: "OwnerID", Column: "owner_id", Kind: "ref", GoType: "string",
+				Ref: "user", RefType: "User", RefTable: "users", Assoc: "Owner",
+			},
+		},
+		{
+			arg: "parent_id:ref:OrderItem",
+			want: resourceField{
+				Name: "ParentID", Column: "parent_id", Kind: "ref", GoType: "string",
+				Ref: "orderitem", RefType: "OrderItem", RefTable: "order_items", Assoc: "Parent",
+			},
+		},
+		{arg: "name", wantErr: "must be written as name:type"},
+		{arg: "name:string:extra", wantErr: "must be written as name:type"},
+		{arg: "Name:string", wantErr: "must be lower snake_case"},
+		{arg: "id:string", wantErr: "provided by every resource"},
+		{arg: "created_at:time", wantErr: "provided by every resource"},
+		{arg: "price:decimal", wantErr: `unsupported type "decimal"`},
+		{arg: "owner:ref:user", wantErr: "must end in _id"},
+		{arg: "owner_id:ref", wantErr: "must be written as name_id:ref:resource"},
+		{arg: "owner_id:ref:bad_name", wantErr: "must be written as name_id:ref:resource"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := parseResourceField(tt.arg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseResourceField = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("parseResourceField =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResourceSpecSelfRef(t *testing.T) {
+	spec, err := parseResourceSpec("example.com/shop", "Category", []string{"parent_id:ref:category", "owner_id:ref:user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent := spec.Fields[0]; !parent.SelfRef || parent.RefType != "Category" {
+		t.Errorf("parent_id = %+v, want a reference to the resource itself", parent)
+	}
+	if refs := spec.Refs(); len(refs) != 1 || refs[0].Ref != "user" {
+		t.Errorf("Refs = %+v, want only user: the resource itself needs no import", refs)
+	}
+	if refs := spec.RefFields(); len(refs) != 2 {
+		t.Errorf("RefFields = %+v, want both ref fields", refs)
+	}
+}
+
+func TestParseResourceSpecReportsEveryField(t *testing.T) {
+	_, err := parseResourceSpec("example.com/shop", "Product", []string{"name:string", "name:text", "price:decimal"})
+	if err == nil {
+		t.Fatal("parseResourceSpec accepted invalid fields")
+	}
+	for _, want := range []string{`field "name" is defined more than once`, `unsupported type "decimal"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not report %q", err, want)
+		}
+	}
+}
+
+func TestNaming(t *testing.T) {
+	tests := []struct {
+		fn   func(string) string
+		name string
+		in   string
+		want string
+	}{
+		{toCamel, "toCamel", "owner_id", "OwnerID"},
+		{toCamel, "toCamel", "api_url", "APIURL"},
+		{toCamel, "toCamel", "released_at", "ReleasedAt"},
+		{toCamel, "toCamel", "name", "Name"},
+		{toSnake, "toSnake", "OrderItem", "order_item"},
+		{toSnake, "toSnake", "product", "product"},
+		{pluralize, "pluralize", "product", "products"},
+		{pluralize, "pluralize", "category", "categories"},
+		{pluralize, "pluralize", "day", "days"},
+		{pluralize, "pluralize", "box", "boxes"},
+		{pluralize, "pluralize", "address", "addresses"},
+		{pluralize, "pluralize", "batch", "batches"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.fn(tt.in); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNextMigrationVersion(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"sqlite/0001_init.up.sql", "mysql/0003_create_tags.down.sql", "postgres/0002_x.up.sql", "postgres/README.md"} {
+		if err := writeFileAll(filepath.Join(dir, filepath.FromSlash(f)), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, err := nextMigrationVersion(dir); err != nil || got != 4 {
+		t.Errorf("nextMigrationVersion = %d, %v, want 4 (after the newest migration of any driver)", got, err)
+	}
+	if got, err := nextMigrationVersion(t.TempDir()); err != nil || got != 1 {
+		t.Errorf("nextMigrationVersion without migrations = %d, %v, want 1", got, err)
+	}
+}
+
+func TestAddImport(t *testing.T) {
+	src := []byte("package p\n\nimport (\n\t\"fmt\"\n)\n")
+
+	got := addImport(src, "productApp", "example.com/shop/internal/app/product")
+	if !bytes.Contains(got, []byte("\tproductApp \"example.com/shop/internal/app/product\"\n)")) {
+		t.Errorf("addImport did not add the import to the block:\n%s", got)
+	}
+	if again := addImport(got, "productApp", "example.com/shop/internal/app/product"); !bytes.Equal(again, got) {
+		t.Errorf("addImport imported the package twice:\n%s", again)
+	}
+}
+
+// renderResourceProject renders the gin-full template into a directory to
+// scaffold resources in
+func renderResourceProject(t *testing.T) string {
+	t.Helper()
+	config := applyDefaults(ProjectConfig{ProjectName: "shop", ModuleName: "example.com/shop"})
+	dir := t.TempDir()
+	if err := renderTemplate(dir, config); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func mustParseResource(t *testing.T, name string, fields ...string) resourceSpec {
+	t.Helper()
+	spec, err := parseResourceSpec("example.com/shop", name, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// readFiles returns the content of the files under dir, keyed by their path
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		files[path] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestWireIdempotent(t *testing.T) {
+	dir := renderResourceProject(t)
+	spec := mustParseResource(t, "Product", "name:string")
+
+	wiring := []struct {
+		path string
+		wire func([]byte) ([]byte, error)
+		want string
+	}{
+		{providersFile, spec.wireProviders, "productApp.NewProductService,"},
+		{filepath.Join("api", "protocol", "http", "router", "router.go"), spec.wireRoutes, "routes.SetupProductRoutes(router, jwtSvc)"},
+		{filepath.Join("cmd", "api", "migrate.go"), spec.wireModels, "&productGorm.ProductGORM{},"},
+	}
+
+	for _, w := range wiring {
+		t.Run(filepath.Base(w.path), func(t *testing.T) {
+			src, err := os.ReadFile(filepath.Join(dir, w.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			once, err := w.wire(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := strings.Count(string(once), w.want); n != 1 {
+				t.Fatalf("wired %s %d times, want once:\n%s", w.want, n, once)
+			}
+			twice, err := w.wire(once)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(twice, once) {
+				t.Errorf("wiring an already wired resource changed %s:\n%s", w.path, twice)
+			}
+		})
+	}
+}
+
+func TestGenerateResource(t *testing.T) {
+	dir := renderResourceProject(t)
+	version, err := nextMigrationVersion(filepath.Join(dir, "internal", "db", "migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := mustParseResource(t, "Product", "name:string", "owner_id:ref:user")
+	if err := generateResource(dir, spec, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range spec.files() {
+		if _, err := os.Stat(filepath.Join(dir, f.path)); err != nil {
+			t.Errorf("%s was not generated: %v", f.path, err)
+		}
+	}
+	migrations, err := filepath.Glob(filepath.Join(dir, "internal", "db", "migrations", "*", "*_create_products.*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 6 {
+		t.Fatalf("generated migrations %v, want an up and a down migration for every driver", migrations)
+	}
+	for _, m := range migrations {
+		if want := fmt.Sprintf("%04d_", version); !strings.HasPrefix(filepath.Base(m), want) {
+			t.Errorf("migration %s is not numbered %s", filepath.Base(m), want)
+		}
+	}
+}
+
+func TestGenerateResourceExists(t *testing.T) {
+	dir := renderResourceProject(t)
+	spec := mustParseResource(t, "Product", "name:string")
+	if err := generateResource(dir, spec, false); err != nil {
+		t.Fatal(err)
+	}
+	before := readFiles(t, dir)
+
+	err := generateResource(dir, mustParseResource(t, "Product", "title:string"), false)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("generateResource = %v, want it to refuse to overwrite without --force", err)
+	}
+	if after := readFiles(t, dir); !maps.Equal(after, before) {
+		t.Error("generateResource changed the project although it failed")
+	}
+}
+
+func TestGenerateResourceForce(t *testing.T) {
+	dir := renderResourceProject(t)
+	if err := generateResource(dir, mustParseResource(t, "Product", "name:string"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateResource(dir, mustParseResource(t, "Product", "title:string"), true); err != nil {
+		t.Fatal(err)
+	}
+
+	model, err := os.ReadFile(filepath.Join(dir, "internal", "domain", "product", "model", "product.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(model), "Title") {
+		t.Errorf("--force did not regenerate the model:\n%s", model)
+	}
+	migrations, _ := filepath.Glob(filepath.Join(dir, "internal", "db", "migrations", "sqlite", "*_create_products.up.sql"))
+	if len(migrations) != 1 {
+		t.Errorf("sqlite migrations %v, want the existing migration kept", migrations)
+	}
+	router, err := os.ReadFile(filepath.Join(dir, "api", "protocol", "http", "router", "router.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(router), "routes.SetupProductRoutes("); n != 1 {
+		t.Errorf("routes registered %d times, want once", n)
+	}
+}
+
+func TestGenerateResourceMissingRef(t *testing.T) {
+	dir := renderResourceProject(t)
+	before := readFiles(t, dir)
+
+	err := generateResource(dir, mustParseResource(t, "Product", "brand_id:ref:brand"), false)
+	if err == nil || !strings.Contains(err.Error(), `references "brand"`) {
+		t.Fatalf("generateResource = %v, want an error for the missing brand resource", err)
+	}
+	if after := readFiles(t, dir); !maps.Equal(after, before) {
+		t.Error("generateResource changed the project although it failed")
+	}
+}
+
+func TestGenerateResourceMissingAnchor(t *testing.T) {
+	dir := renderResourceProject(t)
+	router := filepath.Join(dir, "api", "protocol", "http", "router", "router.go")
+	src, err := os.ReadFile(router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(router, bytes.ReplaceAll(src, []byte(scaffoldAnchorRoutes), nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+	before := readFiles(t, dir)
+
+	err = generateResource(dir, mustParseResource(t, "Product", "name:string"), false)
+	if err == nil || !strings.Contains(err.Error(), scaffoldAnchorRoutes) {
+		t.Fatalf("generateResource = %v, want an error naming the missing anchor", err)
+	}
+	if after := readFiles(t, dir); !maps.Equal(after, before) {
+		t.Error("generateResource changed the project although it failed")
+	}
+}
+
+func TestRunGenerateExitCodes(t *testing.T) {
+	project := renderResourceProject(t)
+	if err := os.WriteFile(filepath.Join(project, "go.mod"), []byte("module example.com/shop\n\ngo 1.24\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateResource(project, mustParseResource(t, "Product", "name:string"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "no kind", args: nil, want: exitUsage},
+		{name: "unknown kind", args: []string{"model", "Product"}, want: exitUsage},
+		{name: "no name", args: []string{"resource", "--dir", project}, want: exitUsage},
+		{name: "unknown flag", args: []string{"resource", "--bogus", "Product"}, want: exitUsage},
+		{name: "no go.mod", args: []string{"resource", "--dir", t.TempDir(), "Product"}, want: exitFailure},
+		{name: "invalid field", args: []string{"resource", "--dir", project, "Tag", "label:decimal"}, want: exitInvalidConfig},
+		{name: "existing resource", args: []string{"resource", "Product", "--dir", project, "name:string"}, want: exitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runGenerate(tt.args); got != tt.want {
+				t.Errorf("runGenerate(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGeneratedResourceBuilds scaffolds a resource in a generated project and
+// checks that the project still builds, vets and passes its tests with the
+// resource wired in.
+func TestGeneratedResourceBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates and builds a full project")
+	}
+
+	config := applyDefaults(ProjectConfig{ProjectName: "selftest-resource", ModuleName: "example.com/selftest/resource"})
+	dir := filepath.Join(t.TempDir(), config.ProjectName)
+	opts := generateOptions{Offline: true, SkipVerify: true}
+	if err := generateProject(dir, config, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"resource", "--dir", dir, "Product", "name:string", "price:float64", "released_at:time", "owner_id:ref:user", "parent_id:ref:product"}
+	if got := runGenerate(args); got != exitOK {
+		t.Fatalf("runGenerate = %d, want %d", got, exitOK)
+	}
+
+	cmd := exec.Command("go", "run", "./cmd/diwire", "-check", "-dir", "internal/di")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("DI wiring was not regenerated for the resource: %v\n%s", err, output)
+	}
+
+	env, err := opts.goEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyProject(dir, env); err != nil {
+		t.Fatalf("project with a generated resource does not verify: %v", err)
+	}
+}
@@ -27,6 +27,8 @@ type RegistrationResponse struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	// Device names the session, e.g. "Ada's phone"; defaults to the browser and OS
+	Device string `json:"device,omitempty"`
 }
 
 type LoginResponse struct {
@@ -34,11 +36,28 @@ type LoginResponse struct {
 	UserID      string `json:"user_id,omitempty"`
 	UserEmail   string `json:"user_email,omitempty"`
 	Token       string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn   int64  `json:"expires_in,omitempty"`
 	StatusCode  int    `json:"status_code"`
 	Success     bool   `json:"success,omitempty"`
 	ErrorMessage string `json:"error_message,omitempty"`
 }
 
+// Refresh DTOs
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type RefreshTokenResponse struct {
+	Message      string `json:"message,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	StatusCode   int    `json:"status_code"`
+	Success      bool   `json:"success,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
 // OTP Verification DTOs (Django's VerifyUserSerializer equivalent)
 type VerifyOTPRequest struct {
 	Email string `json:"email" binding:"required,email"`
@@ -99,11 +118,9 @@ type UserData struct {
 
 // User Management DTOs
 type UpdateUserRequest struct {
-	Username  string  `json:"username,omitempty"`
-	FirstName string  `json:"first_name,omitempty"`
-	LastName  string  `json:"last_name,omitempty"`
-	Height    float64 `json:"height,omitempty" binding:"min=0"`
-	Weight    float64 `json:"weight,omitempty" binding:"min=0"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
 }
 
 type UpdateUserResponse struct {
@@ -193,3 +210,28 @@ type PasswordResetConfirmResponse struct {
 	StatusCode int         `json:"status_code"`
 	Data       interface{} `json:"data,omitempty"`
 }
+
+// Session DTOs
+type SessionData struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type SessionListResponse struct {
+	Success    bool          `json:"success"`
+	StatusCode int           `json:"status_code"`
+	Data       []SessionData `json:"data"`
+}
+
+type SessionRevokeResponse struct {
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+	Revoked    int    `json:"revoked"`
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -38,23 +39,61 @@ func RequireAuth(jwtService jwt.JWTServiceInterface) gin.HandlerFunc {
 
 		tokenString := tokenParts[1]
 
-		// Validate token
+		// Validate token; refresh tokens are only accepted by the refresh endpoint
 		claims, err := jwtService.ValidateToken(tokenString)
-		if err != nil {
+		if err != nil || claims.Subject != "access" {
+			status, message := http.StatusUnauthorized, "Invalid or expired token"
+			switch {
+			case errors.Is(err, jwt.ErrTokenRevoked):
+				message = "Token has been revoked"
+			case errors.Is(err, jwt.ErrStoreUnavailable):
+				// Fail closed: the token may have been revoked
+				status, message = http.StatusServiceUnavailable, "Failed to validate token"
+			}
+			c.JSON(status, dto.AuthErrorResponse{
+				Error:      message,
+				Success:    false,
+				StatusCode: status,
+			})
+			c.Abort()
+			return
+		}
+
+		// Tokens of a revoked session stop working before they expire. Every
+		// access token carries its session; one without, issued before
+		// sessions existed, could not be revoked and is rejected
+		if claims.SessionID == "" {
 			c.JSON(http.StatusUnauthorized, dto.AuthErrorResponse{
-				Error:      "Invalid or expired token",
+				Error:      "Token has no session, log in again",
 				Success:    false,
 				StatusCode: http.StatusUnauthorized,
 			})
 			c.Abort()
 			return
 		}
+		if err := jwtService.ValidateSession(claims.SessionID); err != nil {
+			status, message := http.StatusUnauthorized, "Session has been revoked"
+			if !errors.Is(err, jwt.ErrSessionRevoked) {
+				status, message = http.StatusServiceUnavailable, "Failed to validate session"
+			}
+			c.JSON(status, dto.AuthErrorResponse{
+				Error:      message,
+				Success:    false,
+				StatusCode: status,
+			})
+			c.Abort()
+			return
+		}
 
 		// Set user context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
-		// c.Set("is_staff", claims.IsStaff)
-		// c.Set("is_superuser", claims.IsSuperuser)
+		c.Set("session_id", claims.SessionID)
+		// Privileges come from the token, so a change to them takes effect at
+		// the user's next login or refresh
+		c.Set("is_staff", claims.IsStaff)
+		c.Set("is_superuser", claims.IsSuperuser)
+		c.Set("is_admin", claims.IsStaff || claims.IsSuperuser)
 
 		c.Next()
 	})
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/SOG-web/goinit/gin/internal/di"
+	"github.com/gin-gonic/gin"
+)
+
+// scopeKey is the gin.Context key of the request's DI scope
+const scopeKey = "di_scope"
+
+// DIScope creates a DI scope per request and disposes it once the handlers
+// have returned. The scope's context is the gin.Context, so scoped
+// constructors that take a context.Context see the request values set by
+// earlier middleware, such as user_id from RequireAuth. Scoped instances
+// must not be used after the request has finished.
+func DIScope(container *di.Container) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := container.NewScope(c)
+		c.Set(scopeKey, scope)
+		defer func() {
+			// Dispose even when the client has gone away
+			if err := scope.Dispose(context.WithoutCancel(c.Request.Context())); err != nil {
+				slog.Error("scope dispose error", "path", c.FullPath(), "err", err)
+			}
+		}()
+		c.Next()
+	}
+}
+
+// Scope returns the request's DI scope, or the global container when
+// DIScope is not installed.
+func Scope(c *gin.Context) *di.Container {
+	if scope, ok := c.Get(scopeKey); ok {
+		return scope.(*di.Container)
+	}
+	return di.DIContainer
+}
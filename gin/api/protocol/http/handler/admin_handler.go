@@ -17,7 +17,12 @@ type AdminHandler struct {
 
 
 func NewAdminHandlerDI() *AdminHandler {
-	userSvc := di.GetUserService()
+	return NewAdminHandler(di.DIContainer)
+}
+
+// NewAdminHandler creates a new AdminHandler with its services from c.
+func NewAdminHandler(c *di.Container) *AdminHandler {
+	userSvc := di.MustResolve[*userService.UserService](c)
 	return &AdminHandler{
 		userService: userSvc,
 	}
@@ -238,6 +243,7 @@ func (h *AdminHandler) ForceVerifyUser(c *gin.Context) {
 	})
 }
 
+// goinit:if email
 // SendBulkEmail sends email to multiple users (Django equivalent)
 // @Summary Send Bulk Email
 // @Description Send email to multiple users at once (admin only)
@@ -280,6 +286,8 @@ func (h *AdminHandler) SendBulkEmail(c *gin.Context) {
 	})
 }
 
+// goinit:end
+
 
 
 // Helper function to convert user model to DTO
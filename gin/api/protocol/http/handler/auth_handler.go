@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/SOG-web/goinit/gin/api/common/dto"
+	"github.com/SOG-web/goinit/gin/config"
 	userService "github.com/SOG-web/goinit/gin/internal/app/user"
 	"github.com/SOG-web/goinit/gin/internal/di"
 	userModel "github.com/SOG-web/goinit/gin/internal/domain/user/model"
@@ -18,8 +22,13 @@ type AuthHandler struct {
 
 // NewAuthHandlerDI creates a new AuthHandler using DI container.
 func NewAuthHandlerDI() *AuthHandler {
-	userSvc := di.GetUserService()
-	jwtSvc := di.MustResolve[jwt.JWTServiceInterface](di.DIContainer)
+	return NewAuthHandler(di.DIContainer)
+}
+
+// NewAuthHandler creates a new AuthHandler with its services from c.
+func NewAuthHandler(c *di.Container) *AuthHandler {
+	userSvc := di.MustResolve[*userService.UserService](c)
+	jwtSvc := di.MustResolve[jwt.JWTServiceInterface](c)
 	return &AuthHandler{
 		userService: userSvc,
 		jwtService:     jwtSvc,
@@ -167,7 +176,7 @@ func (h *AuthHandler) UserLogin(c *gin.Context) {
 	}
 
 	// Generate JWT token pair
-	tokenPair, err := h.jwtService.GenerateTokenPair(user)
+	tokenPair, err := h.jwtService.GenerateTokenPair(user, clientInfo(c, req.Device))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.LoginResponse{
 			ErrorMessage: "Failed to generate token",
@@ -182,11 +191,102 @@ func (h *AuthHandler) UserLogin(c *gin.Context) {
 		UserID:     user.ID,
 		UserEmail:  user.Email,
 		Token:      tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		ExpiresIn:  tokenPair.ExpiresIn,
 		StatusCode: http.StatusOK,
 		Success:    true,
 	})
 }
 
+// RefreshToken exchanges a refresh token for a new token pair
+// @Summary Refresh Tokens
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token can be used once; presenting a used one again revokes every token issued from the same login.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} dto.RefreshTokenResponse "New token pair"
+// @Failure 400 {object} dto.RefreshTokenResponse "Invalid request format"
+// @Failure 401 {object} dto.RefreshTokenResponse "Invalid, expired, revoked or reused refresh token"
+// @Failure 500 {object} dto.RefreshTokenResponse "Internal server error"
+// @Failure 503 {object} dto.RefreshTokenResponse "Token store unavailable"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.RefreshTokenResponse{
+			ErrorMessage: err.Error(),
+			Success:      false,
+			StatusCode:   http.StatusBadRequest,
+		})
+		return
+	}
+
+	unauthorized := func(message string) {
+		c.JSON(http.StatusUnauthorized, dto.RefreshTokenResponse{
+			ErrorMessage: message,
+			Success:      false,
+			StatusCode:   http.StatusUnauthorized,
+		})
+	}
+
+	unavailable := func() {
+		// Fail closed: the token may have been revoked or used
+		c.JSON(http.StatusServiceUnavailable, dto.RefreshTokenResponse{
+			ErrorMessage: "Failed to validate refresh token",
+			Success:      false,
+			StatusCode:   http.StatusServiceUnavailable,
+		})
+	}
+
+	claims, err := h.jwtService.ValidateToken(req.RefreshToken)
+	if errors.Is(err, jwt.ErrStoreUnavailable) {
+		unavailable()
+		return
+	}
+	if err != nil || claims.Subject != "refresh" || claims.Family == "" {
+		unauthorized("Invalid or expired refresh token")
+		return
+	}
+
+	// The token claims may be stale; refresh from the current account
+	user, err := h.userService.GetUserByID(claims.UserID)
+	if err != nil || !user.IsActive {
+		unauthorized("User not found or not active")
+		return
+	}
+
+	tokenPair, err := h.jwtService.RefreshToken(claims, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, jwt.ErrRefreshTokenReused):
+			slog.Warn("refresh token reuse detected, token family revoked", "user_id", user.ID)
+			unauthorized("Refresh token has already been used; please log in again")
+		case errors.Is(err, jwt.ErrRefreshTokenRevoked):
+			unauthorized("Refresh token has been revoked; please log in again")
+		case errors.Is(err, jwt.ErrStoreUnavailable):
+			unavailable()
+		default:
+			c.JSON(http.StatusInternalServerError, dto.RefreshTokenResponse{
+				ErrorMessage: "Failed to refresh token",
+				Success:      false,
+				StatusCode:   http.StatusInternalServerError,
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RefreshTokenResponse{
+		Message:      "Token refreshed successfully",
+		Token:        tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		ExpiresIn:    tokenPair.ExpiresIn,
+		StatusCode:   http.StatusOK,
+		Success:      true,
+	})
+}
+
 // UserLogout handles user logout (Django's user_logout equivalent)
 // @Summary User Logout
 // @Description Log out user and invalidate JWT tokens
@@ -216,10 +316,16 @@ func (h *AuthHandler) UserLogout(c *gin.Context) {
 		tokenString := authHeader[7:]
 
 		// Add the token to blacklist
-		err := h.jwtService.BlacklistToken(tokenString)
-		if err != nil {
+		if err := h.jwtService.BlacklistToken(tokenString); err != nil {
 			// Log the error but don't fail the logout
-			// In production, you might want to log this properly
+			slog.Error("failed to blacklist token on logout", "err", err)
+		}
+	}
+
+	// End the session, so its refresh token and other access tokens stop working
+	if sessionID := c.GetString("session_id"); sessionID != "" {
+		if err := h.jwtService.RevokeSession(userID, sessionID); err != nil && !errors.Is(err, jwt.ErrSessionNotFound) {
+			slog.Error("failed to revoke session on logout", "err", err)
 		}
 	}
 
@@ -282,7 +388,7 @@ func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 		Success: true,
 		Code:    http.StatusOK,
 		Message: "Account Created and Verified!",
-		Extra:   "You have successfully signed up on GoPadi",
+		Extra:   fmt.Sprintf("You have successfully signed up on %s", config.Envs.AppName),
 		Data:    req,
 	})
 }
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	userService "github.com/SOG-web/goinit/gin/internal/app/user"
+	userRepo "github.com/SOG-web/goinit/gin/internal/data/user/repo"
+	"github.com/SOG-web/goinit/gin/internal/lib/jwt"
+)
+
+var errStoreDown = errors.New("store down")
+
+// flakyValidAfter and flakyRefresh fail while down is set
+type flakyValidAfter struct {
+	jwt.ValidAfterStore
+	down *atomic.Bool
+}
+
+func (s flakyValidAfter) ValidAfter(userID string) (time.Time, error) {
+	if s.down.Load() {
+		return time.Time{}, errStoreDown
+	}
+	return s.ValidAfterStore.ValidAfter(userID)
+}
+
+// countedValidAfter counts the valid-after lookups, one per validated token
+type countedValidAfter struct {
+	jwt.ValidAfterStore
+	calls *atomic.Int32
+}
+
+func (s countedValidAfter) ValidAfter(userID string) (time.Time, error) {
+	s.calls.Add(1)
+	return s.ValidAfterStore.ValidAfter(userID)
+}
+
+type flakyRefresh struct {
+	jwt.RefreshTokenStore
+	down *atomic.Bool
+}
+
+func (s flakyRefresh) Rotate(family, jti, next string, expiresAt time.Time) error {
+	if s.down.Load() {
+		return errStoreDown
+	}
+	return s.RefreshTokenStore.Rotate(family, jti, next, expiresAt)
+}
+
+func (s flakyRefresh) RevokeFamily(family string) error {
+	if s.down.Load() {
+		return errStoreDown
+	}
+	return s.RefreshTokenStore.RevokeFamily(family)
+}
+
+func TestRefreshToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		store string // the store that is down
+		// reuse presents a refresh token that was already exchanged
+		reuse bool
+		want  int
+	}{
+		{name: "refresh token", want: http.StatusOK},
+		{name: "reused refresh token", reuse: true, want: http.StatusUnauthorized},
+		{name: "valid-after store down", store: "valid-after", want: http.StatusServiceUnavailable},
+		{name: "refresh token store down", store: "refresh", want: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			down := new(atomic.Bool)
+			stores := jwt.Stores{
+				Blacklist:  jwt.NewDatabaseTokenBlacklist(db),
+				Refresh:    jwt.NewDatabaseRefreshTokenStore(db),
+				ValidAfter: jwt.NewDatabaseValidAfterStore(db),
+			}
+			switch tt.store {
+			case "valid-after":
+				stores.ValidAfter = flakyValidAfter{stores.ValidAfter, down}
+			case "refresh":
+				stores.Refresh = flakyRefresh{stores.Refresh, down}
+			}
+			validations := new(atomic.Int32)
+			stores.ValidAfter = countedValidAfter{stores.ValidAfter, validations}
+			tokens := jwt.NewJWTService(jwt.NewKeyring(jwt.NewHMACKey("test-secret")), time.Hour, 24*time.Hour, stores, jwt.FailClosed)
+			users := userService.NewUserService(
+				userRepo.NewUserRepositoryGORM(db),
+				tokens,
+				// goinit:if email
+				nil,
+				// goinit:end
+			)
+			h := &AuthHandler{userService: users, jwtService: tokens}
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/api/auth/refresh/", h.RefreshToken)
+
+			user, err := users.CreateSuperuser("alice", "alice@example.com", "password")
+			if err != nil {
+				t.Fatal(err)
+			}
+			pair, err := tokens.GenerateTokenPair(user, jwt.ClientInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.reuse {
+				claims, err := tokens.ValidateToken(pair.RefreshToken)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := tokens.RefreshToken(claims, user); err != nil {
+					t.Fatal(err)
+				}
+			}
+			down.Store(tt.store != "")
+			validations.Store(0)
+
+			body := `{"refresh_token": "` + pair.RefreshToken + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh/", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("refresh = %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+			if n := validations.Load(); n != 1 {
+				t.Errorf("refresh token validated %d times, want once", n)
+			}
+		})
+	}
+}
@@ -1,12 +1,18 @@
 package handler
 
 import (
+	// goinit:if redis
 	"context"
+	// goinit:end
 	"net/http"
+	// goinit:if redis
 	"time"
+	// goinit:end
 
 	"github.com/gin-gonic/gin"
+	// goinit:if redis
 	"github.com/redis/go-redis/v9"
+	// goinit:end
 )
 
 // HealthResponse represents the health check response
@@ -29,6 +35,7 @@ func Health(c *gin.Context) {
 	c.JSON(http.StatusOK, "ok")
 }
 
+// goinit:if redis
 // HealthWithRedis creates a health check handler that includes Redis status
 func HealthWithRedis(redisClient *redis.Client) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
@@ -63,3 +70,5 @@ func HealthWithRedis(redisClient *redis.Client) gin.HandlerFunc {
 		c.JSON(statusCode, response)
 	})
 }
+
+// goinit:end
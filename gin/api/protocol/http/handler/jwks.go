@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS serves the public keys that verify the access tokens, so other
+// services can verify them without sharing a secret. With HS256 the set is
+// empty.
+// @Summary JSON Web Key Set
+// @Description Public keys (RFC 7517) of the keys that sign and still verify access tokens, selected by the kid header of a token
+// @Tags System
+// @Produce json
+// @Success 200 {object} jwtLib.JWKSet "Key set"
+// @Router /.well-known/jwks.json [get]
+func JWKS(keys *jwtLib.Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Verifiers refetch the set when a token has an unknown kid; a short
+		// max-age bounds how long they keep trusting a key that was dropped
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	}
+}
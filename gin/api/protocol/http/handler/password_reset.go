@@ -24,9 +24,14 @@ type PasswordResetHandler struct {
 }
 
 func NewPasswordResetHandlerDI(publicHost string) *PasswordResetHandler {
-	userSvc := di.GetUserService()
-	pwSvc := di.MustResolve[pwreset.PasswordResetServiceInterface](di.DIContainer)
-	emailSvc := di.MustResolve[email.EmailServiceInterface](di.DIContainer)
+	return NewPasswordResetHandler(di.DIContainer, publicHost)
+}
+
+// NewPasswordResetHandler creates a new PasswordResetHandler with its services from c.
+func NewPasswordResetHandler(c *di.Container, publicHost string) *PasswordResetHandler {
+	userSvc := di.MustResolve[*userService.UserService](c)
+	pwSvc := di.MustResolve[pwreset.PasswordResetServiceInterface](c)
+	emailSvc := di.MustResolve[email.EmailServiceInterface](c)
 	return  &PasswordResetHandler{
 		userService:  userSvc,
 		pwService:    pwSvc,
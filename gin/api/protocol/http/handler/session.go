@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/SOG-web/goinit/gin/api/common/dto"
+	"github.com/SOG-web/goinit/gin/internal/di"
+	"github.com/SOG-web/goinit/gin/internal/lib/jwt"
+)
+
+// SessionHandler lists and revokes the sessions (logins on a device) of
+// users. A session is a refresh token family; revoking it stops its refresh
+// token and access tokens at once.
+type SessionHandler struct {
+	jwtService jwt.JWTServiceInterface
+}
+
+// NewSessionHandlerDI creates a new SessionHandler using DI container.
+func NewSessionHandlerDI() *SessionHandler {
+	return NewSessionHandler(di.DIContainer)
+}
+
+// NewSessionHandler creates a new SessionHandler with its services from c.
+func NewSessionHandler(c *di.Container) *SessionHandler {
+	return &SessionHandler{
+		jwtService: di.MustResolve[jwt.JWTServiceInterface](c),
+	}
+}
+
+// ListSessions lists the active sessions of the current user
+// @Summary List Sessions
+// @Description List the devices the current user is logged in on; the session of the request is marked as current
+// @Tags Sessions
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} dto.SessionListResponse "Active sessions"
+// @Failure 401 {object} dto.AuthErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 500 {object} dto.AuthErrorResponse "Internal server error"
+// @Router /user/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	h.listSessions(c, c.GetString("user_id"), c.GetString("session_id"))
+}
+
+// RevokeSession revokes a session of the current user
+// @Summary Revoke Session
+// @Description Log the current user out of one device
+// @Tags Sessions
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Session ID"
+// @Success 200 {object} dto.SessionRevokeResponse "Session revoked"
+// @Failure 401 {object} dto.AuthErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 404 {object} dto.AuthErrorResponse "Session not found"
+// @Failure 500 {object} dto.AuthErrorResponse "Internal server error"
+// @Router /user/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	h.revokeSession(c, c.GetString("user_id"), c.Param("id"))
+}
+
+// RevokeAllSessions revokes every other session of the current user
+// @Summary Revoke All Sessions
+// @Description Log the current user out of every device except the one making the request
+// @Tags Sessions
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} dto.SessionRevokeResponse "Sessions revoked"
+// @Failure 401 {object} dto.AuthErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 500 {object} dto.AuthErrorResponse "Internal server error"
+// @Router /user/sessions/revoke-all [post]
+func (h *SessionHandler) RevokeAllSessions(c *gin.Context) {
+	h.revokeAllSessions(c, c.GetString("user_id"), c.GetString("session_id"))
+}
+
+// goinit:if admin
+// AdminListSessions lists the active sessions of a user
+// @Summary List User Sessions
+// @Description List the devices a user is logged in on (admin only)
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.SessionListResponse "Active sessions"
+// @Failure 401 {object} dto.AuthErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.AuthErrorResponse "Forbidden - admin access required"
+// @Failure 500 {object} dto.AuthErrorResponse "Internal server error"
+// @Router /admin/users/{id}/sessions [get]
+func (h *SessionHandler) AdminListSessions(c *gin.Context) {
+	h.listSessions(c, c.Param("id"), ownSessionID(c))
+}
+
+// AdminRevokeSession revokes a session of a user
+// @Summary Revoke User Session
+// @Description Log a user out of one device (admin only)
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param sid path string true "Session ID"
+// @Success 200 {object} dto.SessionRevokeResponse "Session revoked"
+// @Failure 401 {object} dto.AuthErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.AuthErrorResponse "Forbidden - admin access required"
+// @Failure 404 {object} dto.AuthErrorResponse "Session not found"
+// @Failure 500 {object} dto.AuthErrorResponse "Internal server error"
+// @Router /admin/users/{id}/sessions/{sid} [delete]
+func (h *SessionHandler) AdminRevokeSession(c *gin.Context) {
+	h.revokeSession(c, c.Param("id"), c.Param("sid"))
+}
+
+// AdminRevokeAllSessions revokes every session of a user
+// @Summary Revoke All User Sessions
+// @Description Log a user out of every device (admin only)
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.SessionRevokeResponse "Sessions revoked"
+// @Failure 401 {object} dto.AuthErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.AuthErrorResponse "Forbidden - admin access required"
+// @Failure 500 {object} dto.AuthErrorResponse "Internal server error"
+// @Router /admin/users/{id}/sessions/revoke-all [post]
+func (h *SessionHandler) AdminRevokeAllSessions(c *gin.Context) {
+	// Every session of another user is revoked; revoking their own, the
+	// admin keeps the session of the request
+	h.revokeAllSessions(c, c.Param("id"), ownSessionID(c))
+}
+
+// ownSessionID returns the session of the request if the user of the path
+// is the current user, and "" otherwise
+func ownSessionID(c *gin.Context) string {
+	if c.Param("id") != c.GetString("user_id") {
+		return ""
+	}
+	return c.GetString("session_id")
+}
+
+// goinit:end
+
+func (h *SessionHandler) listSessions(c *gin.Context, userID, currentSessionID string) {
+	sessions, err := h.jwtService.ListSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.AuthErrorResponse{
+			Error:      "Failed to list sessions",
+			Success:    false,
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	data := make([]dto.SessionData, 0, len(sessions))
+	for _, s := range sessions {
+		data = append(data, dto.SessionData{
+			ID:         s.ID,
+			Device:     s.Device,
+			IP:         s.IP,
+			UserAgent:  s.UserAgent,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == currentSessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.SessionListResponse{
+		Success:    true,
+		StatusCode: http.StatusOK,
+		Data:       data,
+	})
+}
+
+func (h *SessionHandler) revokeSession(c *gin.Context, userID, sessionID string) {
+	if err := h.jwtService.RevokeSession(userID, sessionID); err != nil {
+		status, message := http.StatusInternalServerError, "Failed to revoke session"
+		if errors.Is(err, jwt.ErrSessionNotFound) {
+			status, message = http.StatusNotFound, err.Error()
+		}
+		c.JSON(status, dto.AuthErrorResponse{
+			Error:      message,
+			Success:    false,
+			StatusCode: status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SessionRevokeResponse{
+		Success:    true,
+		StatusCode: http.StatusOK,
+		Message:    "Session revoked successfully",
+		Revoked:    1,
+	})
+}
+
+func (h *SessionHandler) revokeAllSessions(c *gin.Context, userID, exceptSessionID string) {
+	revoked, err := h.jwtService.RevokeAllSessions(userID, exceptSessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.AuthErrorResponse{
+			Error:      "Failed to revoke sessions",
+			Success:    false,
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SessionRevokeResponse{
+		Success:    true,
+		StatusCode: http.StatusOK,
+		Message:    "Sessions revoked successfully",
+		Revoked:    revoked,
+	})
+}
+
+// clientInfo describes the client of a login request. The device is the
+// name the client gave, or its browser and operating system.
+func clientInfo(c *gin.Context, device string) jwt.ClientInfo {
+	userAgent := c.Request.UserAgent()
+	if device == "" {
+		device = describeDevice(userAgent)
+	}
+	return jwt.ClientInfo{
+		Device:    device,
+		IP:        c.ClientIP(),
+		UserAgent: userAgent,
+	}
+}
+
+// describeDevice returns a short description such as "Chrome on macOS" of
+// a User-Agent header. Order matters: Edge and Opera also claim to be
+// Chrome, and Chrome claims to be Safari.
+func describeDevice(userAgent string) string {
+	match := func(pairs [][2]string) string {
+		for _, p := range pairs {
+			if strings.Contains(userAgent, p[0]) {
+				return p[1]
+			}
+		}
+		return ""
+	}
+	browser := match([][2]string{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"}, {"curl/", "curl"},
+	})
+	os := match([][2]string{
+		{"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Android", "Android"},
+		{"Windows", "Windows"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+	return "Unknown device"
+}
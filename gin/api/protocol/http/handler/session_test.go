@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/SOG-web/goinit/gin/api/common/dto"
+	"github.com/SOG-web/goinit/gin/api/common/middleware"
+	userGORM "github.com/SOG-web/goinit/gin/internal/data/user/model/gorm"
+	"github.com/SOG-web/goinit/gin/internal/di"
+	"github.com/SOG-web/goinit/gin/internal/domain/model"
+	userModel "github.com/SOG-web/goinit/gin/internal/domain/user/model"
+	"github.com/SOG-web/goinit/gin/internal/lib/jwt"
+)
+
+// newTestDB returns a test database with the user and token tables
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&userGORM.UserGORM{}, &jwt.RefreshTokenFamily{}, &jwt.TokenValidAfter{}, &jwt.BlacklistedToken{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// testStores returns the token stores of a JWT service in db
+func testStores(db *gorm.DB) jwt.Stores {
+	return jwt.Stores{
+		Blacklist:  jwt.NewDatabaseTokenBlacklist(db),
+		Refresh:    jwt.NewDatabaseRefreshTokenStore(db),
+		ValidAfter: jwt.NewDatabaseValidAfterStore(db),
+	}
+}
+
+// newTestRouter returns the session routes, with the middleware of the
+// application, on a JWT service whose stores are in a test database
+func newTestRouter(t *testing.T) (*gin.Engine, *jwt.JWTService) {
+	t.Helper()
+	tokens := jwt.NewJWTService(jwt.NewKeyring(jwt.NewHMACKey("test-secret")), time.Hour, 24*time.Hour, testStores(newTestDB(t)), jwt.FailClosed)
+	return newSessionRouter(t, tokens), tokens
+}
+
+// newSessionRouter returns the session routes, with the middleware of the
+// application, on tokens
+func newSessionRouter(t *testing.T, tokens *jwt.JWTService) *gin.Engine {
+	t.Helper()
+	c := di.New()
+	if err := di.Provide[jwt.JWTServiceInterface](c, tokens); err != nil {
+		t.Fatal(err)
+	}
+	h := NewSessionHandler(c)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	user := router.Group("/api/user", middleware.RequireAuth(tokens))
+	user.GET("/sessions/", h.ListSessions)
+	user.DELETE("/sessions/:id/", h.RevokeSession)
+	// goinit:if admin
+	admin := router.Group("/api/admin", middleware.RequireAuth(tokens), middleware.RequireAdmin())
+	admin.GET("/users/:id/sessions/", h.AdminListSessions)
+	admin.DELETE("/users/:id/sessions/:sid/", h.AdminRevokeSession)
+	admin.POST("/users/:id/sessions/revoke-all/", h.AdminRevokeAllSessions)
+	// goinit:end
+	return router
+}
+
+// login returns the access token of a new session of user
+func login(t *testing.T, tokens *jwt.JWTService, user *userModel.User) string {
+	t.Helper()
+	pair, err := tokens.GenerateTokenPair(user, jwt.ClientInfo{Device: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair.AccessToken
+}
+
+// serve sends a request with token as its bearer token and returns the response
+func serve(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSessionRoutes(t *testing.T) {
+	bob := &userModel.User{Base: model.Base{ID: "bob"}, Email: "bob@example.com"}
+	// goinit:if admin
+	staff := &userModel.User{Base: model.Base{ID: "staff"}, Email: "staff@example.com", IsStaff: true}
+	superuser := &userModel.User{Base: model.Base{ID: "root"}, Email: "root@example.com", IsSuperuser: true}
+	// goinit:end
+
+	tests := []struct {
+		name   string
+		user   *userModel.User // who makes the request; nil for no token
+		method string
+		path   string
+		want   int
+	}{
+		{name: "own sessions", user: bob, method: http.MethodGet, path: "/api/user/sessions/", want: http.StatusOK},
+		{name: "own sessions without a token", method: http.MethodGet, path: "/api/user/sessions/", want: http.StatusUnauthorized},
+		// goinit:if admin
+		{name: "staff lists a user's sessions", user: staff, method: http.MethodGet, path: "/api/admin/users/bob/sessions/", want: http.StatusOK},
+		{name: "superuser lists a user's sessions", user: superuser, method: http.MethodGet, path: "/api/admin/users/bob/sessions/", want: http.StatusOK},
+		{name: "user lists another user's sessions", user: bob, method: http.MethodGet, path: "/api/admin/users/staff/sessions/", want: http.StatusForbidden},
+		{name: "user lists own sessions as admin", user: bob, method: http.MethodGet, path: "/api/admin/users/bob/sessions/", want: http.StatusForbidden},
+		{name: "admin route without a token", method: http.MethodGet, path: "/api/admin/users/bob/sessions/", want: http.StatusUnauthorized},
+		{name: "staff revokes a user's sessions", user: staff, method: http.MethodPost, path: "/api/admin/users/bob/sessions/revoke-all/", want: http.StatusOK},
+		{name: "user revokes another user's sessions", user: bob, method: http.MethodPost, path: "/api/admin/users/staff/sessions/revoke-all/", want: http.StatusForbidden},
+		// goinit:end
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, tokens := newTestRouter(t)
+			login(t, tokens, bob)
+			var token string
+			if tt.user != nil {
+				token = login(t, tokens, tt.user)
+			}
+
+			w := serve(router, tt.method, tt.path, token)
+			if w.Code != tt.want {
+				t.Fatalf("%s %s = %d %s, want %d", tt.method, tt.path, w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+// TestRevokeSessionErrors checks that a failing store is reported without
+// its error, unlike an unknown session
+func TestRevokeSessionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		session string // the session to revoke; empty for the user's own
+		down    bool   // whether the refresh token store fails
+		want    int
+		message string
+	}{
+		{name: "unknown session", session: "other", want: http.StatusNotFound, message: jwt.ErrSessionNotFound.Error()},
+		{name: "refresh token store down", down: true, want: http.StatusInternalServerError, message: "Failed to revoke session"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			down := new(atomic.Bool)
+			stores := testStores(newTestDB(t))
+			stores.Refresh = flakyRefresh{stores.Refresh, down}
+			tokens := jwt.NewJWTService(jwt.NewKeyring(jwt.NewHMACKey("test-secret")), time.Hour, 24*time.Hour, stores, jwt.FailClosed)
+			router := newSessionRouter(t, tokens)
+			token := login(t, tokens, &userModel.User{Base: model.Base{ID: "bob"}, Email: "bob@example.com"})
+			session := tt.session
+			if session == "" {
+				claims, err := tokens.ValidateToken(token)
+				if err != nil {
+					t.Fatal(err)
+				}
+				session = claims.SessionID
+			}
+
+			down.Store(tt.down)
+			w := serve(router, http.MethodDelete, "/api/user/sessions/"+session+"/", token)
+			if w.Code != tt.want {
+				t.Fatalf("revoke session = %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+			var body dto.AuthErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error != tt.message {
+				t.Errorf("error = %q, want %q", body.Error, tt.message)
+			}
+		})
+	}
+}
+
+// TestTokenWithoutSession checks that an access token without a session,
+// which no session revocation could stop, is rejected
+func TestTokenWithoutSession(t *testing.T) {
+	router, _ := newTestRouter(t)
+	now := time.Now()
+	token, err := jwt.NewKeyring(jwt.NewHMACKey("test-secret")).Sign(&jwt.Claims{
+		UserID:         "bob",
+		Email:          "bob@example.com",
+		IssuedAtMillis: now.UnixMilli(),
+		RegisteredClaims: gojwt.RegisteredClaims{
+			ExpiresAt: gojwt.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  gojwt.NewNumericDate(now),
+			Subject:   "access",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := serve(router, http.MethodGet, "/api/user/sessions/", token); w.Code != http.StatusUnauthorized {
+		t.Errorf("token without a session = %d %s, want %d", w.Code, w.Body, http.StatusUnauthorized)
+	}
+}
+
+// goinit:if admin
+func TestAdminSessions(t *testing.T) {
+	admin := &userModel.User{Base: model.Base{ID: "admin"}, Email: "admin@example.com", IsStaff: true}
+
+	tests := []struct {
+		name string
+		user string // whose sessions the admin manages
+		// The sessions listed, those marked current and those left after
+		// revoking all: the admin's own session is current and survives
+		listed, current, left int
+	}{
+		{name: "another user", user: "bob", listed: 2},
+		{name: "the admin", user: "admin", listed: 3, current: 1, left: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, tokens := newTestRouter(t)
+			owner := &userModel.User{Base: model.Base{ID: tt.user}, Email: tt.user + "@example.com", IsStaff: tt.user == admin.ID}
+			login(t, tokens, owner)
+			login(t, tokens, owner)
+			token := login(t, tokens, admin)
+
+			w := serve(router, http.MethodGet, "/api/admin/users/"+tt.user+"/sessions/", token)
+			if w.Code != http.StatusOK {
+				t.Fatalf("list sessions = %d %s, want %d", w.Code, w.Body, http.StatusOK)
+			}
+			var list dto.SessionListResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+				t.Fatal(err)
+			}
+			if len(list.Data) != tt.listed {
+				t.Errorf("listed %d sessions, want %d", len(list.Data), tt.listed)
+			}
+			current := 0
+			for _, s := range list.Data {
+				if s.Current {
+					current++
+				}
+			}
+			if current != tt.current {
+				t.Errorf("%d sessions marked current, want %d", current, tt.current)
+			}
+
+			w = serve(router, http.MethodPost, "/api/admin/users/"+tt.user+"/sessions/revoke-all/", token)
+			if w.Code != http.StatusOK {
+				t.Fatalf("revoke all sessions = %d %s, want %d", w.Code, w.Body, http.StatusOK)
+			}
+			var revoke dto.SessionRevokeResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &revoke); err != nil {
+				t.Fatal(err)
+			}
+			if revoke.Revoked != 2 {
+				t.Errorf("revoked %d sessions, want 2", revoke.Revoked)
+			}
+			remaining, err := tokens.ListSessions(tt.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(remaining) != tt.left {
+				t.Errorf("%d sessions left, want %d", len(remaining), tt.left)
+			}
+		})
+	}
+}
+
+// goinit:end
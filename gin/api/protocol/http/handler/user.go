@@ -9,21 +9,34 @@ import (
 	userService "github.com/SOG-web/goinit/gin/internal/app/user"
 	"github.com/SOG-web/goinit/gin/internal/di"
 	userModel "github.com/SOG-web/goinit/gin/internal/domain/user/model"
+	// goinit:if storage
 	"github.com/SOG-web/goinit/gin/internal/lib/storage"
+	// goinit:end
 )
 
 type UserHandler struct {
 	userService *userService.UserService
-	storage     storage.Storage
+	// goinit:if storage
+	storage storage.Storage
+	// goinit:end
 }
 
 // NewUserHandlerDI creates a new UserHandler using DI container.
 func NewUserHandlerDI() *UserHandler {
-	userSvc := di.GetUserService()
-	store := di.MustResolve[storage.Storage](di.DIContainer)
+	return NewUserHandler(di.DIContainer)
+}
+
+// NewUserHandler creates a new UserHandler with its services from c.
+func NewUserHandler(c *di.Container) *UserHandler {
+	userSvc := di.MustResolve[*userService.UserService](c)
+	// goinit:if storage
+	store := di.MustResolve[storage.Storage](c)
+	// goinit:end
 	return &UserHandler{
 		userService: userSvc,
-		storage:     store,
+		// goinit:if storage
+		storage: store,
+		// goinit:end
 	}
 }
 
@@ -1,13 +1,16 @@
 package router
 
 import (
+	"github.com/SOG-web/goinit/gin/api/common/middleware"
 	"github.com/SOG-web/goinit/gin/api/protocol/http/handler"
 	"github.com/SOG-web/goinit/gin/api/protocol/http/routes"
 	"github.com/SOG-web/goinit/gin/internal/di"
 	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	// goinit:if redis
 	"github.com/redis/go-redis/v9"
+	// goinit:end
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -16,17 +19,31 @@ type Dependencies struct {
 	SessionMW            gin.HandlerFunc
 	PublicHost           string
 	JWTService           jwtLib.JWTServiceInterface
+	// OnShutdown registers a function that closes long-lived connections
+	// (WebSocket, SSE) before the server drains in-flight requests
+	OnShutdown           func(func())
 }
 
 func New(deps Dependencies) *gin.Engine {
 	r := gin.Default()
 
 	// jwtSvc := di.MustResolve[jwt.JWTServiceInterface](di.DIContainer)
+	// goinit:if redis
 	redisClient, _ := di.Resolve[*redis.Client](di.DIContainer)
+	// goinit:end
 
 	// Limit multipart memory to 16 MiB (tunable)
 	r.MaxMultipartMemory = 16 << 20
 
+	// Let gin.Context carry the request's deadline, cancellation and values,
+	// so it can be passed wherever a context.Context is expected
+	r.ContextWithFallback = true
+
+	// Per-request DI scope; handlers resolve scoped services from middleware.Scope(c)
+	if di.DIContainer != nil {
+		r.Use(middleware.DIScope(di.DIContainer))
+	}
+
 	// Session middleware
 	if deps.SessionMW != nil {
 		r.Use(deps.SessionMW)
@@ -41,10 +58,17 @@ func New(deps Dependencies) *gin.Engine {
 	}))
 
 	// Health check endpoint
+	var health gin.HandlerFunc = handler.Health
+	// goinit:if redis
 	if redisClient != nil {
-		r.GET("/health", handler.HealthWithRedis(redisClient))
-	} else {
-		r.GET("/health", handler.Health)
+		health = handler.HealthWithRedis(redisClient)
+	}
+	// goinit:end
+	r.GET("/health", health)
+
+	// Public keys for verifying access tokens signed with RS256, ES256 or EdDSA
+	if keys, err := di.Resolve[*jwtLib.Keyring](di.DIContainer); err == nil {
+		r.GET("/.well-known/jwks.json", handler.JWKS(keys))
 	}
 
 	// Swagger documentation
@@ -53,34 +77,46 @@ func New(deps Dependencies) *gin.Engine {
 		c.File("./docs/swagger.json")
 	})
 
+	// goinit:if storage:local
 	// Serve static uploads (profile images, etc.)
 	r.Static("/uploads", "./uploads")
+	// goinit:end
 
 	// Setup all routes
 	if deps.JWTService != nil {
-		setupAllRoutes(r, deps.JWTService, deps.PublicHost)
+		setupAllRoutes(r, deps.JWTService, deps.PublicHost, deps.OnShutdown)
 	}
 
 	return r
 }
 
 // SetupAllRoutes sets up all API routes
-func setupAllRoutes(router *gin.Engine, jwtSvc jwtLib.JWTServiceInterface, publicHost string) {
+func setupAllRoutes(router *gin.Engine, jwtSvc jwtLib.JWTServiceInterface, publicHost string, onShutdown func(func())) {
 	// Authentication routes
 	routes.SetupAuthRoutes(router, jwtSvc)
 
 	// User management routes
 	routes.SetupUserRoutes(router, jwtSvc)
 
+	// goinit:if pwreset
 	// Password reset routes
 	routes.SetupPasswordResetRoutes(router, publicHost)
+	// goinit:end
 
+	// goinit:if admin
 	// Admin routes
 	routes.SetupAdminRoutes(router, jwtSvc)
+	// goinit:end
 
+	// goinit:if realtime
 	// Real-time routes (SSE and WebSocket)
-	routes.SetupSSERoutes(router)
-	routes.SetupWSRoutes(router)
-
-	
+	// goinit:if realtime:sse
+	routes.SetupSSERoutes(router, onShutdown)
+	// goinit:end
+	// goinit:if realtime:ws
+	routes.SetupWSRoutes(router, onShutdown)
+	// goinit:end
+	// goinit:end
+
+	// goinit:scaffold:routes
 }
\ No newline at end of file
@@ -21,6 +21,9 @@ func SetupAuthRoutes(router *gin.Engine, jwtSvc jwt.JWTServiceInterface) {
 		// User login (POST /api/auth/login/)
 		auth.POST("/login/", authHandler.UserLogin)
 
+		// Token refresh (POST /api/auth/refresh/) - exchanges a one-time refresh token
+		auth.POST("/refresh/", authHandler.RefreshToken)
+
 		// User logout (GET /api/auth/logout/) - requires authentication
 		auth.GET("/logout/", middleware.RequireAuth(jwtSvc), authHandler.UserLogout)
 
@@ -41,6 +44,7 @@ func SetupAuthRoutes(router *gin.Engine, jwtSvc jwt.JWTServiceInterface) {
 // SetupUserRoutes sets up user management routes
 func SetupUserRoutes(router *gin.Engine, jwtSvc jwt.JWTServiceInterface) {
 	userHandler := handler.NewUserHandlerDI()
+	sessionHandler := handler.NewSessionHandlerDI()
 
 	// User management API routes group
 	user := router.Group("/api/user")
@@ -51,9 +55,21 @@ func SetupUserRoutes(router *gin.Engine, jwtSvc jwt.JWTServiceInterface) {
 		// Update current user profile (PUT /api/user/profile/) - requires authentication
 		user.PUT("/profile/", middleware.RequireAuth(jwtSvc), userHandler.UpdateUserProfile)
 
+		// goinit:if storage
 		// Upload/Update profile image (POST /api/user/profile/image/) - requires authentication
 		user.POST("/profile/image/", middleware.RequireAuth(jwtSvc), userHandler.UploadProfileImage)
+		// goinit:end
+
+		// List current user's sessions (GET /api/user/sessions/) - requires authentication
+		user.GET("/sessions/", middleware.RequireAuth(jwtSvc), sessionHandler.ListSessions)
+
+		// Revoke one session (DELETE /api/user/sessions/:id/) - requires authentication
+		user.DELETE("/sessions/:id/", middleware.RequireAuth(jwtSvc), sessionHandler.RevokeSession)
+
+		// Revoke all other sessions (POST /api/user/sessions/revoke-all/) - requires authentication
+		user.POST("/sessions/revoke-all/", middleware.RequireAuth(jwtSvc), sessionHandler.RevokeAllSessions)
 
+		// goinit:if admin
 		// Admin routes - requires staff privileges
 		admin := user.Group("/admin")
 		admin.Use(middleware.RequireAuth(jwtSvc))
@@ -71,9 +87,11 @@ func SetupUserRoutes(router *gin.Engine, jwtSvc jwt.JWTServiceInterface) {
 			// Get user by ID (GET /api/user/admin/:id/) - admin only
 			admin.GET("/:id/", userHandler.GetUserByID)
 		}
+		// goinit:end
 	}
 }
 
+// goinit:if pwreset
 // SetupPasswordResetRoutes registers password reset request and confirm endpoints
 func SetupPasswordResetRoutes(router *gin.Engine, publicHost string) {
 	
@@ -85,9 +103,13 @@ func SetupPasswordResetRoutes(router *gin.Engine, publicHost string) {
 	}
 }
 
+// goinit:end
+
+// goinit:if admin
 // SetupAdminRoutes sets up admin-specific routes (Django admin equivalent)
 func SetupAdminRoutes(router *gin.Engine, jwtSvc jwt.JWTServiceInterface) {
 	adminHandler := handler.NewAdminHandlerDI()
+	sessionHandler := handler.NewSessionHandlerDI()
 
 	// Admin API routes group - requires staff privileges
 	admin := router.Group("/api/admin")
@@ -103,7 +125,16 @@ func SetupAdminRoutes(router *gin.Engine, jwtSvc jwt.JWTServiceInterface) {
 		admin.PUT("/users/:id/deactivate/", adminHandler.DeactivateUser)
 		admin.PUT("/users/:id/force-verify/", adminHandler.ForceVerifyUser)
 
+		// Session management
+		admin.GET("/users/:id/sessions/", sessionHandler.AdminListSessions)
+		admin.DELETE("/users/:id/sessions/:sid/", sessionHandler.AdminRevokeSession)
+		admin.POST("/users/:id/sessions/revoke-all/", sessionHandler.AdminRevokeAllSessions)
+
+		// goinit:if email
 		// Bulk operations
 		admin.POST("/bulk-email/", adminHandler.SendBulkEmail)
+		// goinit:end
 	}
 }
+
+// goinit:end
@@ -1,14 +1,23 @@
 package routes
 
 import (
+	// goinit:if realtime:sse
 	sseHandler "github.com/SOG-web/goinit/gin/api/protocol/sse"
+	// goinit:end
+	// goinit:if realtime:ws
 	wsHandler "github.com/SOG-web/goinit/gin/api/protocol/ws"
+	// goinit:end
 	"github.com/gin-gonic/gin"
 )
 
-// SetupSSERoutes sets up Server-Sent Events routes
-func SetupSSERoutes(router *gin.Engine) {
+// goinit:if realtime:sse
+// SetupSSERoutes sets up Server-Sent Events routes. onShutdown, if set,
+// registers the function that ends open streams when the server stops.
+func SetupSSERoutes(router *gin.Engine, onShutdown func(func())) {
 	sse := sseHandler.NewSSEHandler()
+	if onShutdown != nil {
+		onShutdown(sse.Shutdown)
+	}
 
 	sseGroup := router.Group("/api/sse")
 	{
@@ -17,12 +26,21 @@ func SetupSSERoutes(router *gin.Engine) {
 	}
 }
 
-// SetupWSRoutes sets up WebSocket routes
-func SetupWSRoutes(router *gin.Engine) {
+// goinit:end
+
+// goinit:if realtime:ws
+// SetupWSRoutes sets up WebSocket routes. onShutdown, if set, registers the
+// function that closes open connections when the server stops.
+func SetupWSRoutes(router *gin.Engine, onShutdown func(func())) {
 	ws := wsHandler.NewWebSocketHandler()
+	if onShutdown != nil {
+		onShutdown(ws.Shutdown)
+	}
 
 	wsGroup := router.Group("/api/ws")
 	{
 		wsGroup.GET("/connect", ws.HandleConnection)
 	}
-}
\ No newline at end of file
+}
+
+// goinit:end
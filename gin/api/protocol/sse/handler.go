@@ -3,6 +3,8 @@ package sse
 import (
 	"fmt"
 	"io"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-contrib/sse"
@@ -10,11 +12,19 @@ import (
 )
 
 // SSEHandler handles Server-Sent Events
-type SSEHandler struct{}
+type SSEHandler struct {
+	done     chan struct{} // closed by Shutdown to end every stream
+	shutdown sync.Once
+}
 
 // NewSSEHandler creates a new SSE handler
 func NewSSEHandler() *SSEHandler {
-	return &SSEHandler{}
+	return &SSEHandler{done: make(chan struct{})}
+}
+
+// Shutdown ends every open stream with a final "close" event
+func (h *SSEHandler) Shutdown() {
+	h.shutdown.Do(func() { close(h.done) })
 }
 
 // StreamEvents streams server-sent events to the client
@@ -26,48 +36,17 @@ func NewSSEHandler() *SSEHandler {
 // @Success 200 {string} string "SSE stream established"
 // @Router /api/sse/events [get]
 func (h *SSEHandler) StreamEvents(c *gin.Context) {
-	// Set headers for SSE
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
-
-	// Create a channel to send events
-	eventChan := make(chan sse.Event)
-
-	// Start a goroutine to send periodic events
-	go func() {
-		defer close(eventChan)
-		counter := 0
-
-		for {
-			select {
-			case <-c.Request.Context().Done():
-				// Client disconnected
-				return
-			default:
-				// Send an event
-				event := sse.Event{
-					Event: "message",
-					Data:  fmt.Sprintf(`{"timestamp": "%s", "counter": %d, "message": "Hello from server!"}`, time.Now().Format(time.RFC3339), counter),
-					Id:    fmt.Sprintf("%d", counter),
-				}
-				eventChan <- event
-				counter++
-
-				// Wait 2 seconds before sending next event
-				time.Sleep(2 * time.Second)
-			}
+	counter := 0
+
+	// Send an event every 2 seconds
+	h.stream(c, 2*time.Second, func() sse.Event {
+		event := sse.Event{
+			Event: "message",
+			Data:  fmt.Sprintf(`{"timestamp": "%s", "counter": %d, "message": "Hello from server!"}`, time.Now().Format(time.RFC3339), counter),
+			Id:    fmt.Sprintf("%d", counter),
 		}
-	}()
-
-	// Stream the events to the client
-	c.Stream(func(w io.Writer) bool {
-		if event, ok := <-eventChan; ok {
-			c.Render(-1, event)
-			return true
-		}
-		return false
+		counter++
+		return event
 	})
 }
 
@@ -80,41 +59,49 @@ func (h *SSEHandler) StreamEvents(c *gin.Context) {
 // @Success 200 {string} string "Notification SSE stream established"
 // @Router /api/sse/notifications [get]
 func (h *SSEHandler) StreamNotifications(c *gin.Context) {
+	notificationID := 0
+
+	// Send notifications every 5 seconds
+	h.stream(c, 5*time.Second, func() sse.Event {
+		notification := sse.Event{
+			Event: "notification",
+			Data:  fmt.Sprintf(`{"id": %d, "type": "info", "message": "System notification %d", "timestamp": "%s"}`, notificationID, notificationID, time.Now().Format(time.RFC3339)),
+			Id:    fmt.Sprintf("notif-%d", notificationID),
+		}
+		notificationID++
+		return notification
+	})
+}
+
+// stream sends next() right away and then every interval until the client
+// disconnects or the handler shuts down.
+func (h *SSEHandler) stream(c *gin.Context, interval time.Duration, next func() sse.Event) {
+	// Set headers for SSE
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
 
-	eventChan := make(chan sse.Event)
+	// The stream outlives the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
 
-	go func() {
-		defer close(eventChan)
-		notificationID := 0
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-		for {
-			select {
-			case <-c.Request.Context().Done():
-				return
-			default:
-				notification := sse.Event{
-					Event: "notification",
-					Data:  fmt.Sprintf(`{"id": %d, "type": "info", "message": "System notification %d", "timestamp": "%s"}`, notificationID, notificationID, time.Now().Format(time.RFC3339)),
-					Id:    fmt.Sprintf("notif-%d", notificationID),
-				}
-				eventChan <- notification
-				notificationID++
-
-				// Send notifications every 5 seconds
-				time.Sleep(5 * time.Second)
-			}
-		}
-	}()
+	c.Render(-1, next())
+	c.Writer.Flush()
 
 	c.Stream(func(w io.Writer) bool {
-		if event, ok := <-eventChan; ok {
-			c.Render(-1, event)
+		select {
+		case <-c.Request.Context().Done():
+			// Client disconnected
+			return false
+		case <-h.done:
+			c.Render(-1, sse.Event{Event: "close", Data: "server shutting down"})
+			return false
+		case <-ticker.C:
+			c.Render(-1, next())
 			return true
 		}
-		return false
 	})
-}
\ No newline at end of file
+}
@@ -16,6 +16,7 @@ type WebSocketHandler struct {
 	upgrader websocket.Upgrader
 	clients  map[*websocket.Conn]bool
 	mutex    sync.RWMutex
+	closed   bool // set by Shutdown; new connections are refused
 }
 
 // Message represents a WebSocket message
@@ -57,6 +58,12 @@ func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
 
 	// Add client to the list
 	h.mutex.Lock()
+	if h.closed {
+		h.mutex.Unlock()
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(time.Second))
+		conn.Close()
+		return
+	}
 	h.clients[conn] = true
 	h.mutex.Unlock()
 
@@ -166,6 +173,23 @@ func (h *WebSocketHandler) removeClient(conn *websocket.Conn) {
 	log.Printf("WebSocket connection closed. Total clients: %d", len(h.clients))
 }
 
+// Shutdown sends a going-away close frame to every client and closes the connections
+func (h *WebSocketHandler) Shutdown() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.closed = true
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for conn := range h.clients {
+		if err := conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second)); err != nil {
+			log.Printf("Error sending close message: %v", err)
+		}
+		conn.Close()
+		delete(h.clients, conn)
+	}
+	log.Printf("WebSocket connections closed for shutdown")
+}
+
 // GetClientCount returns the number of connected clients
 func (h *WebSocketHandler) GetClientCount() int {
 	h.mutex.RLock()
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/SOG-web/goinit/gin/config"
+	"github.com/SOG-web/goinit/gin/internal/db"
+	"github.com/SOG-web/goinit/gin/internal/di"
+)
+
+// Exit codes of the commands.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// command is a subcommand of the server binary, e.g. "api migrate up".
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(cfg config.Config, args []string) int
+}
+
+// commands lists the subcommands in the order they are shown in the usage.
+// It is filled in init because the help command prints it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "", "run the HTTP server (default)", runServe},
+		{"migrate", "up|down [n]|status|create <name>", "manage the database schema", runMigrate},
+		{"createsuperuser", "[--username u] [--email e] [--no-input]", "create a staff account with every permission", runCreateSuperuser},
+		{"user", "list|activate|deactivate|verify", "manage user accounts", runUser},
+		{"tokens", "cleanup", "delete expired tokens from the database stores", runTokens},
+		{"keys", "list|rotate [--prepare|--activate]", "manage the JWT signing keys", runKeys},
+		{"di", "validate|graph [--format dot|json]", "check the DI container or print its dependency graph", runDI},
+		{"help", "", "show this help", runHelp},
+	}
+}
+
+// run dispatches args to their command and returns the exit code.
+// Without arguments the server is started.
+func run(cfg config.Config, args []string) int {
+	if len(args) == 0 {
+		return runServe(cfg, nil)
+	}
+
+	name := args[0]
+	if name == "-h" || name == "--help" {
+		name = "help"
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(cfg, args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+func runHelp(cfg config.Config, args []string) int {
+	printUsage(os.Stdout)
+	return exitOK
+}
+
+func printUsage(w *os.File) {
+	fmt.Fprintf(w, "usage: %s <command> [arguments]\n\ncommands:\n", filepath.Base(os.Args[0]))
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(usageLine(cmd)))
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, usageLine(cmd), cmd.summary)
+	}
+}
+
+func usageLine(cmd command) string {
+	return strings.TrimSpace(cmd.name + " " + cmd.args)
+}
+
+// bootstrap opens the database and initializes the DI container, the setup
+// shared by the server and every command that uses the application services.
+func bootstrap(cfg config.Config) (*gorm.DB, error) {
+	slog.Info("creating db", "driver", cfg.DBDriver)
+	gdb, err := db.Open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	slog.Info("db created")
+
+	if err := di.InitContainer(cfg, gdb); err != nil {
+		return nil, fmt.Errorf("failed to initialize DI container: %v", err)
+	}
+	slog.Info("DI container initialized")
+	return gdb, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/SOG-web/goinit/gin/config"
+)
+
+// testConfig returns the default config with a fresh sqlite database, which
+// also holds the token stores so no Redis is needed. The test runs in a
+// temporary directory, where the commands write their files.
+func testConfig(t *testing.T) config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	cfg := config.Envs
+	cfg.DBDriver = "sqlite"
+	cfg.DBName = filepath.Join(dir, "test.db")
+	cfg.DBDSN = ""
+	cfg.DBParams = ""
+	cfg.DBAutoMigrate = false
+	cfg.UseDatabaseJWT = true
+	cfg.JWTBlacklist = ""
+	cfg.JWTKeyDir = filepath.Join(dir, "keys")
+	return cfg
+}
+
+// migratedConfig returns a test config whose database has every migration applied.
+func migratedConfig(t *testing.T) config.Config {
+	t.Helper()
+	cfg := testConfig(t)
+	if code := run(cfg, []string{"migrate", "up"}); code != exitOK {
+		t.Fatalf("migrate up = %d, want %d", code, exitOK)
+	}
+	return cfg
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{args: []string{"help"}, want: exitOK},
+		{args: []string{"--help"}, want: exitOK},
+		{args: []string{"-h"}, want: exitOK},
+		{args: []string{"bogus"}, want: exitUsage},
+	}
+
+	for _, tt := range tests {
+		if got := run(config.Config{}, tt.args); got != tt.want {
+			t.Errorf("run(%q) = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+// TestUsageErrors checks that malformed arguments are rejected with the usage
+// exit code before a command touches the database or the keys.
+func TestUsageErrors(t *testing.T) {
+	cfg := testConfig(t)
+	t.Setenv("SUPERUSER_PASSWORD", "")
+
+	tests := [][]string{
+		{"migrate"},
+		{"migrate", "create"},
+		{"migrate", "create", "a", "b"},
+		{"migrate", "down", "0"},
+		{"migrate", "down", "x"},
+		{"migrate", "sideways"},
+		{"user"},
+		{"user", "promote", "alice"},
+		{"user", "activate"},
+		{"user", "list", "--limit", "x"},
+		{"tokens"},
+		{"tokens", "purge"},
+		{"keys"},
+		{"keys", "delete"},
+		{"keys", "list", "extra"},
+		{"keys", "rotate", "--prepare", "--activate"},
+		{"keys", "list", "--prepare"},
+		{"createsuperuser", "--bogus"},
+		{"createsuperuser", "--no-input", "--email", "admin@example.com", "--username", "admin"},
+	}
+
+	for _, args := range tests {
+		if got := run(cfg, args); got != exitUsage {
+			t.Errorf("run(%q) = %d, want %d", args, got, exitUsage)
+		}
+	}
+}
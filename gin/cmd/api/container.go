@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/SOG-web/goinit/gin/config"
+	"github.com/SOG-web/goinit/gin/internal/db"
+	"github.com/SOG-web/goinit/gin/internal/di"
+)
+
+const diUsage = `usage: api di <command>
+
+commands:
+  validate                   check that every dependency can be resolved
+  graph [--format dot|json]  print the dependency graph (default dot)`
+
+// runDI implements "di validate" and "di graph". Both build the container
+// without validating it first, so the graph of a broken container can
+// still be inspected.
+func runDI(cfg config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, diUsage)
+		return exitUsage
+	}
+
+	var format *string
+	switch args[0] {
+	case "validate":
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, diUsage)
+			return exitUsage
+		}
+	case "graph":
+		fs := flag.NewFlagSet("di graph", flag.ContinueOnError)
+		format = fs.String("format", "dot", "output format: dot or json")
+		if err := fs.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		if *format != "dot" && *format != "json" {
+			fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+			return exitUsage
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown di command %q\n%s\n", args[0], diUsage)
+		return exitUsage
+	}
+
+	// Log to stderr so the output can be piped, e.g. into dot -Tsvg
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	gdb, err := db.Open(cfg)
+	if err != nil {
+		slog.Error("failed to open database", "err", err)
+		return exitFailure
+	}
+	c, err := di.BuildContainer(cfg, gdb)
+	if err != nil {
+		slog.Error("failed to build DI container", "err", err)
+		return exitFailure
+	}
+
+	if format == nil {
+		if err := c.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		fmt.Printf("%d registrations, all dependencies resolvable\n", len(c.GetRegisteredTypes()))
+		return exitOK
+	}
+
+	if *format == "json" {
+		out, err := c.Graph().JSON()
+		if err != nil {
+			slog.Error("failed to encode graph", "err", err)
+			return exitFailure
+		}
+		fmt.Println(string(out))
+		return exitOK
+	}
+	fmt.Print(c.Graph().DOT())
+	return exitOK
+}
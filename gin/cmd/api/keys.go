@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SOG-web/goinit/gin/config"
+	"github.com/SOG-web/goinit/gin/internal/di"
+	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
+)
+
+const keysUsage = `usage: api keys <command>
+
+commands:
+  list                          list the JWT signing keys of JWT_KEY_DIR
+  rotate                        generate a new signing key and delete the expired ones
+  rotate --prepare|--activate   rotate in two steps, for several instances
+
+Replaced keys keep verifying tokens for JWT_KEY_GRACE_PERIOD. A plain rotate
+suits a single instance: restart it to sign with the new key. Instances
+sharing JWT_KEY_DIR would reject the new key's tokens until they restart
+too, so rotate them in two steps: "rotate --prepare" adds the key, which the
+instances only verify tokens with once restarted; after restarting them all,
+"rotate --activate" makes it the signing key, and a second restart has them
+sign with it.`
+
+// runKeys implements "keys list" and "keys rotate". They need no database
+// and only apply to the asymmetric algorithms; HS256 uses JWT_SECRET.
+func runKeys(cfg config.Config, args []string) int {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "rotate") {
+		fmt.Fprintln(os.Stderr, keysUsage)
+		return exitUsage
+	}
+	fs := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+	var prepare, activate bool
+	if args[0] == "rotate" {
+		fs.BoolVar(&prepare, "prepare", false, "add the next signing key, which only verifies tokens")
+		fs.BoolVar(&activate, "activate", false, "make the prepared key the signing key")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 || (prepare && activate) {
+		fmt.Fprintln(os.Stderr, keysUsage)
+		return exitUsage
+	}
+	if cfg.JWTAlgorithm == jwtLib.AlgHS256 {
+		fmt.Fprintln(os.Stderr, "JWT_ALGORITHM is HS256: tokens are signed with JWT_SECRET, there are no keys to manage")
+		return exitFailure
+	}
+	keyCfg := di.JWTKeyringConfig(cfg)
+
+	switch {
+	case activate:
+		key, err := jwtLib.ActivateKey(keyCfg)
+		if errors.Is(err, jwtLib.ErrNoPendingKey) {
+			fmt.Fprintln(os.Stderr, "No key has been prepared; run keys rotate --prepare first")
+			return exitFailure
+		}
+		if err != nil {
+			slog.Error("failed to activate jwt key", "err", err)
+			return exitFailure
+		}
+		fmt.Printf("Activated %s key %s; restart every instance to sign with it\n", key.Algorithm, key.ID)
+		return exitOK
+	case args[0] == "rotate":
+		rotate, done := jwtLib.RotateKey, "restart the server to sign with it"
+		if prepare {
+			rotate, done = jwtLib.PrepareKey, "restart every instance to verify its tokens, then run keys rotate --activate"
+		}
+		key, removed, err := rotate(keyCfg)
+		if err != nil {
+			slog.Error("failed to rotate jwt key", "err", err)
+			return exitFailure
+		}
+		for _, path := range removed {
+			fmt.Printf("Deleted expired key %s\n", path)
+		}
+		fmt.Printf("Created %s key %s; %s\n", key.Algorithm, key.ID, done)
+		return exitOK
+	}
+
+	files, err := jwtLib.ListKeys(keyCfg)
+	if err != nil {
+		slog.Error("failed to read jwt keys", "err", err)
+		return exitFailure
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALG\tCREATED\tSTATUS\tFILE")
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		status := "verifies until " + f.ValidUntil.Format(time.RFC3339)
+		switch {
+		case f.Signing:
+			status = "signing"
+		case f.Pending:
+			status = "prepared, verifies only"
+		case f.Expired():
+			status = "expired"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			f.Key.ID, f.Key.Algorithm, f.Key.Created.Format(time.RFC3339), status, f.Path)
+	}
+	w.Flush()
+	return exitOK
+}
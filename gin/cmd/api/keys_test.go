@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SOG-web/goinit/gin/config"
+	"github.com/SOG-web/goinit/gin/internal/di"
+	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
+)
+
+func TestKeysHS256(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.JWTAlgorithm = jwtLib.AlgHS256
+
+	for _, args := range [][]string{{"keys", "list"}, {"keys", "rotate"}} {
+		if code := run(cfg, args); code != exitFailure {
+			t.Errorf("run(%q) with HS256 = %d, want %d", args, code, exitFailure)
+		}
+	}
+}
+
+func TestKeysRotate(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.JWTAlgorithm = jwtLib.AlgES256
+
+	for _, args := range [][]string{{"keys", "rotate"}, {"keys", "rotate"}, {"keys", "list"}} {
+		if code := run(cfg, args); code != exitOK {
+			t.Fatalf("run(%q) = %d, want %d", args, code, exitOK)
+		}
+	}
+
+	keys, err := jwtLib.ListKeys(di.JWTKeyringConfig(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signing := 0
+	for _, k := range keys {
+		if k.Signing {
+			signing++
+		}
+	}
+	if len(keys) != 2 || signing != 1 {
+		t.Errorf("keys = %+v, want the new signing key and the replaced one", keys)
+	}
+}
+
+func TestKeysRotateInTwoSteps(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.JWTAlgorithm = jwtLib.AlgES256
+
+	if code := run(cfg, []string{"keys", "rotate", "--activate"}); code != exitFailure {
+		t.Errorf("keys rotate --activate without a prepared key = %d, want %d", code, exitFailure)
+	}
+
+	// The key files are named after their creation second, so the current
+	// key is backdated to be ordered before the activated one
+	current, err := jwtLib.GenerateKey(cfg.JWTAlgorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Created = time.Now().Add(-time.Hour).UTC()
+	if _, err := jwtLib.WriteKeyFile(cfg.JWTKeyDir, current); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := run(cfg, []string{"keys", "rotate", "--prepare"}); code != exitOK {
+		t.Fatalf("keys rotate --prepare = %d, want %d", code, exitOK)
+	}
+	prepared := pendingKey(t, cfg)
+	if prepared == "" {
+		t.Fatal("keys rotate --prepare did not add a pending key")
+	}
+
+	if code := run(cfg, []string{"keys", "rotate", "--activate"}); code != exitOK {
+		t.Fatalf("keys rotate --activate = %d, want %d", code, exitOK)
+	}
+	keys, err := jwtLib.ListKeys(di.JWTKeyringConfig(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range keys {
+		if k.Signing && k.Key.ID != prepared {
+			t.Errorf("signing key = %s, want the prepared key %s", k.Key.ID, prepared)
+		}
+	}
+}
+
+// pendingKey returns the ID of the prepared key, or "" without one.
+func pendingKey(t *testing.T, cfg config.Config) string {
+	t.Helper()
+	keys, err := jwtLib.ListKeys(di.JWTKeyringConfig(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range keys {
+		if k.Pending {
+			return k.Key.ID
+		}
+	}
+	return ""
+}
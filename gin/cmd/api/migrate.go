@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+
+	"github.com/SOG-web/goinit/gin/config"
+	userGorm "github.com/SOG-web/goinit/gin/internal/data/user/model/gorm"
+	"github.com/SOG-web/goinit/gin/internal/db"
+	"github.com/SOG-web/goinit/gin/internal/db/migrate"
+	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
+	// goinit:if pwreset
+	pwresetGorm "github.com/SOG-web/goinit/gin/internal/lib/pwreset"
+	// goinit:end
+)
+
+// migrationsDir is where "migrate create" writes new migrations.
+const migrationsDir = "internal/db/migrations"
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up              apply every pending migration
+  down [n]        revert the last n applied migrations (default 1)
+  status          list migrations and whether they are applied
+  create <name>   add empty up/down files for every driver in ` + migrationsDir
+
+// runMigrate implements the "migrate" subcommand and returns the exit code.
+func runMigrate(cfg config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return exitUsage
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return exitUsage
+		}
+		files, err := migrate.Create(migrationsDir, args[1])
+		if err != nil {
+			slog.Error("failed to create migration", "err", err)
+			return exitFailure
+		}
+		for _, f := range files {
+			fmt.Println("created", f)
+		}
+		return exitOK
+	}
+
+	gdb, err := db.Open(cfg)
+	if err != nil {
+		slog.Error("db error", "err", err)
+		return exitFailure
+	}
+	m, err := migrate.New(gdb, cfg.DBDriver)
+	if err != nil {
+		slog.Error("failed to load migrations", "err", err)
+		return exitFailure
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		done, err := m.Up(ctx)
+		for _, mig := range done {
+			slog.Info("migration applied", "version", mig.Version, "name", mig.Name)
+		}
+		if err != nil {
+			slog.Error("migrate up failed", "err", err)
+			return exitFailure
+		}
+		if len(done) == 0 {
+			slog.Info("no pending migrations")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintf(os.Stderr, "invalid number of migrations %q\n", args[1])
+				return exitUsage
+			}
+		}
+		done, err := m.Down(ctx, steps)
+		for _, mig := range done {
+			slog.Info("migration reverted", "version", mig.Version, "name", mig.Name)
+		}
+		if err != nil {
+			slog.Error("migrate down failed", "err", err)
+			return exitFailure
+		}
+		if len(done) == 0 {
+			slog.Info("no applied migrations")
+		}
+
+	case "status":
+		list, err := m.Status(ctx)
+		if err != nil {
+			slog.Error("migrate status failed", "err", err)
+			return exitFailure
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range list {
+			status, appliedAt := "pending", ""
+			if s.Applied {
+				status, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Missing {
+				status = "applied, file missing"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		w.Flush()
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n%s\n", args[0], migrateUsage)
+		return exitUsage
+	}
+	return exitOK
+}
+
+// prepareSchema checks the database schema at boot. With DB_AUTO_MIGRATE the
+// tables are created from the GORM models (development only); otherwise
+// pending migrations are reported so they can be applied with "migrate up".
+func prepareSchema(cfg config.Config, gdb *gorm.DB) error {
+	if cfg.DBAutoMigrate {
+		if cfg.RunMode == "release" {
+			return errors.New("DB_AUTO_MIGRATE is for development only; apply migrations with \"migrate up\"")
+		}
+		slog.Warn("DB_AUTO_MIGRATE is on: creating tables from the GORM models instead of migrations")
+		return gdb.AutoMigrate(autoMigrateModels(cfg)...)
+	}
+
+	m, err := migrate.New(gdb, cfg.DBDriver)
+	if err != nil {
+		return err
+	}
+	pending, err := m.Pending(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		slog.Warn("database has pending migrations; run \"migrate up\"", "pending", len(pending), "next", fmt.Sprintf("%04d_%s", pending[0].Version, pending[0].Name))
+	}
+	return nil
+}
+
+// autoMigrateModels are the models DB_AUTO_MIGRATE creates tables for.
+func autoMigrateModels(cfg config.Config) []any {
+	models := []any{
+		&userGorm.UserGORM{},
+		// goinit:scaffold:models
+	}
+
+	// JWT and Password Reset models (only if using database implementations)
+	if cfg.UseDatabaseJWT {
+		models = append(models, &jwtLib.BlacklistedToken{}, &jwtLib.RefreshTokenFamily{}, &jwtLib.TokenValidAfter{})
+	}
+	// goinit:if pwreset
+	if cfg.UseDatabasePWReset {
+		models = append(models, &pwresetGorm.PasswordResetToken{})
+	}
+	// goinit:end
+	return models
+}
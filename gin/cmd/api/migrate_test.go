@@ -0,0 +1,54 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/SOG-web/goinit/gin/internal/db"
+)
+
+func TestMigrateCommands(t *testing.T) {
+	cfg := testConfig(t)
+
+	for _, args := range [][]string{{"migrate", "status"}, {"migrate", "up"}, {"migrate", "up"}, {"migrate", "status"}} {
+		if code := run(cfg, args); code != exitOK {
+			t.Fatalf("run(%q) = %d, want %d", args, code, exitOK)
+		}
+	}
+
+	gdb, err := db.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gdb.Migrator().HasTable("users") {
+		t.Fatal("migrate up did not create the users table")
+	}
+
+	if code := run(cfg, []string{"migrate", "down", "100"}); code != exitOK {
+		t.Fatalf("migrate down = %d, want %d", code, exitOK)
+	}
+	if gdb.Migrator().HasTable("users") {
+		t.Error("migrate down did not drop the users table")
+	}
+}
+
+func TestMigrateCreate(t *testing.T) {
+	cfg := testConfig(t)
+
+	if code := run(cfg, []string{"migrate", "create", "add_tags"}); code != exitOK {
+		t.Fatalf("migrate create = %d, want %d", code, exitOK)
+	}
+	for _, driver := range []string{"sqlite", "mysql", "postgres"} {
+		files, err := filepath.Glob(filepath.Join(migrationsDir, driver, "*_add_tags.*.sql"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 2 {
+			t.Errorf("%s migrations = %v, want an up and a down file", driver, files)
+		}
+	}
+
+	if code := run(cfg, []string{"migrate", "create", "Add-Tags"}); code != exitFailure {
+		t.Errorf("migrate create with an invalid name = %d, want %d", code, exitFailure)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/SOG-web/goinit/gin/api/common/middleware"
+	"github.com/SOG-web/goinit/gin/api/protocol/http/router"
+	"github.com/SOG-web/goinit/gin/config"
+	docs "github.com/SOG-web/goinit/gin/docs"
+	"github.com/SOG-web/goinit/gin/internal/di"
+	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
+	"github.com/SOG-web/goinit/gin/internal/server"
+)
+
+// configureSwagger sets the swagger metadata that depends on cfg: the title,
+// and the name of the session cookie in the Session security definition
+func configureSwagger(cfg config.Config) {
+	docs.SwaggerInfo.Title = cfg.AppName
+	docs.SwaggerInfo.BasePath = "/api"
+	docs.SwaggerInfo.SwaggerTemplate = strings.Replace(docs.SwaggerInfo.SwaggerTemplate,
+		`"name": "session"`, `"name": `+strconv.Quote(cfg.SessionName), 1)
+}
+
+// runServe runs the HTTP server until it fails or a SIGINT or SIGTERM shuts it down.
+func runServe(cfg config.Config, args []string) int {
+	if len(args) > 0 {
+		slog.Error("serve takes no arguments", "args", args)
+		return exitUsage
+	}
+
+	configureSwagger(cfg)
+
+	gdb, err := bootstrap(cfg)
+	if err != nil {
+		slog.Error("bootstrap error", "err", err)
+		return exitFailure
+	}
+
+	if err := prepareSchema(cfg, gdb); err != nil {
+		slog.Error("schema error", "err", err)
+		return exitFailure
+	}
+
+	slog.Info("creating server")
+
+	// Get JWT service from DI container
+	jwtSvc := di.MustResolve[jwtLib.JWTServiceInterface](di.DIContainer)
+
+	deps := router.Dependencies{
+		SessionMW:  middleware.NewSessionMiddleware(cfg),
+		PublicHost: cfg.PublicHost,
+		JWTService: jwtSvc,
+	}
+
+	srv := server.New(cfg, deps)
+	slog.Info("server created")
+
+	// SIGINT or SIGTERM starts a graceful shutdown; a second signal exits at once
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
+	// The container starts its services in dependency order and, once the
+	// HTTP server has drained, stops them in reverse: the email queue, the
+	// Redis client and the database among them
+	if err := di.DIContainer.Start(ctx); err != nil {
+		slog.Error("container start error", "err", err)
+		return exitFailure
+	}
+	srv.OnShutdown("services", di.DIContainer.Stop)
+
+	slog.Info("running server")
+	if err := srv.Run(ctx); err != nil {
+		slog.Error("server error", "err", err)
+		return exitFailure
+	}
+	slog.Info("server stopped")
+	return exitOK
+}
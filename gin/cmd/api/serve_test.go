@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/SOG-web/goinit/gin/config"
+	docs "github.com/SOG-web/goinit/gin/docs"
+)
+
+func TestConfigureSwagger(t *testing.T) {
+	configureSwagger(config.Config{AppName: "Shop", SessionName: "shop_session"})
+
+	var spec struct {
+		Info struct {
+			Title string `json:"title"`
+		} `json:"info"`
+		SecurityDefinitions map[string]struct {
+			Name string `json:"name"`
+			In   string `json:"in"`
+		} `json:"securityDefinitions"`
+	}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Info.Title != "Shop" {
+		t.Errorf("title = %q, want %q", spec.Info.Title, "Shop")
+	}
+	if session := spec.SecurityDefinitions["Session"]; session.Name != "shop_session" || session.In != "cookie" {
+		t.Errorf("Session security definition = %+v, want the shop_session cookie", session)
+	}
+}
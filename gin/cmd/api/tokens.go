@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	// goinit:if pwreset
+	"time"
+	// goinit:end
+
+	"github.com/SOG-web/goinit/gin/config"
+	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
+	// goinit:if pwreset
+	"github.com/SOG-web/goinit/gin/internal/lib/pwreset"
+	// goinit:end
+)
+
+// tokenStore is a database token store that can delete its expired tokens.
+type tokenStore interface {
+	GetExpiredTokenCount() (int64, error)
+	ClearExpiredTokens() error
+}
+
+// runTokens implements "tokens cleanup". Redis stores expire their keys on
+// their own, so only the database stores are cleaned.
+func runTokens(cfg config.Config, args []string) int {
+	if len(args) != 1 || args[0] != "cleanup" {
+		fmt.Fprintln(os.Stderr, "usage: api tokens cleanup")
+		return exitUsage
+	}
+
+	gdb, err := bootstrap(cfg)
+	if err != nil {
+		slog.Error("bootstrap error", "err", err)
+		return exitFailure
+	}
+
+	stores := []struct {
+		name  string
+		model any
+		store tokenStore
+	}{
+		{"blacklisted tokens", &jwtLib.BlacklistedToken{}, jwtLib.NewDatabaseTokenBlacklist(gdb)},
+		{"refresh token families", &jwtLib.RefreshTokenFamily{}, jwtLib.NewDatabaseRefreshTokenStore(gdb)},
+		{"token valid-after records", &jwtLib.TokenValidAfter{}, jwtLib.NewDatabaseValidAfterStore(gdb)},
+		// goinit:if pwreset
+		{"password reset tokens", &pwreset.PasswordResetToken{}, pwreset.NewDatabaseService(gdb, time.Hour)},
+		// goinit:end
+	}
+
+	code := exitOK
+	for _, s := range stores {
+		// The table only exists once its migration ran (or DB_AUTO_MIGRATE created it)
+		if !gdb.Migrator().HasTable(s.model) {
+			fmt.Printf("%s: no table, skipped\n", s.name)
+			continue
+		}
+		expired, err := s.store.GetExpiredTokenCount()
+		if err == nil {
+			err = s.store.ClearExpiredTokens()
+		}
+		if err != nil {
+			slog.Error("failed to clean up "+s.name, "err", err)
+			code = exitFailure
+			continue
+		}
+		fmt.Printf("%s: %d expired deleted\n", s.name, expired)
+	}
+	return code
+}
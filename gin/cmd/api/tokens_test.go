@@ -0,0 +1,12 @@
+package main
+
+import "testing"
+
+func TestTokensCleanup(t *testing.T) {
+	if code := run(testConfig(t), []string{"tokens", "cleanup"}); code != exitOK {
+		t.Errorf("tokens cleanup without the token tables = %d, want %d", code, exitOK)
+	}
+	if code := run(migratedConfig(t), []string{"tokens", "cleanup"}); code != exitOK {
+		t.Errorf("tokens cleanup = %d, want %d", code, exitOK)
+	}
+}
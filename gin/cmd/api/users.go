@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/SOG-web/goinit/gin/config"
+	"github.com/SOG-web/goinit/gin/internal/app/user"
+	"github.com/SOG-web/goinit/gin/internal/di"
+	userModel "github.com/SOG-web/goinit/gin/internal/domain/user/model"
+)
+
+// minPasswordLength matches the password rule of the registration endpoint.
+const minPasswordLength = 8
+
+const userUsage = `usage: api user <command>
+
+commands:
+  list [--limit n] [--offset n]   list user accounts
+  activate <user>                 allow the user to log in
+  deactivate <user>               stop the user from logging in
+  verify <user>                   mark the user's email as verified
+
+<user> is an ID, email or username.`
+
+// runCreateSuperuser creates a staff account with every permission. The
+// password is prompted for, or read from SUPERUSER_PASSWORD with --no-input.
+func runCreateSuperuser(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("createsuperuser", flag.ContinueOnError)
+	username := fs.String("username", "", "username of the new account")
+	email := fs.String("email", "", "email of the new account")
+	noInput := fs.Bool("no-input", false, "do not prompt; the password is read from SUPERUSER_PASSWORD")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	var password string
+	if *noInput {
+		password = os.Getenv("SUPERUSER_PASSWORD")
+		if *email == "" || *username == "" || password == "" {
+			fmt.Fprintln(os.Stderr, "--no-input needs --email, --username and SUPERUSER_PASSWORD")
+			return exitUsage
+		}
+	} else {
+		in := bufio.NewReader(os.Stdin)
+		var err error
+		if *email == "" {
+			if *email, err = prompt(in, "Email: "); err != nil {
+				slog.Error("failed to read email", "err", err)
+				return exitFailure
+			}
+		}
+		if *username == "" {
+			suggested, _, _ := strings.Cut(*email, "@")
+			if *username, err = prompt(in, fmt.Sprintf("Username (leave blank to use %q): ", suggested)); err != nil {
+				slog.Error("failed to read username", "err", err)
+				return exitFailure
+			}
+			if *username == "" {
+				*username = suggested
+			}
+		}
+		if password, err = promptPassword(in); err != nil {
+			slog.Error("invalid password", "err", err)
+			return exitFailure
+		}
+	}
+
+	if !strings.Contains(*email, "@") {
+		fmt.Fprintf(os.Stderr, "invalid email %q\n", *email)
+		return exitUsage
+	}
+	if len(password) < minPasswordLength {
+		fmt.Fprintf(os.Stderr, "the password must be at least %d characters\n", minPasswordLength)
+		return exitUsage
+	}
+
+	if _, err := bootstrap(cfg); err != nil {
+		slog.Error("bootstrap error", "err", err)
+		return exitFailure
+	}
+
+	u, err := di.GetUserService().CreateSuperuser(*username, *email, password)
+	if err != nil {
+		slog.Error("failed to create superuser", "err", err)
+		return exitFailure
+	}
+	fmt.Printf("Superuser %s (%s) created with ID %s\n", u.Username, u.Email, u.ID)
+	return exitOK
+}
+
+// prompt prints label and reads one trimmed line from in.
+func prompt(in *bufio.Reader, label string) (string, error) {
+	fmt.Print(label)
+	line, err := in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptPassword reads the password twice without echoing it when stdin is a terminal.
+func promptPassword(in *bufio.Reader) (string, error) {
+	if setEcho(false) {
+		defer setEcho(true)
+	}
+	password, err := prompt(in, "Password: ")
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	again, err := prompt(in, "Password (again): ")
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	if password != again {
+		return "", errors.New("the passwords do not match")
+	}
+	return password, nil
+}
+
+// setEcho turns terminal echo on or off with stty and reports whether it
+// succeeded. It does nothing when stdin is not a terminal.
+func setEcho(on bool) bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	mode := "-echo"
+	if on {
+		mode = "echo"
+	}
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = os.Stdin
+	return cmd.Run() == nil
+}
+
+// runUser implements the "user" subcommands on top of the user service.
+func runUser(cfg config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("user list", flag.ContinueOnError)
+		limit := fs.Int("limit", 50, "maximum number of users to list")
+		offset := fs.Int("offset", 0, "number of users to skip")
+		if err := fs.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		if _, err := bootstrap(cfg); err != nil {
+			slog.Error("bootstrap error", "err", err)
+			return exitFailure
+		}
+		users, err := di.GetUserService().GetUserList(*limit, *offset)
+		if err != nil {
+			slog.Error("failed to list users", "err", err)
+			return exitFailure
+		}
+		printUsers(users)
+		return exitOK
+
+	case "activate", "deactivate", "verify":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, userUsage)
+			return exitUsage
+		}
+		if _, err := bootstrap(cfg); err != nil {
+			slog.Error("bootstrap error", "err", err)
+			return exitFailure
+		}
+		svc := di.GetUserService()
+		u, err := findUser(svc, args[1])
+		if err != nil {
+			slog.Error("user not found", "err", err)
+			return exitFailure
+		}
+
+		var done string
+		switch args[0] {
+		case "activate":
+			err, done = svc.ActivateUser(u.ID), "activated"
+		case "deactivate":
+			err, done = svc.DeactivateUser(u.ID), "deactivated"
+		case "verify":
+			err, done = svc.ForceVerifyUser(u.ID), "verified"
+		}
+		if err != nil {
+			slog.Error("failed to "+args[0]+" user", "user", u.Username, "err", err)
+			return exitFailure
+		}
+		fmt.Printf("User %s (%s) %s\n", u.Username, u.Email, done)
+		return exitOK
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown user command %q\n%s\n", args[0], userUsage)
+		return exitUsage
+	}
+}
+
+// findUser looks a user up by ID, then by email or username.
+func findUser(svc *user.UserService, ref string) (*userModel.User, error) {
+	if u, err := svc.GetUserByID(ref); err == nil {
+		return u, nil
+	}
+	lookup := svc.GetUserByUsername
+	if strings.Contains(ref, "@") {
+		lookup = svc.GetUserByEmail
+	}
+	if u, err := lookup(ref); err == nil {
+		return u, nil
+	}
+	return nil, fmt.Errorf("no user with ID, email or username %q", ref)
+}
+
+func printUsers(users []*userModel.User) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tACTIVE\tVERIFIED\tSTAFF\tSUPERUSER\tJOINED")
+	for _, u := range users {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			u.ID, u.Username, u.Email,
+			yesNo(u.IsActive), yesNo(u.IsVerified), yesNo(u.IsStaff), yesNo(u.IsSuperuser),
+			u.DateJoined.Format("2006-01-02"))
+	}
+	w.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
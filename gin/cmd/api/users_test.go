@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/SOG-web/goinit/gin/internal/di"
+)
+
+func TestCreateSuperuser(t *testing.T) {
+	cfg := migratedConfig(t)
+	t.Setenv("SUPERUSER_PASSWORD", "correct horse")
+
+	args := []string{"createsuperuser", "--no-input", "--email", "admin@example.com", "--username", "admin"}
+	if code := run(cfg, args); code != exitOK {
+		t.Fatalf("createsuperuser = %d, want %d", code, exitOK)
+	}
+
+	u, err := di.GetUserService().GetUserByEmail("admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Username != "admin" || !u.IsStaff || !u.IsSuperuser || !u.IsActive {
+		t.Errorf("superuser = %+v, want an active staff superuser named admin", u)
+	}
+
+	if code := run(cfg, args); code != exitFailure {
+		t.Errorf("createsuperuser with a taken email = %d, want %d", code, exitFailure)
+	}
+}
+
+func TestCreateSuperuserInvalid(t *testing.T) {
+	cfg := testConfig(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+	}{
+		{name: "email", email: "admin", password: "correct horse"},
+		{name: "short password", email: "admin@example.com", password: "short"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SUPERUSER_PASSWORD", tt.password)
+			args := []string{"createsuperuser", "--no-input", "--email", tt.email, "--username", "admin"}
+			if code := run(cfg, args); code != exitUsage {
+				t.Errorf("createsuperuser = %d, want %d", code, exitUsage)
+			}
+		})
+	}
+}
+
+// TestCreateSuperuserPrompt answers the prompts on stdin, leaving the
+// username blank to take the one suggested from the email.
+func TestCreateSuperuserPrompt(t *testing.T) {
+	cfg := migratedConfig(t)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin; r.Close() })
+	if _, err := w.WriteString("root@example.com\n\ncorrect horse\ncorrect horse\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	if code := run(cfg, []string{"createsuperuser"}); code != exitOK {
+		t.Fatalf("createsuperuser = %d, want %d", code, exitOK)
+	}
+	u, err := di.GetUserService().GetUserByEmail("root@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Username != "root" {
+		t.Errorf("username = %q, want the suggested %q", u.Username, "root")
+	}
+}
+
+func TestUserCommands(t *testing.T) {
+	cfg := migratedConfig(t)
+	t.Setenv("SUPERUSER_PASSWORD", "correct horse")
+	if code := run(cfg, []string{"createsuperuser", "--no-input", "--email", "admin@example.com", "--username", "admin"}); code != exitOK {
+		t.Fatalf("createsuperuser = %d, want %d", code, exitOK)
+	}
+	admin, err := di.GetUserService().GetUserByEmail("admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Users are found by ID, email or username
+	tests := []struct {
+		args   []string
+		active bool
+	}{
+		{args: []string{"user", "deactivate", admin.ID}, active: false},
+		{args: []string{"user", "activate", "admin@example.com"}, active: true},
+		{args: []string{"user", "deactivate", "admin"}, active: false},
+	}
+
+	for _, tt := range tests {
+		if code := run(cfg, tt.args); code != exitOK {
+			t.Fatalf("run(%q) = %d, want %d", tt.args, code, exitOK)
+		}
+		u, err := di.GetUserService().GetUserByID(admin.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.IsActive != tt.active {
+			t.Errorf("after %q active = %v, want %v", tt.args, u.IsActive, tt.active)
+		}
+	}
+
+	if code := run(cfg, []string{"user", "list"}); code != exitOK {
+		t.Errorf("user list = %d, want %d", code, exitOK)
+	}
+	if code := run(cfg, []string{"user", "verify", "nobody"}); code != exitFailure {
+		t.Errorf("user verify of an unknown user = %d, want %d", code, exitFailure)
+	}
+}
@@ -0,0 +1,789 @@
+// Command diwire generates the reflection-free wiring of the DI container.
+//
+// It reads providers.go in the di package, whose providers variable lists
+// constructors and whose external variable lists the types registered by
+// hand (see BuildContainer), and writes wire_gen.go with a
+// registerProviders function that registers every constructor as a
+// singleton through di.RegisterProvider. The parameters of each constructor
+// are resolved by typed calls instead of reflect.Value.Call, so a missing
+// provider is reported when generating and a changed constructor signature
+// breaks the build until the wiring is regenerated.
+//
+// Run it with "go generate ./internal/di", or with -check to fail when
+// wire_gen.go is out of date. goinit:if markers around entries and around
+// constructor parameters are carried over to the generated code.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the di package")
+	in := flag.String("in", "providers.go", "file declaring the providers and external types")
+	out := flag.String("out", "wire_gen.go", "file to write")
+	check := flag.Bool("check", false, "fail if the output is out of date instead of writing it")
+	flag.Parse()
+
+	src, err := generate(filepath.Join(*dir, *in))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "diwire: %v\n", err)
+		os.Exit(1)
+	}
+
+	outPath := filepath.Join(*dir, *out)
+	if *check {
+		current, err := os.ReadFile(outPath)
+		if err != nil || !bytes.Equal(current, src) {
+			fmt.Fprintf(os.Stderr, "diwire: %s is out of date; run go generate\n", outPath)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := os.WriteFile(outPath, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "diwire: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// provider is a constructor listed in providers.go.
+type provider struct {
+	name   string // as written in providers.go, e.g. "user.NewUserService"
+	fn     qualified
+	result typeRef
+	errs   bool // returns (T, error)
+	params []param
+	conds  []string // features of the goinit:if markers around the entry
+}
+
+// param is a constructor parameter.
+type param struct {
+	name  string
+	typ   typeRef
+	conds []string // features of markers around the parameter only
+}
+
+// external is a type listed in the external variable of providers.go.
+type external struct {
+	typ   typeRef
+	conds []string
+}
+
+// qualified is a package-level identifier.
+type qualified struct {
+	pkg  string // import path
+	name string
+}
+
+// typeRef is a type expression together with the file it was written in,
+// which is needed to know the packages it refers to.
+type typeRef struct {
+	expr ast.Expr
+	file *sourceFile
+}
+
+// key returns the type with full import paths, which is equal for the same
+// type written in different files.
+func (t typeRef) key() (string, error) {
+	return t.file.typeString(t.expr, func(pkg string) string { return pkg + "." })
+}
+
+// sourceFile is a parsed file and what its identifiers refer to.
+type sourceFile struct {
+	fset    *token.FileSet
+	file    *ast.File
+	pkg     string            // import path of the file's package
+	imports map[string]string // local name -> import path
+	conds   [][]string        // goinit:if features active on each line
+}
+
+// generator resolves packages of the module that providers.go is part of.
+type generator struct {
+	fset       *token.FileSet
+	modulePath string
+	moduleDir  string
+	packages   map[string][]*sourceFile // import path -> files
+	names      map[string]string        // import path -> package name
+}
+
+func generate(providersPath string) ([]byte, error) {
+	absPath, err := filepath.Abs(providersPath)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{fset: token.NewFileSet(), packages: make(map[string][]*sourceFile), names: make(map[string]string)}
+	if err := g.findModule(filepath.Dir(absPath)); err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(g.moduleDir, filepath.Dir(absPath))
+	if err != nil {
+		return nil, err
+	}
+	pkg := path.Join(g.modulePath, filepath.ToSlash(rel))
+	decl, err := g.parseFile(absPath, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	providers, externals, err := g.declarations(decl)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProviders(providers, externals); err != nil {
+		return nil, err
+	}
+	return render(decl, providers, g.packageName)
+}
+
+// findModule locates go.mod at or above dir.
+func (g *generator) findModule(dir string) error {
+	for d := dir; ; d = filepath.Dir(d) {
+		data, err := os.ReadFile(filepath.Join(d, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+					g.modulePath = strings.Trim(fields[1], `"`)
+					g.moduleDir = d
+					return nil
+				}
+			}
+			return fmt.Errorf("%s has no module declaration", filepath.Join(d, "go.mod"))
+		}
+		if filepath.Dir(d) == d {
+			return fmt.Errorf("no go.mod found above %s", dir)
+		}
+	}
+}
+
+func (g *generator) parseFile(filename, pkg string) (*sourceFile, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parser.ParseFile(g.fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	conds, err := markerConds(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	f := &sourceFile{fset: g.fset, file: file, pkg: pkg, imports: make(map[string]string), conds: conds}
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := g.packageName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name != "_" && name != "." {
+			f.imports[name] = importPath
+		}
+	}
+	return f, nil
+}
+
+// packageName returns the name of the package at importPath: declared in
+// its files for packages of the module, assumed from the path otherwise.
+func (g *generator) packageName(importPath string) string {
+	if name, ok := g.names[importPath]; ok {
+		return name
+	}
+	name := assumedName(importPath)
+	if dir, err := g.dir(importPath); err == nil {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+		for _, m := range matches {
+			if strings.HasSuffix(m, "_test.go") {
+				continue
+			}
+			if f, err := parser.ParseFile(token.NewFileSet(), m, nil, parser.PackageClauseOnly); err == nil {
+				name = f.Name.Name
+				break
+			}
+		}
+	}
+	g.names[importPath] = name
+	return name
+}
+
+// dir returns the directory of a package of the module.
+func (g *generator) dir(importPath string) (string, error) {
+	rel, ok := strings.CutPrefix(importPath, g.modulePath)
+	if !ok || (rel != "" && !strings.HasPrefix(rel, "/")) {
+		return "", fmt.Errorf("package %s is not part of module %s", importPath, g.modulePath)
+	}
+	return filepath.Join(g.moduleDir, filepath.FromSlash(rel)), nil
+}
+
+// load parses the non-test files of a package of the module.
+func (g *generator) load(importPath string) ([]*sourceFile, error) {
+	if files, ok := g.packages[importPath]; ok {
+		return files, nil
+	}
+	dir, err := g.dir(importPath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*sourceFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := g.parseFile(filepath.Join(dir, name), importPath)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	g.packages[importPath] = files
+	return files, nil
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// assumedName guesses the package name from its import path the way
+// goimports does, e.g. "github.com/redis/go-redis/v9" -> "redis".
+func assumedName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && majorVersion.MatchString(name) {
+		name = elems[len(elems)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexFunc(name, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+var markerPattern = regexp.MustCompile(`^\s*//\s*goinit:(if|end)\b\s*(.*?)\s*$`)
+
+// markerConds returns, for every line of src (1-based), the features of the
+// marker blocks the line is in, outermost first.
+func markerConds(src []byte) ([][]string, error) {
+	conds := [][]string{nil}
+	var stack []string
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(src)+1)
+	for scanner.Scan() {
+		conds = append(conds, slices.Clone(stack))
+		m := markerPattern.FindStringSubmatch(scanner.Text())
+		switch {
+		case m == nil:
+		case m[1] == "if":
+			stack = append(stack, m[2])
+		case len(stack) == 0:
+			return nil, fmt.Errorf("line %d: goinit:end without goinit:if", len(conds)-1)
+		default:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("%d goinit:if marker(s) without goinit:end", len(stack))
+	}
+	return conds, scanner.Err()
+}
+
+func (f *sourceFile) condsAt(pos token.Pos) []string {
+	line := f.fset.Position(pos).Line
+	if line < len(f.conds) {
+		return f.conds[line]
+	}
+	return nil
+}
+
+func (f *sourceFile) position(pos token.Pos) string {
+	p := f.fset.Position(pos)
+	return fmt.Sprintf("%s:%d", filepath.Base(p.Filename), p.Line)
+}
+
+// declarations reads the providers and external variables of providers.go.
+func (g *generator) declarations(decl *sourceFile) ([]*provider, []external, error) {
+	lists := make(map[string]*ast.CompositeLit)
+	for _, d := range decl.file.Decls {
+		gen, ok := d.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if i < len(vs.Values) {
+					if lit, ok := vs.Values[i].(*ast.CompositeLit); ok {
+						lists[name.Name] = lit
+					}
+				}
+			}
+		}
+	}
+	if lists["providers"] == nil {
+		return nil, nil, errors.New("providers.go must declare var providers = []any{...}")
+	}
+
+	var errs []error
+	var providers []*provider
+	for _, elt := range lists["providers"].Elts {
+		p, err := g.provider(decl, elt)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", decl.position(elt.Pos()), err))
+			continue
+		}
+		providers = append(providers, p)
+	}
+
+	var externals []external
+	if lit := lists["external"]; lit != nil {
+		for _, elt := range lit.Elts {
+			call, ok := elt.(*ast.CallExpr)
+			if !ok || !isIdentNamed(call.Fun, "new") || len(call.Args) != 1 {
+				errs = append(errs, fmt.Errorf("%s: external types must be written as new(T)", decl.position(elt.Pos())))
+				continue
+			}
+			externals = append(externals, external{typ: typeRef{expr: call.Args[0], file: decl}, conds: decl.condsAt(elt.Pos())})
+		}
+	}
+	return providers, externals, errors.Join(errs...)
+}
+
+// provider resolves an entry of the providers list to its declaration.
+func (g *generator) provider(decl *sourceFile, elt ast.Expr) (*provider, error) {
+	sel, ok := elt.(*ast.SelectorExpr)
+	if !ok {
+		return nil, errors.New("providers must be functions of other packages written as pkg.Func")
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil, errors.New("providers must be functions of other packages written as pkg.Func")
+	}
+	pkg, ok := decl.imports[x.Name]
+	if !ok {
+		return nil, fmt.Errorf("%s is not an imported package", x.Name)
+	}
+
+	files, err := g.load(pkg)
+	if err != nil {
+		return nil, err
+	}
+	var fn *ast.FuncDecl
+	var file *sourceFile
+	for _, f := range files {
+		for _, d := range f.file.Decls {
+			if fd, ok := d.(*ast.FuncDecl); ok && fd.Recv == nil && fd.Name.Name == sel.Sel.Name {
+				fn, file = fd, f
+			}
+		}
+	}
+	p := &provider{name: exprString(elt), fn: qualified{pkg: pkg, name: sel.Sel.Name}, conds: decl.condsAt(elt.Pos())}
+	if fn == nil {
+		return nil, fmt.Errorf("%s is not a function in %s", p.name, pkg)
+	}
+	if fn.Type.TypeParams != nil {
+		return nil, fmt.Errorf("%s is generic", p.name)
+	}
+
+	results := fieldTypes(fn.Type.Results)
+	switch {
+	case len(results) == 1:
+	case len(results) == 2 && isIdentNamed(results[1], "error"):
+		p.errs = true
+	default:
+		return nil, fmt.Errorf("%s must return T or (T, error)", p.name)
+	}
+	p.result = typeRef{expr: results[0], file: file}
+
+	funcConds := file.condsAt(fn.Pos())
+	for i, field := range fn.Type.Params.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return nil, fmt.Errorf("%s is variadic", p.name)
+		}
+		conds := file.condsAt(field.Pos())
+		if !slices.Equal(conds[:min(len(funcConds), len(conds))], funcConds) {
+			return nil, fmt.Errorf("markers around parameter %d of %s are unbalanced", i, p.name)
+		}
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: ""}}
+		}
+		for _, name := range names {
+			p.params = append(p.params, param{name: name.Name, typ: typeRef{expr: field.Type, file: file}, conds: conds[len(funcConds):]})
+		}
+	}
+	return p, nil
+}
+
+// fieldTypes returns the type of every parameter or result in fields.
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var exprs []ast.Expr
+	for _, f := range fields.List {
+		for range max(1, len(f.Names)) {
+			exprs = append(exprs, f.Type)
+		}
+	}
+	return exprs
+}
+
+func isIdentNamed(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+func exprString(expr ast.Expr) string {
+	var b bytes.Buffer
+	format.Node(&b, token.NewFileSet(), expr)
+	return b.String()
+}
+
+// typeString writes expr with the package of every named type replaced by
+// what qualify returns for its import path, e.g. "pkg." or nothing.
+func (f *sourceFile) typeString(expr ast.Expr, qualify func(pkg string) string) (string, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if obj := types.Universe.Lookup(t.Name); obj != nil {
+			if _, ok := obj.(*types.TypeName); ok {
+				return t.Name, nil
+			}
+		}
+		return qualify(f.pkg) + t.Name, nil
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		pkg, ok := f.imports[x.Name]
+		if !ok {
+			return "", fmt.Errorf("%s is not an imported package", x.Name)
+		}
+		return qualify(pkg) + t.Sel.Name, nil
+	case *ast.StarExpr:
+		s, err := f.typeString(t.X, qualify)
+		return "*" + s, err
+	case *ast.ArrayType:
+		elem, err := f.typeString(t.Elt, qualify)
+		if err != nil || t.Len == nil {
+			return "[]" + elem, err
+		}
+		return "[" + exprString(t.Len) + "]" + elem, nil
+	case *ast.MapType:
+		key, err := f.typeString(t.Key, qualify)
+		if err != nil {
+			return "", err
+		}
+		value, err := f.typeString(t.Value, qualify)
+		return "map[" + key + "]" + value, err
+	case *ast.InterfaceType:
+		if t.Methods == nil || len(t.Methods.List) == 0 {
+			return "interface{}", nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", exprString(expr))
+}
+
+// checkProviders reports every parameter that no provider or external type
+// supplies under the features the parameter is compiled with, and types
+// that are provided more than once.
+func checkProviders(providers []*provider, externals []external) error {
+	type source struct {
+		name  string
+		conds []string
+	}
+	sources := make(map[string][]source)
+	var errs []error
+	for _, e := range externals {
+		key, err := e.typ.key()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sources[key] = append(sources[key], source{name: "external", conds: e.conds})
+	}
+	for _, p := range providers {
+		key, err := p.result.key()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", p.name, err))
+			continue
+		}
+		for _, s := range sources[key] {
+			errs = append(errs, fmt.Errorf("%s is provided by both %s and %s", displayType(p.result), s.name, p.name))
+		}
+		sources[key] = append(sources[key], source{name: p.name, conds: p.conds})
+	}
+
+	for _, p := range providers {
+		for i, prm := range p.params {
+			key, err := prm.typ.key()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s parameter %d: %v", p.name, i, err))
+				continue
+			}
+			conds := append(slices.Clone(p.conds), prm.conds...)
+			found := false
+			for _, s := range sources[key] {
+				if subset(s.conds, conds) {
+					found = true
+				}
+			}
+			if !found {
+				msg := fmt.Sprintf("no provider for %s needed by %s parameter %d", displayType(prm.typ), p.name, i)
+				if prm.name != "" {
+					msg += " (" + prm.name + ")"
+				}
+				if len(conds) > 0 {
+					msg += " with features " + strings.Join(conds, ", ")
+				}
+				errs = append(errs, errors.New(msg))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// displayType writes t with package names for error messages.
+func displayType(t typeRef) string {
+	s, err := t.file.typeString(t.expr, func(pkg string) string { return path.Base(pkg) + "." })
+	if err != nil {
+		return exprString(t.expr)
+	}
+	return s
+}
+
+// subset reports whether every feature in a is in b.
+func subset(a, b []string) bool {
+	for _, f := range a {
+		if !slices.Contains(b, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// importSet names the packages that the generated file uses and records the
+// features under which each is used.
+type importSet struct {
+	self    string                // the di package, which is not imported
+	pkgName func(string) string   // declared name of a package
+	names   map[string]string     // import path -> name in the generated file
+	uses    map[string][][]string // import path -> features of each use
+	taken   map[string]bool
+}
+
+func newImportSet(decl *sourceFile, pkgName func(string) string) *importSet {
+	s := &importSet{
+		self:    decl.pkg,
+		pkgName: pkgName,
+		names:   make(map[string]string),
+		uses:    make(map[string][][]string),
+		taken:   make(map[string]bool),
+	}
+	// Keep the names used in providers.go, so aliases carry over
+	for name, pkg := range decl.imports {
+		s.names[pkg] = name
+		s.taken[name] = true
+	}
+	return s
+}
+
+func (s *importSet) use(pkg string, conds []string) {
+	s.uses[pkg] = append(s.uses[pkg], conds)
+	if _, ok := s.names[pkg]; ok {
+		return
+	}
+	// On a clash prefer the parent directory, e.g. postRepo for .../post/repo
+	base := s.pkgName(pkg)
+	name := base
+	if parent := path.Base(path.Dir(pkg)); s.taken[name] && token.IsIdentifier(parent) {
+		name = parent + strings.ToUpper(base[:1]) + base[1:]
+	}
+	for i := 2; s.taken[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	s.names[pkg] = name
+	s.taken[name] = true
+}
+
+func (s *importSet) qualifier(conds []string) func(pkg string) string {
+	return func(pkg string) string {
+		if pkg == s.self {
+			return ""
+		}
+		s.use(pkg, conds)
+		return s.names[pkg] + "."
+	}
+}
+
+// typeString writes t for the generated file, recording its imports.
+func (s *importSet) typeString(t typeRef, conds []string) string {
+	str, _ := t.file.typeString(t.expr, s.qualifier(conds))
+	return str
+}
+
+// gate returns the features to put the import of pkg under: the smallest
+// set among its uses, which must be part of every other use so the import
+// is present exactly when something uses it.
+func (s *importSet) gate(pkg string) ([]string, error) {
+	uses := s.uses[pkg]
+	gate := uses[0]
+	for _, u := range uses {
+		if len(u) < len(gate) {
+			gate = u
+		}
+	}
+	for _, u := range uses {
+		if !subset(gate, u) {
+			return nil, fmt.Errorf("cannot place import %s under goinit:if markers: it is used with features %v and %v", pkg, gate, u)
+		}
+	}
+	return gate, nil
+}
+
+// writer writes Go source with goinit:if blocks.
+type writer struct {
+	bytes.Buffer
+}
+
+func (w *writer) open(conds []string) {
+	for _, c := range conds {
+		fmt.Fprintf(w, "// goinit:if %s\n", c)
+	}
+}
+
+func (w *writer) close(conds []string) {
+	for range conds {
+		w.WriteString("// goinit:end\n")
+	}
+}
+
+func render(decl *sourceFile, providers []*provider, pkgName func(string) string) ([]byte, error) {
+	imports := newImportSet(decl, pkgName)
+	var body writer
+	for _, p := range providers {
+		renderProvider(&body, imports, p)
+	}
+
+	var w writer
+	w.WriteString("// Code generated by diwire from providers.go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&w, "package %s\n\n", decl.file.Name.Name)
+
+	var paths []string
+	for pkg := range imports.uses {
+		paths = append(paths, pkg)
+	}
+	sort.Strings(paths)
+	if len(paths) > 0 {
+		w.WriteString("import (\n")
+		for _, pkg := range paths {
+			gate, err := imports.gate(pkg)
+			if err != nil {
+				return nil, err
+			}
+			w.open(gate)
+			if name := imports.names[pkg]; name != pkgName(pkg) {
+				fmt.Fprintf(&w, "%s %q\n", name, pkg)
+			} else {
+				fmt.Fprintf(&w, "%q\n", pkg)
+			}
+			w.close(gate)
+		}
+		w.WriteString(")\n\n")
+	}
+
+	w.WriteString("// registerProviders registers the constructors listed in providers.go as\n")
+	w.WriteString("// singletons that resolve their parameters without reflection.\n")
+	w.WriteString("func registerProviders(c *Container) error {\n")
+	w.Write(body.Bytes())
+	w.WriteString("return nil\n}\n")
+
+	src, err := format.Source(w.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid code: %v\n%s", err, w.Bytes())
+	}
+	return src, nil
+}
+
+// reserved are the identifiers that the generated provider functions use.
+var reserved = map[string]bool{
+	"c": true, "err": true, "zero": true, "Container": true, "RegisterProvider": true,
+	"Resolve": true, "Dep": true, "Singleton": true,
+}
+
+func renderProvider(w *writer, imports *importSet, p *provider) {
+	result := imports.typeString(p.result, p.conds)
+	fn := imports.qualifier(p.conds)(p.fn.pkg) + p.fn.name
+
+	// Parameter names become variables of the provider function, unless
+	// they would shadow something it uses
+	vars := make([]string, len(p.params))
+	typeStrings := make([]string, len(p.params))
+	for i, prm := range p.params {
+		typeStrings[i] = imports.typeString(prm.typ, append(slices.Clone(p.conds), prm.conds...))
+	}
+	for i, prm := range p.params {
+		vars[i] = prm.name
+		if vars[i] == "" || vars[i] == "_" || reserved[vars[i]] || imports.taken[vars[i]] {
+			vars[i] = fmt.Sprintf("arg%d", i)
+		}
+	}
+
+	w.open(p.conds)
+	fmt.Fprintf(w, "// %s from %s\n", result, p.name)
+	fmt.Fprintf(w, "if err := RegisterProvider(c, func(c *Container) (%s, error) {\n", result)
+	for i, prm := range p.params {
+		w.open(prm.conds)
+		fmt.Fprintf(w, "%s, err := Resolve[%s](c)\n", vars[i], typeStrings[i])
+		fmt.Fprintf(w, "if err != nil {\nvar zero %s\nreturn zero, err\n}\n", result)
+		w.close(prm.conds)
+	}
+	// Arguments go on lines of their own, so markers can surround any of
+	// them and the layout stays the same when they are pruned
+	args := func(format string, values []string) {
+		for i, prm := range p.params {
+			w.open(prm.conds)
+			fmt.Fprintf(w, format+",\n", values[i])
+			w.close(prm.conds)
+		}
+	}
+
+	fmt.Fprintf(w, "return %s(", fn)
+	if len(p.params) > 0 {
+		w.WriteString("\n")
+	}
+	args("%s", vars)
+	if p.errs {
+		w.WriteString(")\n")
+	} else {
+		w.WriteString("), nil\n")
+	}
+	w.WriteString("}, Singleton,\n")
+	args("Dep[%s]()", typeStrings)
+	w.WriteString("); err != nil {\nreturn err\n}\n")
+	w.close(p.conds)
+	w.WriteString("\n")
+}
@@ -3,25 +3,43 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/lpernett/godotenv"
 )
 
 type Config struct {
+	AppName string
+
 	PublicHost string
 	Port       string
 
+	// HTTP server timeouts; ShutdownTimeout bounds the whole graceful shutdown
+	ServerReadTimeout  time.Duration
+	ServerWriteTimeout time.Duration
+	ServerIdleTimeout  time.Duration
+	ShutdownTimeout    time.Duration
+
+	DBDriver   string
+	DBHost     string
+	DBPort     string
 	DBUser     string
 	DBPassword string
-	DBName     string
-	DBAddress  string
-	DBDriver   string
+	DBName     string // database name, or the database file for sqlite
+	DBSSLMode  string // postgres only
+	DBParams   string // extra DSN options as a URL query, e.g. "connect_timeout=5"
+	DBDSN      string // full DSN; overrides the fields above when set
+
+	// DBAutoMigrate creates tables from the GORM models at boot instead of
+	// requiring "migrate up". For development only; refused in release mode.
+	DBAutoMigrate bool
 
 	LogLevel  string
 	LogFile   string
 	LogToFile bool
 	RunMode   string
 
+	// goinit:if auth
 	SessionSecret string
 	SessionName   string
 	SessionSecure bool
@@ -29,9 +47,23 @@ type Config struct {
 	SessionMaxAge int
 
 	// JWT Configuration
-	JWTSecret      string
-	UseDatabaseJWT bool
-
+	JWTSecret string
+	// JWTPreviousSecrets are replaced JWT secrets as "secret@replaced-at"
+	// entries, which verify tokens for JWTKeyGracePeriod
+	JWTPreviousSecrets string
+	JWTAlgorithm       string
+	JWTKeyDir          string
+	JWTKeyGracePeriod  time.Duration
+	UseDatabaseJWT     bool
+	// JWTBlacklist is "redis", "database" or "memory"; empty follows UseDatabaseJWT
+	JWTBlacklist          string
+	JWTBlacklistCacheSize int
+	JWTBlacklistCacheTTL  time.Duration
+	// JWTFailOpen accepts tokens while a token store is down instead of rejecting them
+	JWTFailOpen bool
+	// goinit:end
+
+	// goinit:if email
 	// Email Configuration
 	EmailHost     string
 	EmailPort     int
@@ -40,20 +72,29 @@ type Config struct {
 	EmailFrom     string
 	UseLocalEmail bool
 	EmailLogPath  string
+	// goinit:end
 
+	// goinit:if redis
 	// Redis Configuration
 	RedisAddr     string
 	RedisPassword string
 	RedisDB       int
+	// goinit:end
 
+	// goinit:if pwreset
 	// Password Reset Configuration
 	UseDatabasePWReset bool
+	// goinit:end
 
+	// goinit:if storage
 	// Storage Configuration
-	StorageBackend      string // local or s3
+	StorageBackend string // local or s3
+	// goinit:if storage:local
 	UploadBaseDir       string // e.g. ./uploads
 	UploadPublicBaseURL string // e.g. /uploads
+	// goinit:end
 
+	// goinit:if storage:s3
 	// S3 Configuration
 	S3Endpoint        string
 	S3Region          string
@@ -63,6 +104,8 @@ type Config struct {
 	S3UseSSL          bool
 	S3ForcePathStyle  bool
 	S3PublicBaseURL   string
+	// goinit:end
+	// goinit:end
 }
 
 var Envs = initConfig()
@@ -71,51 +114,86 @@ func initConfig() Config {
 	godotenv.Load()
 
 	return Config{
+		AppName: getEnv("APP_NAME", "Go Gin API"),
+
 		PublicHost: getEnv("PUBLIC_HOST", "http://localhost"),
 		Port:       getEnv("PORT", "8080"),
+
+		ServerReadTimeout:  getEnvDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ServerWriteTimeout: getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		ServerIdleTimeout:  getEnvDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout:    getEnvDuration("SHUTDOWN_TIMEOUT", 20*time.Second),
+
 		DBDriver:   getEnv("DB_DRIVER", "sqlite"),
+		DBHost:     getEnv("DB_HOST", "127.0.0.1"),
+		DBPort:     getEnv("DB_PORT", ""),
 		DBUser:     getEnv("DB_USER", "root"),
 		DBPassword: getEnv("DB_PASSWORD", "password"),
-		DBName:     getEnv("DB_NAME", "ecomerce"),
-		DBAddress:  getEnv("DB_ADDRESS", fmt.Sprintf("%s:%s", getEnv("DB_HOST", "127.0.0.1"), getEnv("DB_PORT", "3306"))),
+		DBName:     getEnv("DB_NAME", "app.db"),
+		DBSSLMode:  getEnv("DB_SSLMODE", ""),
+		DBParams:   getEnv("DB_PARAMS", ""),
+		DBDSN:      getEnv("DB_DSN", ""),
+
+		DBAutoMigrate: getEnvBool("DB_AUTO_MIGRATE", false),
 
 		LogLevel:  getEnv("LOG_LEVEL", "info"),
 		LogFile:   getEnv("LOG_FILE", "logs/app.log"),
 		LogToFile: getEnvBool("LOG_FILE_ENABLED", false),
 		RunMode:   getEnv("GIN_MODE", "debug"),
 
+		// goinit:if auth
 		SessionSecret: getEnv("SESSION_SECRET", "dev-secret-change-me"),
-		SessionName:   getEnv("SESSION_NAME", "hor_session"),
+		SessionName:   getEnv("SESSION_NAME", "session"),
 		SessionSecure: getEnvBool("SESSION_SECURE", false),
 		SessionDomain: getEnv("SESSION_DOMAIN", ""),
 		SessionMaxAge: getEnvInt("SESSION_MAX_AGE", 86400),
 
 		// JWT Configuration
-		JWTSecret:      getEnv("JWT_SECRET", "dev-jwt-secret-change-me-in-production"),
-		UseDatabaseJWT: getEnvBool("USE_DATABASE_JWT", false),
-
+		JWTSecret:          getEnv("JWT_SECRET", "dev-jwt-secret-change-me-in-production"),
+		JWTPreviousSecrets: getEnv("JWT_PREVIOUS_SECRETS", ""),
+		JWTAlgorithm:       getEnv("JWT_ALGORITHM", "HS256"),
+		JWTKeyDir:          getEnv("JWT_KEY_DIR", "./keys"),
+		JWTKeyGracePeriod:  getEnvDuration("JWT_KEY_GRACE_PERIOD", 720*time.Hour),
+		UseDatabaseJWT:     getEnvBool("USE_DATABASE_JWT", false),
+
+		JWTBlacklist:          getEnv("JWT_BLACKLIST", ""),
+		JWTBlacklistCacheSize: getEnvInt("JWT_BLACKLIST_CACHE_SIZE", 0),
+		JWTBlacklistCacheTTL:  getEnvDuration("JWT_BLACKLIST_CACHE_TTL", 5*time.Second),
+		JWTFailOpen:           getEnvBool("JWT_FAIL_OPEN", false),
+		// goinit:end
+
+		// goinit:if email
 		// Email Configuration
 		EmailHost:     getEnv("EMAIL_HOST", "smtp.gmail.com"),
 		EmailPort:     getEnvInt("EMAIL_PORT", 587),
 		EmailUsername: getEnv("EMAIL_USERNAME", ""),
 		EmailPassword: getEnv("EMAIL_PASSWORD", ""),
-		EmailFrom:     getEnv("EMAIL_FROM", "noreply@gopadi.com"),
+		EmailFrom:     getEnv("EMAIL_FROM", "noreply@example.com"),
 		UseLocalEmail: getEnvBool("USE_LOCAL_EMAIL", true),
 		EmailLogPath:  getEnv("EMAIL_LOG_PATH", "./logs/emails.log"),
+		// goinit:end
 
+		// goinit:if redis
 		// Redis Configuration
 		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       getEnvInt("REDIS_DB", 0),
+		// goinit:end
 
+		// goinit:if pwreset
 		// Password Reset Configuration
 		UseDatabasePWReset: getEnvBool("USE_DATABASE_PWRESET", false),
+		// goinit:end
 
+		// goinit:if storage
 		// Storage Configuration
-		StorageBackend:      getEnv("STORAGE_BACKEND", "local"),
+		StorageBackend: getEnv("STORAGE_BACKEND", "local"),
+		// goinit:if storage:local
 		UploadBaseDir:       getEnv("UPLOAD_BASE_DIR", "./uploads"),
 		UploadPublicBaseURL: getEnv("UPLOAD_PUBLIC_BASE_URL", "/uploads"),
+		// goinit:end
 
+		// goinit:if storage:s3
 		// S3 Configuration
 		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
 		S3Region:          getEnv("S3_REGION", "us-east-1"),
@@ -125,6 +203,8 @@ func initConfig() Config {
 		S3UseSSL:          getEnvBool("S3_USE_SSL", true),
 		S3ForcePathStyle:  getEnvBool("S3_FORCE_PATH_STYLE", false),
 		S3PublicBaseURL:   getEnv("S3_PUBLIC_BASE_URL", ""),
+		// goinit:end
+		// goinit:end
 	}
 }
 
@@ -159,3 +239,13 @@ func getEnvInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+// getEnvDuration parses values such as "15s" or "1m30s".
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
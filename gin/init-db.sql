@@ -1,9 +0,0 @@
--- GoPadi Database Initialization
--- This file will be executed when the PostgreSQL container starts for the first time
-
--- Create extensions if needed
--- CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
--- CREATE EXTENSION IF NOT EXISTS "pgcrypto";
-
--- Database is ready for GORM auto-migration
--- The Go application will create tables automatically
@@ -13,5 +13,10 @@ func (s *UserService) ResetPassword(userID, newPassword string) error {
 		return err
 	}
 
-	return s.userRepo.UpdatePassword(user.ID, hashed)
+	if err := s.userRepo.UpdatePassword(user.ID, hashed); err != nil {
+		return err
+	}
+
+	// Whoever made the user reset their password may hold their tokens
+	return s.tokens.RevokeUserTokens(user.ID)
 }
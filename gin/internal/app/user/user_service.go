@@ -11,20 +11,36 @@ import (
 	"github.com/SOG-web/goinit/gin/internal/domain/model"
 	userModel "github.com/SOG-web/goinit/gin/internal/domain/user/model"
 	"github.com/SOG-web/goinit/gin/internal/domain/user/repo"
+	// goinit:if email
 	"github.com/SOG-web/goinit/gin/internal/lib/email"
+	// goinit:end
 	"github.com/SOG-web/goinit/gin/internal/lib/id"
+	"github.com/SOG-web/goinit/gin/internal/lib/jwt"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
-	userRepo     repo.UserRepository
+	userRepo repo.UserRepository
+	// tokens revokes a user's tokens when their password or status changes
+	tokens jwt.JWTServiceInterface
+	// goinit:if email
 	emailService email.EmailServiceInterface
+	// goinit:end
 }
 
-func NewUserService(userRepo repo.UserRepository, emailService email.EmailServiceInterface) *UserService {
+func NewUserService(
+	userRepo repo.UserRepository,
+	tokens jwt.JWTServiceInterface,
+	// goinit:if email
+	emailService email.EmailServiceInterface,
+	// goinit:end
+) *UserService {
 	return &UserService{
-		userRepo:     userRepo,
+		userRepo: userRepo,
+		tokens:   tokens,
+		// goinit:if email
 		emailService: emailService,
+		// goinit:end
 	}
 }
 
@@ -81,6 +97,7 @@ func (s *UserService) RegisterUser(username, email, firstName, lastName, passwor
 		return nil, err
 	}
 
+	// goinit:if email
 	// Send OTP email
 	if s.emailService != nil {
 		err = s.emailService.SendOTPEmail(user.Email, user.FirstName, otp)
@@ -90,6 +107,7 @@ func (s *UserService) RegisterUser(username, email, firstName, lastName, passwor
 			fmt.Printf("Failed to send OTP email: %v\n", err)
 		}
 	}
+	// goinit:end
 
 	return user, nil
 }
@@ -149,6 +167,7 @@ func (s *UserService) VerifyOTP(email, otp string) error {
 		return err
 	}
 
+	// goinit:if email
 	// Send welcome email
 	if s.emailService != nil {
 		err = s.emailService.SendWelcomeEmail(user.Email, user.FirstName)
@@ -157,6 +176,7 @@ func (s *UserService) VerifyOTP(email, otp string) error {
 			fmt.Printf("Failed to send welcome email: %v\n", err)
 		}
 	}
+	// goinit:end
 
 	return nil
 }
@@ -177,6 +197,7 @@ func (s *UserService) ResendOTP(userID string) error {
 		return err
 	}
 
+	// goinit:if email
 	// Send OTP email
 	if s.emailService != nil {
 		err = s.emailService.SendOTPEmail(user.Email, user.FirstName, otp)
@@ -185,6 +206,7 @@ func (s *UserService) ResendOTP(userID string) error {
 			fmt.Printf("Failed to send OTP email: %v\n", err)
 		}
 	}
+	// goinit:end
 
 	return nil
 }
@@ -213,12 +235,17 @@ func (s *UserService) ChangePassword(userID, oldPassword, newPassword string) er
 		return err
 	}
 
-	return nil
+	// Log out every session, in case the old password leaked. This comes
+	// after the update so that no login with the old password can follow it
+	return s.tokens.RevokeUserTokens(user.ID)
 }
 
 // DeleteAccount deletes a user account (Django's delete_account equivalent)
 func (s *UserService) DeleteAccount(userID string) error {
-	return s.userRepo.Delete(userID)
+	if err := s.userRepo.Delete(userID); err != nil {
+		return err
+	}
+	return s.tokens.RevokeUserTokens(userID)
 }
 
 // GetAllUsers returns all users (for admin purposes)
@@ -282,6 +309,7 @@ func (s *UserService) GetUserList(limit, offset int) ([]*userModel.User, error)
 	return s.userRepo.List(limit, offset)
 }
 
+// goinit:if email
 // SendBulkEmail sends email to multiple users (Django equivalent)
 func (s *UserService) SendBulkEmail(userIDs []string, subject, content string) error {
 	var emails []string
@@ -303,6 +331,8 @@ func (s *UserService) SendBulkEmail(userIDs []string, subject, content string) e
 	return nil
 }
 
+// goinit:end
+
 
 
 // ActivateUser activates a user account (admin function)
@@ -328,7 +358,12 @@ func (s *UserService) DeactivateUser(userID string) error {
 	user.IsActive = false
 	user.UpdatedAt = time.Now()
 
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	// Tokens are only checked for validity, not the account; revoke them
+	return s.tokens.RevokeUserTokens(user.ID)
 }
 
 // GetUserStats returns user statistics (admin function)
@@ -422,9 +457,58 @@ func (s *UserService) ForceVerifyUser(userID string) error {
 	return s.userRepo.MarkAsVerified(user.ID)
 }
 
+// CreateSuperuser creates an active, verified staff account with every permission (Django's createsuperuser equivalent)
+func (s *UserService) CreateSuperuser(username, email, password string) (*userModel.User, error) {
+	if err := s.ValidateEmail(email); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &userModel.User{
+		Base: model.Base{
+			ID:        id.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Username:    username,
+		Email:       email,
+		Password:    hashedPassword,
+		IsActive:    true,
+		IsVerified:  true,
+		IsStaff:     true,
+		IsSuperuser: true,
+		DateJoined:  now,
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 // NewService creates a new UserService (compatibility function)
-func NewService(userRepo repo.UserRepository, emailService email.EmailServiceInterface) *UserService {
-	return NewUserService(userRepo, emailService)
+func NewService(
+	userRepo repo.UserRepository,
+	tokens jwt.JWTServiceInterface,
+	// goinit:if email
+	emailService email.EmailServiceInterface,
+	// goinit:end
+) *UserService {
+	return NewUserService(
+		userRepo,
+		tokens,
+		// goinit:if email
+		emailService,
+		// goinit:end
+	)
 }
 
 // GenerateOTP generates a 6-digit OTP
@@ -0,0 +1,212 @@
+package user
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	userGORM "github.com/SOG-web/goinit/gin/internal/data/user/model/gorm"
+	userRepo "github.com/SOG-web/goinit/gin/internal/data/user/repo"
+	"github.com/SOG-web/goinit/gin/internal/domain/user/model"
+	"github.com/SOG-web/goinit/gin/internal/domain/user/repo"
+	"github.com/SOG-web/goinit/gin/internal/lib/jwt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// hookedRepo calls beforeWrite before it saves a change to a user, standing
+// in for a request served while the change is being made. An error from
+// beforeWrite fails the write.
+type hookedRepo struct {
+	repo.UserRepository
+	beforeWrite func() error
+}
+
+func (r *hookedRepo) write() error {
+	if r.beforeWrite == nil {
+		return nil
+	}
+	return r.beforeWrite()
+}
+
+func (r *hookedRepo) Update(user *model.User) error {
+	if err := r.write(); err != nil {
+		return err
+	}
+	return r.UserRepository.Update(user)
+}
+
+func (r *hookedRepo) UpdatePassword(id, newPassword string) error {
+	if err := r.write(); err != nil {
+		return err
+	}
+	return r.UserRepository.UpdatePassword(id, newPassword)
+}
+
+func (r *hookedRepo) Delete(id string) error {
+	if err := r.write(); err != nil {
+		return err
+	}
+	return r.UserRepository.Delete(id)
+}
+
+// newTestService returns a user service and the JWT service it revokes
+// tokens with, both on a test database, the repository the user service
+// writes through and a user with password "old-password"
+func newTestService(t *testing.T) (*UserService, *jwt.JWTService, *hookedRepo, string) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&userGORM.UserGORM{}, &jwt.RefreshTokenFamily{}, &jwt.TokenValidAfter{}, &jwt.BlacklistedToken{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := jwt.NewJWTService(jwt.NewKeyring(jwt.NewHMACKey("test-secret")), time.Hour, 24*time.Hour, jwt.Stores{
+		Blacklist:  jwt.NewDatabaseTokenBlacklist(db),
+		Refresh:    jwt.NewDatabaseRefreshTokenStore(db),
+		ValidAfter: jwt.NewDatabaseValidAfterStore(db),
+	}, jwt.FailClosed)
+	users := &hookedRepo{UserRepository: userRepo.NewUserRepositoryGORM(db)}
+	s := NewUserService(
+		users,
+		tokens,
+		// goinit:if email
+		nil,
+		// goinit:end
+	)
+
+	user, err := s.CreateSuperuser("alice", "alice@example.com", "old-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, tokens, users, user.ID
+}
+
+// TestRevokeUserTokens checks that the password and account changes reject
+// the tokens issued before them or while they are saved, even within the
+// same second, and accept those issued after.
+func TestRevokeUserTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *UserService, userID string) error
+	}{
+		{
+			name: "ChangePassword",
+			change: func(s *UserService, userID string) error {
+				return s.ChangePassword(userID, "old-password", "new-password")
+			},
+		},
+		// goinit:if pwreset
+		{
+			name: "ResetPassword",
+			change: func(s *UserService, userID string) error {
+				return s.ResetPassword(userID, "new-password")
+			},
+		},
+		// goinit:end
+		{
+			name:   "DeactivateUser",
+			change: func(s *UserService, userID string) error { return s.DeactivateUser(userID) },
+		},
+		{
+			name:   "DeleteAccount",
+			change: func(s *UserService, userID string) error { return s.DeleteAccount(userID) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, tokens, users, userID := newTestService(t)
+			user, err := s.GetUserByID(userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before, err := tokens.GenerateTokenPair(user, jwt.ClientInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// A login served while the change is saved, before it applies
+			var during *jwt.TokenPair
+			users.beforeWrite = func() (err error) {
+				during, err = tokens.GenerateTokenPair(user, jwt.ClientInfo{})
+				return err
+			}
+			if err := tt.change(s, userID); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if during == nil {
+				t.Fatalf("%s did not save a change to the user", tt.name)
+			}
+			// Tokens of the revocation's millisecond are rejected too
+			time.Sleep(time.Millisecond)
+			after, err := tokens.GenerateTokenPair(user, jwt.ClientInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, pair := range map[string]*jwt.TokenPair{"before": before, "during": during} {
+				if _, err := tokens.ValidateToken(pair.AccessToken); !errors.Is(err, jwt.ErrTokenRevoked) {
+					t.Errorf("access token issued %s: ValidateToken = %v, want %v", name, err, jwt.ErrTokenRevoked)
+				}
+				if _, err := tokens.ValidateToken(pair.RefreshToken); !errors.Is(err, jwt.ErrTokenRevoked) {
+					t.Errorf("refresh token issued %s: ValidateToken = %v, want %v", name, err, jwt.ErrTokenRevoked)
+				}
+			}
+
+			claims, err := tokens.ValidateToken(after.AccessToken)
+			if err != nil {
+				t.Fatalf("access token issued after: ValidateToken = %v", err)
+			}
+			if err := tokens.ValidateSession(claims.SessionID); err != nil {
+				t.Errorf("access token issued after: ValidateSession = %v", err)
+			}
+			refreshClaims, err := tokens.ValidateToken(after.RefreshToken)
+			if err != nil {
+				t.Fatalf("refresh token issued after: ValidateToken = %v", err)
+			}
+			if _, err := tokens.RefreshToken(refreshClaims, user); err != nil {
+				t.Errorf("refresh token issued after: RefreshToken = %v", err)
+			}
+		})
+	}
+}
+
+// TestRevokeUserTokensFailedChange checks that a change that cannot be saved
+// leaves the user's sessions alone
+func TestRevokeUserTokensFailedChange(t *testing.T) {
+	s, tokens, users, userID := newTestService(t)
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tokens.GenerateTokenPair(user, jwt.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errDown := errors.New("database down")
+	users.beforeWrite = func() error { return errDown }
+	if err := s.ChangePassword(userID, "old-password", "new-password"); !errors.Is(err, errDown) {
+		t.Fatalf("ChangePassword = %v, want %v", err, errDown)
+	}
+
+	claims, err := tokens.ValidateToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken = %v, want the token accepted", err)
+	}
+	if err := tokens.ValidateSession(claims.SessionID); err != nil {
+		t.Errorf("ValidateSession = %v, want the session active", err)
+	}
+}
@@ -11,7 +11,7 @@ import (
 
 // UserGORM represents the GORM model for User
 type UserGORM struct {
-	ID          string     `gorm:"type:varchar(26);primaryKey"`
+	ID          string     `gorm:"type:varchar(32);primaryKey"`
 	CreatedAt   time.Time  `gorm:"autoCreateTime"`
 	UpdatedAt   time.Time  `gorm:"autoUpdateTime"`
 	Username    string     `gorm:"unique;not null;size:150"`
@@ -19,8 +19,6 @@ type UserGORM struct {
 	FirstName   string     `gorm:"size:150"`
 	LastName    string     `gorm:"size:150"`
 	Password    string     `gorm:"not null;size:128"`
-	Height      float64    `gorm:"not null"`
-	Weight      float64    `gorm:"not null"`
 	OTP         *string    `gorm:"size:6"`
 	IsStaff     bool       `gorm:"default:false"`
 	IsActive    bool       `gorm:"default:true"`
@@ -70,6 +68,8 @@ func (u *UserGORM) ToUserModel() *userModel.User {
 		OTP:         otp,
 		IsActive:    u.IsActive,
 		IsVerified:  u.IsVerified,
+		IsStaff:     u.IsStaff,
+		IsSuperuser: u.IsSuperuser,
 		DateJoined:  u.DateJoined,
 		LastLogin:   u.LastLogin,
 		ProfileImageURL: u.ProfileImageURL,
@@ -95,6 +95,8 @@ func UserModelToGORM(u *userModel.User) *UserGORM {
 		OTP:         otp,
 		IsActive:    u.IsActive,
 		IsVerified:  u.IsVerified,
+		IsStaff:     u.IsStaff,
+		IsSuperuser: u.IsSuperuser,
 		DateJoined:  u.DateJoined,
 		LastLogin:   u.LastLogin,
 		ProfileImageURL: u.ProfileImageURL,
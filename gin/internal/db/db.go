@@ -1,6 +1,8 @@
 package db
 
 import (
+	"fmt"
+
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -9,8 +11,27 @@ import (
 	"github.com/SOG-web/goinit/gin/config"
 )
 
+// Open connects to the database selected by cfg.DBDriver.
+func Open(cfg config.Config) (*gorm.DB, error) {
+	switch cfg.DBDriver {
+	case "sqlite":
+		return NewSqliteDb(cfg)
+	case "mysql":
+		return NewMysqlDb(cfg)
+	case "postgres":
+		return NewPostgresDb(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported db driver %q", cfg.DBDriver)
+	}
+}
+
 func NewSqliteDb(cfg config.Config) (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(cfg.DBAddress), &gorm.Config{})
+	dsn, err := SqliteDSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
@@ -19,7 +40,12 @@ func NewSqliteDb(cfg config.Config) (*gorm.DB, error) {
 }
 
 func NewMysqlDb(cfg config.Config) (*gorm.DB, error) {
-	db, err := gorm.Open(mysql.Open(cfg.DBAddress), &gorm.Config{})
+	dsn, err := MysqlDSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +54,12 @@ func NewMysqlDb(cfg config.Config) (*gorm.DB, error) {
 }
 
 func NewPostgresDb(cfg config.Config) (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(cfg.DBAddress), &gorm.Config{})
+	dsn, err := PostgresDSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
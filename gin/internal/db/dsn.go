@@ -0,0 +1,123 @@
+package db
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/SOG-web/goinit/gin/config"
+)
+
+// Default ports of the server databases, used when DB_PORT is empty.
+const (
+	defaultMysqlPort    = "3306"
+	defaultPostgresPort = "5432"
+)
+
+// DSN returns the connection string for cfg.DBDriver. DB_DSN is used as is
+// when it is set; otherwise the DSN is built from the structured DB_* settings.
+func DSN(cfg config.Config) (string, error) {
+	switch cfg.DBDriver {
+	case "sqlite":
+		return SqliteDSN(cfg)
+	case "mysql":
+		return MysqlDSN(cfg)
+	case "postgres":
+		return PostgresDSN(cfg)
+	default:
+		return "", fmt.Errorf("unsupported db driver %q", cfg.DBDriver)
+	}
+}
+
+// SqliteDSN returns the database file named by DB_NAME with DB_PARAMS as its query.
+func SqliteDSN(cfg config.Config) (string, error) {
+	if cfg.DBDSN != "" {
+		return cfg.DBDSN, nil
+	}
+	params, err := parseParams(cfg)
+	if err != nil {
+		return "", err
+	}
+	if len(params) == 0 {
+		return cfg.DBName, nil
+	}
+	return cfg.DBName + "?" + params.Encode(), nil
+}
+
+// MysqlDSN returns a go-sql-driver DSN, e.g. user:pass@tcp(host:3306)/name?parseTime=true.
+// Times are parsed into time.Time in the local zone unless DB_PARAMS says otherwise.
+func MysqlDSN(cfg config.Config) (string, error) {
+	if cfg.DBDSN != "" {
+		return cfg.DBDSN, nil
+	}
+	// The password starts at the first colon, so the user cannot contain one
+	if strings.Contains(cfg.DBUser, ":") {
+		return "", fmt.Errorf("invalid mysql settings: DB_USER %q contains ':', which a mysql DSN cannot hold", cfg.DBUser)
+	}
+	params, err := parseParams(cfg)
+	if err != nil {
+		return "", err
+	}
+	setDefault(params, "charset", "utf8mb4")
+	setDefault(params, "parseTime", "true")
+	setDefault(params, "loc", "Local")
+
+	// Parse the address and options first so the credentials never need escaping
+	addr := net.JoinHostPort(cfg.DBHost, portOrDefault(cfg.DBPort, defaultMysqlPort))
+	mc, err := mysql.ParseDSN("tcp(" + addr + ")/" + cfg.DBName + "?" + params.Encode())
+	if err != nil {
+		return "", fmt.Errorf("invalid mysql settings: %v", err)
+	}
+	mc.User = cfg.DBUser
+	mc.Passwd = cfg.DBPassword
+	return mc.FormatDSN(), nil
+}
+
+// PostgresDSN returns a postgres:// URL with DB_SSLMODE and DB_PARAMS as its query.
+func PostgresDSN(cfg config.Config) (string, error) {
+	if cfg.DBDSN != "" {
+		return cfg.DBDSN, nil
+	}
+	params, err := parseParams(cfg)
+	if err != nil {
+		return "", err
+	}
+	if cfg.DBSSLMode != "" {
+		params.Set("sslmode", cfg.DBSSLMode)
+	}
+	setDefault(params, "sslmode", "disable")
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.DBUser, cfg.DBPassword),
+		Host:     net.JoinHostPort(cfg.DBHost, portOrDefault(cfg.DBPort, defaultPostgresPort)),
+		Path:     "/" + cfg.DBName,
+		RawQuery: params.Encode(),
+	}
+	return u.String(), nil
+}
+
+// parseParams parses DB_PARAMS, a URL query such as "a=1&b=2".
+func parseParams(cfg config.Config) (url.Values, error) {
+	params, err := url.ParseQuery(cfg.DBParams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_PARAMS %q: %v", cfg.DBParams, err)
+	}
+	return params, nil
+}
+
+func setDefault(params url.Values, key, value string) {
+	if !params.Has(key) {
+		params.Set(key, value)
+	}
+}
+
+func portOrDefault(port, fallback string) string {
+	if port == "" {
+		return fallback
+	}
+	return port
+}
@@ -0,0 +1,194 @@
+package db
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/SOG-web/goinit/gin/config"
+)
+
+// awkwardPassword has every character that needs escaping in a DSN
+const awkwardPassword = `p@ss/w:rd%?#&=()`
+
+func TestMysqlDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		addr string
+		loc  string
+		tls  string
+	}{
+		{
+			name: "defaults",
+			cfg:  config.Config{DBHost: "db.internal", DBUser: "shop", DBPassword: awkwardPassword, DBName: "shop_db"},
+			addr: "db.internal:3306",
+			loc:  "Local",
+		},
+		{
+			name: "port and params",
+			cfg:  config.Config{DBHost: "db.internal", DBPort: "3307", DBUser: "sh@p/user", DBPassword: awkwardPassword, DBName: "shop_db", DBParams: "loc=UTC&timeout=5s&tls=skip-verify"},
+			addr: "db.internal:3307",
+			loc:  "UTC",
+			tls:  "skip-verify",
+		},
+		{
+			name: "IPv6 host",
+			cfg:  config.Config{DBHost: "::1", DBUser: "shop", DBPassword: awkwardPassword, DBName: "shop_db"},
+			addr: "[::1]:3306",
+			loc:  "Local",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := MysqlDSN(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mc, err := mysql.ParseDSN(dsn)
+			if err != nil {
+				t.Fatalf("mysql.ParseDSN(%q) = %v", dsn, err)
+			}
+			if mc.User != tt.cfg.DBUser || mc.Passwd != tt.cfg.DBPassword {
+				t.Errorf("credentials = %q, %q, want %q, %q", mc.User, mc.Passwd, tt.cfg.DBUser, tt.cfg.DBPassword)
+			}
+			if mc.Net != "tcp" || mc.Addr != tt.addr || mc.DBName != tt.cfg.DBName {
+				t.Errorf("address = %s(%s)/%s, want tcp(%s)/%s", mc.Net, mc.Addr, mc.DBName, tt.addr, tt.cfg.DBName)
+			}
+			if !mc.ParseTime || mc.Loc.String() != tt.loc || mc.TLSConfig != tt.tls {
+				t.Errorf("parseTime, loc, tls = %v, %v, %q, want true, %s, %q", mc.ParseTime, mc.Loc, mc.TLSConfig, tt.loc, tt.tls)
+			}
+		})
+	}
+}
+
+func TestMysqlDSNUserWithColon(t *testing.T) {
+	if dsn, err := MysqlDSN(config.Config{DBHost: "db", DBUser: "shop:api", DBPassword: "secret", DBName: "shop_db"}); err == nil {
+		t.Errorf("MysqlDSN = %q, want an error for a user the DSN cannot hold", dsn)
+	}
+}
+
+func TestMysqlDSNParams(t *testing.T) {
+	dsn, err := MysqlDSN(config.Config{DBHost: "db", DBUser: "shop", DBName: "shop_db", DBParams: "charset=latin1&timeout=5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mc, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("mysql.ParseDSN(%q) = %v", dsn, err)
+	}
+	if mc.Timeout.String() != "5s" {
+		t.Errorf("timeout = %v, want 5s", mc.Timeout)
+	}
+	if got := mc.Params["charset"]; got != "latin1" {
+		t.Errorf("charset = %q, want DB_PARAMS to override the default", got)
+	}
+}
+
+func TestPostgresDSN(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.Config
+		host  string
+		query url.Values
+	}{
+		{
+			name:  "defaults",
+			cfg:   config.Config{DBHost: "db.internal", DBUser: "shop", DBPassword: awkwardPassword, DBName: "shop_db"},
+			host:  "db.internal:5432",
+			query: url.Values{"sslmode": {"disable"}},
+		},
+		{
+			name:  "sslmode",
+			cfg:   config.Config{DBHost: "db.internal", DBPort: "6432", DBUser: "sh@p:user", DBPassword: awkwardPassword, DBName: "shop_db", DBSSLMode: "verify-full"},
+			host:  "db.internal:6432",
+			query: url.Values{"sslmode": {"verify-full"}},
+		},
+		{
+			name:  "DB_SSLMODE wins over DB_PARAMS",
+			cfg:   config.Config{DBHost: "db", DBUser: "shop", DBName: "shop_db", DBSSLMode: "require", DBParams: "sslmode=disable&application_name=shop api&search_path=app"},
+			host:  "db:5432",
+			query: url.Values{"sslmode": {"require"}, "application_name": {"shop api"}, "search_path": {"app"}},
+		},
+		{
+			name:  "sslmode from DB_PARAMS",
+			cfg:   config.Config{DBHost: "db", DBUser: "shop", DBName: "shop_db", DBParams: "sslmode=require"},
+			host:  "db:5432",
+			query: url.Values{"sslmode": {"require"}},
+		},
+		{
+			name:  "IPv6 host",
+			cfg:   config.Config{DBHost: "::1", DBUser: "shop", DBName: "shop_db"},
+			host:  "[::1]:5432",
+			query: url.Values{"sslmode": {"disable"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := PostgresDSN(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(dsn)
+			if err != nil {
+				t.Fatalf("url.Parse(%q) = %v", dsn, err)
+			}
+			password, _ := u.User.Password()
+			if u.User.Username() != tt.cfg.DBUser || password != tt.cfg.DBPassword {
+				t.Errorf("credentials = %q, %q, want %q, %q", u.User.Username(), password, tt.cfg.DBUser, tt.cfg.DBPassword)
+			}
+			if u.Scheme != "postgres" || u.Host != tt.host || u.Path != "/"+tt.cfg.DBName {
+				t.Errorf("URL = %s://%s%s, want postgres://%s/%s", u.Scheme, u.Host, u.Path, tt.host, tt.cfg.DBName)
+			}
+			if got := u.Query(); got.Encode() != tt.query.Encode() {
+				t.Errorf("query = %s, want %s", got.Encode(), tt.query.Encode())
+			}
+		})
+	}
+}
+
+func TestSqliteDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want string
+	}{
+		{name: "file", cfg: config.Config{DBName: "shop.db"}, want: "shop.db"},
+		{name: "params", cfg: config.Config{DBName: "shop.db", DBParams: "_pragma=busy_timeout(5000)&_fk=1"}, want: "shop.db?_fk=1&_pragma=busy_timeout%285000%29"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SqliteDSN(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("SqliteDSN = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDSN(t *testing.T) {
+	const custom = "postgres://custom@db/custom"
+	for _, driver := range []string{"sqlite", "mysql", "postgres"} {
+		got, err := DSN(config.Config{DBDriver: driver, DBDSN: custom, DBName: "ignored"})
+		if err != nil {
+			t.Fatalf("%s: %v", driver, err)
+		}
+		if got != custom {
+			t.Errorf("%s: DSN = %q, want DB_DSN as is", driver, got)
+		}
+	}
+
+	if _, err := DSN(config.Config{DBDriver: "oracle"}); err == nil {
+		t.Error("DSN accepted an unsupported driver")
+	}
+	if _, err := DSN(config.Config{DBDriver: "postgres", DBParams: "a=%zz"}); err == nil {
+		t.Error("DSN accepted malformed DB_PARAMS")
+	}
+}
@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// Drivers are the database drivers that get a copy of every migration.
+var Drivers = []string{"sqlite", "mysql", "postgres"}
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Create writes an empty up and down file for a new migration into the
+// directory of every driver under dir, numbered after the newest migration of
+// any driver. It returns the paths of the files it created.
+func Create(dir, name string) ([]string, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("migration name %q must be lower snake_case", name)
+	}
+
+	version, err := NextVersion(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	for _, driver := range Drivers {
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(dir, driver, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				return created, err
+			}
+			body := fmt.Sprintf("-- %s migration %04d_%s for %s\n", direction, version, name, driver)
+			if err := os.WriteFile(file, []byte(body), 0644); err != nil {
+				return created, err
+			}
+			created = append(created, file)
+		}
+	}
+	return created, nil
+}
+
+// NextVersion returns the version after the newest migration of any driver under dir.
+func NextVersion(dir string) (int64, error) {
+	var latest int64
+	for _, driver := range Drivers {
+		entries, err := os.ReadDir(filepath.Join(dir, driver))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range entries {
+			m := fileNamePattern.FindStringSubmatch(e.Name())
+			if m == nil {
+				continue
+			}
+			if v, err := strconv.ParseInt(m[1], 10, 64); err == nil && v > latest {
+				latest = v
+			}
+		}
+	}
+	return latest + 1, nil
+}
@@ -0,0 +1,234 @@
+// Package migrate applies numbered up/down SQL migrations and records them in
+// the schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/SOG-web/goinit/gin/internal/db/migrations"
+)
+
+// Table records the applied migrations.
+const Table = "schema_migrations"
+
+// fileNamePattern matches migration files such as 0001_create_users.up.sql.
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // applied to the database but no longer on disk
+}
+
+// Migrator applies the migrations of one database driver.
+type Migrator struct {
+	db         *gorm.DB
+	driver     string
+	migrations []Migration
+}
+
+// New returns a Migrator for the migrations embedded for driver.
+func New(db *gorm.DB, driver string) (*Migrator, error) {
+	list, err := Load(migrations.FS, driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: list}, nil
+}
+
+// Load reads the migrations in the driver directory of fsys, sorted by version.
+// Every version needs both an up and a down file.
+func Load(fsys fs.FS, driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, driver)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q: %v", driver, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		m := fileNamePattern.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %v", e.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(driver, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", mig.Version, mig.Name)
+		}
+		list = append(list, *mig)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+type appliedRow struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+// ensureTable creates the schema_migrations table if it does not exist.
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec(`CREATE TABLE IF NOT EXISTS ` + Table + ` (
+    version BIGINT NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`).Error
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedRow, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", Table, err)
+	}
+	var rows []appliedRow
+	if err := m.db.WithContext(ctx).Raw(`SELECT version, name, applied_at FROM ` + Table).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", Table, err)
+	}
+	applied := make(map[int64]appliedRow, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// Pending returns the migrations that have not been applied, oldest first.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+// Each migration runs in its own transaction together with its bookkeeping row.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range pending {
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, m.driver, mig.Up); err != nil {
+				return err
+			}
+			return tx.Exec(`INSERT INTO `+Table+` (version, name, applied_at) VALUES (?, ?, ?)`, mig.Version, mig.Name, time.Now().UTC()).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s failed: %v", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns the ones reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, m.driver, mig.Down); err != nil {
+				return err
+			}
+			return tx.Exec(`DELETE FROM `+Table+` WHERE version = ?`, mig.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("reverting migration %04d_%s failed: %v", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Status lists every known migration and every applied migration that is no longer on disk.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Status
+	known := make(map[int64]bool, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		row, ok := applied[mig.Version]
+		list = append(list, Status{Migration: mig, Applied: ok, AppliedAt: row.AppliedAt})
+	}
+	for version, row := range applied {
+		if !known[version] {
+			list = append(list, Status{Migration: Migration{Version: version, Name: row.Name}, Applied: true, AppliedAt: row.AppliedAt, Missing: true})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// execScript runs every statement of a migration file of driver.
+func execScript(tx *gorm.DB, driver, script string) error {
+	for _, stmt := range SplitStatements(script, driver) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
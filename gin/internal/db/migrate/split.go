@@ -0,0 +1,127 @@
+package migrate
+
+import "strings"
+
+// SplitStatements splits a SQL script for driver into statements on the
+// semicolons that are not inside quotes, comments or postgres dollar-quoted
+// bodies. Not every driver accepts several statements in one Exec, so they
+// are run one by one. A backslash escapes the next character in mysql
+// strings and in postgres E'...' strings.
+func SplitStatements(script, driver string) []string {
+	var stmts []string
+	var cur strings.Builder
+
+	flush := func() {
+		if stmt := strings.TrimSpace(cur.String()); stmt != "" && !onlyComments(stmt) {
+			stmts = append(stmts, stmt)
+		}
+		cur.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			backslash := c != '`' && driver == "mysql" || c == '\'' && driver == "postgres" && escapeString(script, i)
+			end := closingQuote(script, i+1, c, backslash)
+			cur.WriteString(script[i:end])
+			i = end - 1
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			cur.WriteString(script[i : i+end])
+			i += end - 1
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 2
+			} else {
+				end += 2
+			}
+			cur.WriteString(script[i : i+2+end])
+			i += 1 + end
+		case c == '$' && driver == "postgres" && (i == 0 || !isIdentChar(script[i-1])):
+			tag, ok := dollarTag(script[i:])
+			if !ok {
+				cur.WriteByte(c)
+				continue
+			}
+			end := strings.Index(script[i+len(tag):], tag)
+			if end < 0 {
+				end = len(script) - i - len(tag)
+			} else {
+				end += len(tag)
+			}
+			cur.WriteString(script[i : i+len(tag)+end])
+			i += len(tag) + end - 1
+		case c == ';':
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts
+}
+
+// closingQuote returns the index just past the quote closing the one before
+// start. A doubled quote is an escaped quote, and so is a quote after a
+// backslash if backslash is set.
+func closingQuote(s string, start int, quote byte, backslash bool) int {
+	for i := start; i < len(s); i++ {
+		if backslash && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] != quote {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(s)
+}
+
+// escapeString reports whether the quote at i opens a postgres E'...' string.
+func escapeString(s string, i int) bool {
+	if i == 0 || s[i-1] != 'E' && s[i-1] != 'e' {
+		return false
+	}
+	return i == 1 || !isIdentChar(s[i-2])
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// dollarTag returns the $tag$ that opens a postgres dollar-quoted string at
+// the start of s. Other drivers have no dollar quoting: mysql and sqlite allow
+// $ in identifiers, and so does postgres after the first character.
+func dollarTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1], true
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9':
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// onlyComments reports whether stmt holds nothing but -- comments.
+func onlyComments(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
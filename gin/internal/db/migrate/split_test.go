@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		script string
+		want   []string
+	}{
+		{
+			name:   "statements",
+			driver: "sqlite",
+			script: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want:   []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:   "no final semicolon",
+			driver: "sqlite",
+			script: "SELECT 1;\nSELECT 2",
+			want:   []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:   "semicolons in quotes",
+			driver: "sqlite",
+			script: `INSERT INTO t VALUES ('a;b', "c;d");` + "INSERT INTO `e;f` VALUES (1);",
+			want:   []string{`INSERT INTO t VALUES ('a;b', "c;d")`, "INSERT INTO `e;f` VALUES (1)"},
+		},
+		{
+			name:   "doubled quote",
+			driver: "sqlite",
+			script: "INSERT INTO t VALUES ('it''s;');SELECT 1;",
+			want:   []string{"INSERT INTO t VALUES ('it''s;')", "SELECT 1"},
+		},
+		{
+			name:   "backslash in a sqlite string",
+			driver: "sqlite",
+			script: `INSERT INTO t VALUES ('C:\');SELECT 1;`,
+			want:   []string{`INSERT INTO t VALUES ('C:\')`, "SELECT 1"},
+		},
+		{
+			name:   "backslash escapes in mysql",
+			driver: "mysql",
+			script: `INSERT INTO t VALUES ('it\'s;', "say \"hi;\"", 'C:\\');SELECT 1;`,
+			want:   []string{`INSERT INTO t VALUES ('it\'s;', "say \"hi;\"", 'C:\\')`, "SELECT 1"},
+		},
+		{
+			name:   "backslash in a postgres string",
+			driver: "postgres",
+			script: `INSERT INTO t VALUES ('C:\');SELECT 1;`,
+			want:   []string{`INSERT INTO t VALUES ('C:\')`, "SELECT 1"},
+		},
+		{
+			name:   "postgres escape string",
+			driver: "postgres",
+			script: `INSERT INTO t VALUES (E'it\'s;');SELECT 1;`,
+			want:   []string{`INSERT INTO t VALUES (E'it\'s;')`, "SELECT 1"},
+		},
+		{
+			name:   "line comments",
+			driver: "sqlite",
+			script: "-- create; the table\nCREATE TABLE a (id INT); -- done;\n-- only a comment;\n",
+			want:   []string{"-- create; the table\nCREATE TABLE a (id INT)"},
+		},
+		{
+			name:   "block comments",
+			driver: "mysql",
+			script: "/* first; */ SELECT 1; /* multi\nline; */ SELECT 2;",
+			want:   []string{"/* first; */ SELECT 1", "/* multi\nline; */ SELECT 2"},
+		},
+		{
+			name:   "dollar-quoted function body",
+			driver: "postgres",
+			script: "CREATE FUNCTION f() RETURNS trigger AS $body$\nBEGIN\n  NEW.updated_at = now();\n  RETURN NEW;\nEND;\n$body$ LANGUAGE plpgsql;\nSELECT 1;",
+			want: []string{
+				"CREATE FUNCTION f() RETURNS trigger AS $body$\nBEGIN\n  NEW.updated_at = now();\n  RETURN NEW;\nEND;\n$body$ LANGUAGE plpgsql",
+				"SELECT 1",
+			},
+		},
+		{
+			name:   "anonymous dollar quotes",
+			driver: "postgres",
+			script: "DO $$ BEGIN PERFORM 1; END $$;SELECT 1;",
+			want:   []string{"DO $$ BEGIN PERFORM 1; END $$", "SELECT 1"},
+		},
+		{
+			name:   "dollar in a postgres identifier",
+			driver: "postgres",
+			script: "CREATE TABLE a$b$ (id INT);CREATE TABLE c (id INT);",
+			want:   []string{"CREATE TABLE a$b$ (id INT)", "CREATE TABLE c (id INT)"},
+		},
+		{
+			name:   "dollars in mysql identifiers",
+			driver: "mysql",
+			script: "CREATE TABLE $a$ (id INT);INSERT INTO $a$ VALUES (1);",
+			want:   []string{"CREATE TABLE $a$ (id INT)", "INSERT INTO $a$ VALUES (1)"},
+		},
+		{
+			name:   "dollars in sqlite identifiers",
+			driver: "sqlite",
+			script: "CREATE TABLE $t$ (id INT);SELECT 1;",
+			want:   []string{"CREATE TABLE $t$ (id INT)", "SELECT 1"},
+		},
+		{
+			name:   "placeholders",
+			driver: "postgres",
+			script: "UPDATE t SET a = $1 WHERE id = $2;SELECT $1;",
+			want:   []string{"UPDATE t SET a = $1 WHERE id = $2", "SELECT $1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitStatements(tt.script, tt.driver); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
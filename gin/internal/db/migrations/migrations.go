@@ -0,0 +1,11 @@
+// Package migrations holds the numbered SQL migrations of every supported
+// database driver. Each driver has its own directory of
+// NNNN_name.up.sql / NNNN_name.down.sql pairs that share version numbers.
+package migrations
+
+import "embed"
+
+// FS contains the sqlite, mysql and postgres migration directories.
+//
+//go:embed sqlite mysql postgres
+var FS embed.FS
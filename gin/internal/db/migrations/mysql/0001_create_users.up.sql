@@ -0,0 +1,18 @@
+CREATE TABLE users (
+    id VARCHAR(32) NOT NULL PRIMARY KEY,
+    created_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL,
+    username VARCHAR(150) NOT NULL UNIQUE,
+    email VARCHAR(254) NOT NULL UNIQUE,
+    first_name VARCHAR(150),
+    last_name VARCHAR(150),
+    password VARCHAR(128) NOT NULL,
+    otp VARCHAR(6),
+    is_staff BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    is_superuser BOOLEAN DEFAULT FALSE,
+    is_verified BOOLEAN DEFAULT FALSE,
+    date_joined DATETIME(3),
+    last_login DATETIME(3),
+    profile_image_url VARCHAR(512)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1 @@
+DROP TABLE blacklisted_tokens;
@@ -0,0 +1,9 @@
+CREATE TABLE blacklisted_tokens (
+    id VARCHAR(32) NOT NULL PRIMARY KEY,
+    created_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL,
+    token_hash VARCHAR(255) NOT NULL UNIQUE,
+    expires_at DATETIME(3) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX idx_blacklisted_tokens_expires_at ON blacklisted_tokens (expires_at);
@@ -0,0 +1,12 @@
+CREATE TABLE refresh_token_families (
+    id VARCHAR(32) NOT NULL PRIMARY KEY,
+    created_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL,
+    user_id VARCHAR(32) NOT NULL,
+    current_jti VARCHAR(32) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    revoked_at DATETIME(3) NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX idx_refresh_token_families_user_id ON refresh_token_families (user_id);
+CREATE INDEX idx_refresh_token_families_expires_at ON refresh_token_families (expires_at);
@@ -0,0 +1,5 @@
+ALTER TABLE refresh_token_families
+    ADD COLUMN device VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN ip VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ADD COLUMN last_seen_at DATETIME(3) NULL;
@@ -0,0 +1,8 @@
+CREATE TABLE token_valid_after (
+    user_id VARCHAR(32) NOT NULL PRIMARY KEY,
+    valid_after DATETIME(3) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX idx_token_valid_after_expires_at ON token_valid_after (expires_at);
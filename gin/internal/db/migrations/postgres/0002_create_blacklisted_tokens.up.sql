@@ -0,0 +1,9 @@
+CREATE TABLE blacklisted_tokens (
+    id VARCHAR(32) NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    token_hash VARCHAR(255) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_blacklisted_tokens_expires_at ON blacklisted_tokens (expires_at);
@@ -0,0 +1,12 @@
+CREATE TABLE refresh_token_families (
+    id VARCHAR(32) NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    user_id VARCHAR(32) NOT NULL,
+    current_jti VARCHAR(32) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX idx_refresh_token_families_user_id ON refresh_token_families (user_id);
+CREATE INDEX idx_refresh_token_families_expires_at ON refresh_token_families (expires_at);
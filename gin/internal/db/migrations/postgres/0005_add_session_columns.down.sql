@@ -0,0 +1,5 @@
+ALTER TABLE refresh_token_families
+    DROP COLUMN device,
+    DROP COLUMN ip,
+    DROP COLUMN user_agent,
+    DROP COLUMN last_seen_at;
@@ -0,0 +1,18 @@
+CREATE TABLE users (
+    id VARCHAR(32) NOT NULL PRIMARY KEY,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    username VARCHAR(150) NOT NULL UNIQUE,
+    email VARCHAR(254) NOT NULL UNIQUE,
+    first_name VARCHAR(150),
+    last_name VARCHAR(150),
+    password VARCHAR(128) NOT NULL,
+    otp VARCHAR(6),
+    is_staff BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    is_superuser BOOLEAN DEFAULT FALSE,
+    is_verified BOOLEAN DEFAULT FALSE,
+    date_joined DATETIME,
+    last_login DATETIME,
+    profile_image_url VARCHAR(512)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE password_reset_tokens (
+    id VARCHAR(32) NOT NULL PRIMARY KEY,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    token VARCHAR(255) NOT NULL UNIQUE,
+    user_id VARCHAR(32) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    is_used BOOLEAN DEFAULT FALSE
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+CREATE INDEX idx_password_reset_tokens_expires_at ON password_reset_tokens (expires_at);
+CREATE INDEX idx_password_reset_tokens_is_used ON password_reset_tokens (is_used);
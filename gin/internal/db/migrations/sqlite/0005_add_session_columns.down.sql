@@ -0,0 +1,4 @@
+ALTER TABLE refresh_token_families DROP COLUMN device;
+ALTER TABLE refresh_token_families DROP COLUMN ip;
+ALTER TABLE refresh_token_families DROP COLUMN user_agent;
+ALTER TABLE refresh_token_families DROP COLUMN last_seen_at;
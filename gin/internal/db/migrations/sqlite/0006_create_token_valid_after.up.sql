@@ -0,0 +1,8 @@
+CREATE TABLE token_valid_after (
+    user_id VARCHAR(32) NOT NULL PRIMARY KEY,
+    valid_after DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_token_valid_after_expires_at ON token_valid_after (expires_at);
@@ -73,3 +73,61 @@ func BenchmarkContainer_Resolve_Factory(b *testing.B) {
 		_, _ = Resolve[*BenchType](c)
 	}
 }
+
+// --- reflective Register vs generated RegisterProvider ---
+// benchDep is a dependency of BenchType, like a repository of a service.
+type benchDep struct{ N int }
+
+func registerReflect(c *Container, scope Scope) {
+	Register[*benchDep](c, func() *benchDep { return &benchDep{N: 1} }, Singleton)
+	Register[*BenchType](c, func(d *benchDep) *BenchType { return &BenchType{N: d.N + 1} }, scope)
+}
+
+// registerProvider registers the same graph the way cmd/diwire generates it.
+func registerProvider(c *Container, scope Scope) {
+	RegisterProvider(c, func(c *Container) (*benchDep, error) { return &benchDep{N: 1}, nil }, Singleton)
+	RegisterProvider(c, func(c *Container) (*BenchType, error) {
+		d, err := Resolve[*benchDep](c)
+		if err != nil {
+			return nil, err
+		}
+		return &BenchType{N: d.N + 1}, nil
+	}, scope, Dep[*benchDep]())
+}
+
+// Startup: build, validate and resolve a container once.
+func benchmarkStartup(b *testing.B, register func(*Container, Scope)) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c := New()
+		register(c, Singleton)
+		if err := c.Validate(); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := Resolve[*BenchType](c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkContainer_Startup_Reflect(b *testing.B)  { benchmarkStartup(b, registerReflect) }
+func BenchmarkContainer_Startup_Provider(b *testing.B) { benchmarkStartup(b, registerProvider) }
+
+// Resolve: a transient with one singleton dependency, built on every call.
+func benchmarkResolveOneArg(b *testing.B, register func(*Container, Scope)) {
+	c := New()
+	register(c, Transient)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Resolve[*BenchType](c)
+	}
+}
+
+func BenchmarkContainer_Resolve_Reflect_OneArg(b *testing.B) {
+	benchmarkResolveOneArg(b, registerReflect)
+}
+
+func BenchmarkContainer_Resolve_Provider_OneArg(b *testing.B) {
+	benchmarkResolveOneArg(b, registerProvider)
+}
@@ -2,29 +2,55 @@
 package di
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/SOG-web/goinit/gin/config"
 	"github.com/SOG-web/goinit/gin/internal/app/user"
-	dataRepo "github.com/SOG-web/goinit/gin/internal/data/user/repo"
 	"github.com/SOG-web/goinit/gin/internal/domain/user/repo"
+	// goinit:if email
 	"github.com/SOG-web/goinit/gin/internal/lib/email"
+	// goinit:end
 	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
+	// goinit:if pwreset
 	"github.com/SOG-web/goinit/gin/internal/lib/pwreset"
+	// goinit:end
+	// goinit:if storage
 	"github.com/SOG-web/goinit/gin/internal/lib/storage"
+	// goinit:end
+	// goinit:if redis
 	"github.com/redis/go-redis/v9"
+	// goinit:end
 	"gorm.io/gorm"
 )
 
 // DIContainer is the global DI container singleton.
 var DIContainer *Container
 
-// InitContainer initializes the DI container with all dependencies.
+// InitContainer initializes the DI container with all dependencies and
+// validates it, so every missing or ambiguous dependency is reported at boot
+// instead of at first use.
 func InitContainer(cfg config.Config, gdb *gorm.DB) error {
+	c, err := BuildContainer(cfg, gdb)
+	if err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid DI container:\n%w", err)
+	}
+	DIContainer = c
+	return nil
+}
+
+// BuildContainer creates the services and registers them in a new container
+// without validating it.
+func BuildContainer(cfg config.Config, gdb *gorm.DB) (*Container, error) {
 	slog.Info("initializing DI container")
 
 	slog.Info("creating services")
+	// goinit:if email
 	// Email service configuration
 	emailConfig := email.EmailConfig{
 		Host:     cfg.EmailHost,
@@ -32,6 +58,7 @@ func InitContainer(cfg config.Config, gdb *gorm.DB) error {
 		Username: cfg.EmailUsername,
 		Password: cfg.EmailPassword,
 		From:     cfg.EmailFrom,
+		AppName:  cfg.AppName,
 	}
 
 	// Email service (using factory pattern)
@@ -44,7 +71,7 @@ func InitContainer(cfg config.Config, gdb *gorm.DB) error {
 	)
 	if err != nil {
 		slog.Error("failed to create email service", "err", err)
-		return err
+		return nil, err
 	}
 
 	if cfg.UseLocalEmail {
@@ -52,10 +79,13 @@ func InitContainer(cfg config.Config, gdb *gorm.DB) error {
 	} else {
 		slog.Info("using production email service")
 	}
+	// goinit:end
 
+	// goinit:if storage
 	// Storage initialization
 	var store storage.Storage
 	switch cfg.StorageBackend {
+	// goinit:if storage:s3
 	case "s3":
 		slog.Info("initializing s3 storage")
 		s3Store, err := storage.NewS3Storage(storage.Config{
@@ -71,17 +101,28 @@ func InitContainer(cfg config.Config, gdb *gorm.DB) error {
 		})
 		if err != nil {
 			slog.Error("failed to init s3 storage, aborting", "err", err)
-			return err
+			return nil, err
 		}
 		store = s3Store
-	default:
+	// goinit:end
+	// goinit:if storage:local
+	case "local", "":
 		slog.Info("initializing local storage")
 		store = storage.NewLocalStorage(cfg.UploadBaseDir, cfg.UploadPublicBaseURL)
+	// goinit:end
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %q", cfg.StorageBackend)
 	}
+	// goinit:end
 
+	// goinit:if redis
 	// Redis configuration (only if needed)
 	var redisClient *redis.Client
-	if !cfg.UseDatabaseJWT || !cfg.UseDatabasePWReset {
+	needsRedis := !cfg.UseDatabaseJWT || cfg.JWTBlacklist == jwtLib.BlacklistRedis
+	// goinit:if pwreset
+	needsRedis = needsRedis || !cfg.UseDatabasePWReset
+	// goinit:end
+	if needsRedis {
 		slog.Info("connecting to redis")
 		redisClient = redis.NewClient(&redis.Options{
 			Addr:     cfg.RedisAddr,
@@ -90,68 +131,138 @@ func InitContainer(cfg config.Config, gdb *gorm.DB) error {
 		})
 		slog.Info("redis connected")
 	}
+	// goinit:end
+
+	// JWT signing keys; asymmetric keys are loaded from (or generated in) the key directory
+	keys, err := jwtLib.LoadKeyring(JWTKeyringConfig(cfg))
+	if err != nil {
+		slog.Error("failed to load jwt keys, aborting", "err", err)
+		return nil, err
+	}
+	slog.Info("jwt keys loaded", "alg", keys.Current().Algorithm, "kid", keys.Current().ID)
 
 	// JWT service configuration (using factory)
-	jwtService := jwtLib.NewJWTServiceFactory(
-		cfg.JWTSecret,
-		24*time.Hour,  // Access token expiry
-		720*time.Hour, // Refresh token expiry (30 days)
-		redisClient,   // Redis client for token blacklisting (nil if using database)
-		gdb,           // Database connection
+	jwtService, err := jwtLib.NewJWTServiceFactory(
+		JWTServiceConfig(cfg),
+		keys,
+		// goinit:if redis
+		redisClient, // Redis client for token stores (nil if using database)
+		// goinit:end
+		gdb, // Database connection
 	)
+	if err != nil {
+		slog.Error("failed to create jwt service, aborting", "err", err)
+		return nil, err
+	}
 	slog.Info("jwt service created")
 
+	// goinit:if pwreset
 	// Password reset service (using factory)
 	pwResetService := pwreset.NewPasswordResetServiceFactory(
+		// goinit:if redis
 		redisClient, // Redis client (nil if using database)
-		gdb,         // Database connection
-		time.Hour,   // TTL
+		// goinit:end
+		gdb,       // Database connection
+		time.Hour, // TTL
 	)
-	
+	// goinit:end
+
 	c := New()
 
-	// Register database
+	// Register database; its connection pool is closed when the container stops
 	if err := Register[*gorm.DB](c, func() *gorm.DB { return gdb }, Singleton); err != nil {
-		return err
+		return nil, err
+	}
+	if err := OnStop[*gorm.DB](c, func(ctx context.Context, gdb *gorm.DB) error {
+		sqlDB, err := gdb.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	}); err != nil {
+		return nil, err
+	}
+
+	// goinit:if redis
+	// Register redis client (only when a service uses Redis); as an io.Closer
+	// it is closed when the container stops
+	if redisClient != nil {
+		if err := Register[*redis.Client](c, func() *redis.Client { return redisClient }, Singleton); err != nil {
+			return nil, err
+		}
 	}
+	// goinit:end
 
-	// Register email service
+	// goinit:if email
+	// Register email service; Close drains its queue when the container stops
 	if err := Provide[email.EmailServiceInterface](c, emailService); err != nil {
-		return err
+		return nil, err
 	}
+	// goinit:end
 
-	// Register JWT service
+	// Register JWT keyring and service
+	if err := Register[*jwtLib.Keyring](c, func() *jwtLib.Keyring { return keys }, Singleton); err != nil {
+		return nil, err
+	}
 	if err := Provide[jwtLib.JWTServiceInterface](c, jwtService); err != nil {
-		return err
+		return nil, err
 	}
-	
+
+	// goinit:if pwreset
 	// Register password reset service
 	if err := Provide[pwreset.PasswordResetServiceInterface](c, pwResetService); err != nil {
-		return err
+		return nil, err
 	}
+	// goinit:end
 
+	// goinit:if storage
 	// Register storage
 	if err := Register[storage.Storage](c, func() storage.Storage { return store }, Singleton); err != nil {
-		return err
+		return nil, err
 	}
+	// goinit:end
 
-	// Register user repository
-	if err := Register[repo.UserRepository](c, func(db *gorm.DB) repo.UserRepository {
-		return dataRepo.NewGormUserRepository(db)
-	}, Singleton); err != nil {
-		return err
+	if err := RegisterServices(c); err != nil {
+		return nil, err
 	}
+	return c, nil
+}
 
-	// Register user service
-	if err := Register[*user.UserService](c, func(userRepo repo.UserRepository, emailSvc email.EmailServiceInterface) *user.UserService {
-		return user.NewUserService(userRepo, emailSvc)
-	}, Singleton); err != nil {
-		return err
+// JWTKeyringConfig returns the JWT key settings of cfg.
+func JWTKeyringConfig(cfg config.Config) jwtLib.KeyringConfig {
+	return jwtLib.KeyringConfig{
+		Algorithm:       cfg.JWTAlgorithm,
+		Secret:          cfg.JWTSecret,
+		PreviousSecrets: cfg.JWTPreviousSecrets,
+		KeyDir:          cfg.JWTKeyDir,
+		GracePeriod:     cfg.JWTKeyGracePeriod,
+	}
+}
+
+// JWTServiceConfig returns the JWT service settings of cfg.
+func JWTServiceConfig(cfg config.Config) jwtLib.ServiceConfig {
+	return jwtLib.ServiceConfig{
+		TokenExpiry:        24 * time.Hour,  // Access token expiry
+		RefreshExpiry:      720 * time.Hour, // Refresh token expiry (30 days)
+		UseDatabase:        cfg.UseDatabaseJWT,
+		Blacklist:          cfg.JWTBlacklist,
+		BlacklistCacheSize: cfg.JWTBlacklistCacheSize,
+		BlacklistCacheTTL:  cfg.JWTBlacklistCacheTTL,
+		FailOpen:           cfg.JWTFailOpen,
 	}
+}
 
-	// TODO: Add more registrations for other services/repos as needed
+// RegisterServices registers the repositories and application services.
+// They are built from what BuildContainer registers (the database, email
+// and other infrastructure), which tests can replace with in-memory fakes,
+// see NewTestContainer. Their constructors are listed in providers.go and
+// wired without reflection by the generated registerProviders.
+func RegisterServices(c *Container) error {
+	if err := registerProviders(c); err != nil {
+		return err
+	}
 
-	DIContainer = c
+	// TODO: Register services that need more than a constructor call here
 	return nil
 }
 
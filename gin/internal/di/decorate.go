@@ -0,0 +1,65 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Decorate wraps every T (and optional tag) that c resolves with decorator,
+// e.g. to add logging, metrics, caching or retries without changing the
+// constructor:
+//
+//	di.Decorate[repo.UserRepository](c, func(r repo.UserRepository) repo.UserRepository {
+//		return &loggingUserRepo{next: r}
+//	})
+//
+// Decorators run in the order they were added, each wrapping the result of
+// the previous one, and only when an instance is created: a singleton is
+// decorated once and the decorated value is what every Resolve returns.
+// Decorators added to a child container wrap the parent's; those added to a
+// scope apply to the scoped and transient instances it creates. Lifecycle
+// hooks run on the undecorated instance.
+func Decorate[T any](c *Container, decorator func(T) T, tag ...string) error {
+	if c.isClosed() {
+		return errors.New("container is closed")
+	}
+	regTag := ""
+	if len(tag) > 0 {
+		regTag = tag[0]
+	}
+	key := typeKey{typ: reflect.TypeOf((*T)(nil)).Elem(), tag: regTag}
+	if regList, _ := c.lookup(key); regList == nil {
+		return fmt.Errorf("no registration found for type %s", key)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.decorators == nil {
+		c.decorators = make(map[typeKey][]func(any) any)
+	}
+	c.decorators[key] = append(c.decorators[key], func(v any) any { return decorator(v.(T)) })
+	return nil
+}
+
+// decorate applies the decorators of key from the root container, or the
+// container below ancestor when it is set, down to c.
+func (c *Container) decorate(key typeKey, v any, ancestor *Container) (any, error) {
+	var chain []*Container
+	for x := c; x != ancestor; x = x.parent {
+		chain = append(chain, x)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		x := chain[i]
+		x.mu.RLock()
+		decorators := x.decorators[key]
+		x.mu.RUnlock()
+		for _, decorator := range decorators {
+			v = decorator(v)
+			if v == nil {
+				return nil, fmt.Errorf("decorator of %s returned nil", key)
+			}
+		}
+	}
+	return v, nil
+}
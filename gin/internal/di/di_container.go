@@ -2,9 +2,11 @@
 package di
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 	"sync/atomic"
 )
@@ -14,17 +16,42 @@ type Scope int
 const (
 	Singleton Scope = iota
 	Transient
+	// Scoped instances are created once per scope, see NewScope
+	Scoped
 )
 
+func (s Scope) String() string {
+	switch s {
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	case Scoped:
+		return "scoped"
+	default:
+		return fmt.Sprintf("Scope(%d)", int(s))
+	}
+}
+
 type Container struct {
 	mu            sync.RWMutex
 	registrations map[typeKey]*registrationList
-	// Singletons and their per-type creation mutexes (string key = typeKey.String())
+	// parent is set on scopes and child containers; registrations not found
+	// here are looked up there
+	parent *Container
+	// ctx is the context of a scope and nil for a root container
+	ctx context.Context
+	// decorators wrap instances at resolve time, see Decorate
+	decorators map[typeKey][]func(any) any
+	// Singletons (scoped instances in a scope) and their per-type creation
+	// mutexes (string key = typeKey.String())
 	singletons      sync.Map
 	singletonsMutex sync.Map
 	// Circular dependency tracking by type+tag key
 	resolving sync.Map
 	closed    int32
+	// Singletons in creation order and their lifecycle state, see lifecycle.go
+	lifecycle lifecycle
 }
 
 type typeKey struct {
@@ -50,25 +77,90 @@ type registration struct {
 	tag         string
 	// optional direct factory that avoids reflect at resolve time
 	directFactory func() (any, error)
+	// optional typed provider and the dependencies it resolves, see
+	// RegisterProvider
+	provider func(*Container) (any, error)
+	deps     []Dependency
+	// optional lifecycle hooks declared with OnStart and OnStop
+	onStart, onStop hookFunc
 }
 
 func New() *Container {
 	return &Container{registrations: make(map[typeKey]*registrationList)}
 }
 
+// NewScope returns a child scope of c for one unit of work such as an HTTP
+// request. Scoped registrations are created once per scope, singletons are
+// shared with c and transients stay per resolution. Resolving
+// context.Context in the scope returns ctx unless it is registered.
+// Call Dispose when the work is done.
+func (c *Container) NewScope(ctx context.Context) *Container {
+	scope := New()
+	scope.parent = c
+	scope.ctx = ctx
+	if c.isClosed() {
+		scope.closed = 1
+	}
+	return scope
+}
+
+// Dispose ends a scope: it stops the scoped instances in reverse creation
+// order, like Stop, and closes the scope.
+func (c *Container) Dispose(ctx context.Context) error {
+	err := c.Stop(ctx)
+	c.Close()
+	return err
+}
+
 func (c *Container) isClosed() bool { return atomic.LoadInt32(&c.closed) != 0 }
 
+// lookup returns the registrations for key from c or its nearest ancestor,
+// together with the container that owns them.
+func (c *Container) lookup(key typeKey) (*registrationList, *Container) {
+	for owner := c; owner != nil; owner = owner.parent {
+		owner.mu.RLock()
+		regList := owner.registrations[key]
+		owner.mu.RUnlock()
+		if regList != nil {
+			return regList, owner
+		}
+	}
+	return nil, nil
+}
+
+// home returns the nearest container of c that is not a scope.
+func (c *Container) home() *Container {
+	h := c
+	for h.ctx != nil && h.parent != nil {
+		h = h.parent
+	}
+	return h
+}
+
+// scope returns the nearest scope of c, or nil outside a scope.
+func (c *Container) scope() *Container {
+	for s := c; s != nil; s = s.parent {
+		if s.ctx != nil {
+			return s
+		}
+	}
+	return nil
+}
+
 // Close marks the container as closed and clears internal maps.
 // It acquires c.mu so that Close and concurrent Register cannot interleave.
+// Close does not run stop hooks; call Stop first to release the singletons.
 func (c *Container) Close() {
 	atomic.StoreInt32(&c.closed, 1)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	// Clear maps while holding the lock to avoid races with Register/Resolve
 	c.registrations = make(map[typeKey]*registrationList)
+	c.decorators = nil
 	c.singletons.Range(func(key, _ any) bool { c.singletons.Delete(key); return true })
 	c.singletonsMutex.Range(func(key, _ any) bool { c.singletonsMutex.Delete(key); return true })
 	c.resolving.Range(func(key, _ any) bool { c.resolving.Delete(key); return true })
+	c.lifecycle.reset()
 }
 
 // Register registers a constructor for type T with the given scope and optional tag.
@@ -76,6 +168,8 @@ func (c *Container) Close() {
 //   - Allow constructors that return (T) or (T, error).
 //   - Basic validation on constructor input parameters: forbid primitives unless explicitly registered.
 //   - Double-check closed state while holding c.mu to avoid Close/Register races.
+//   - Parameters that are parameter objects (see In) are filled field by field,
+//     which is how a constructor asks for tagged, optional or grouped dependencies.
 func Register[T any](c *Container, constructor any, scope Scope, tag ...string) error {
 	if c.isClosed() {
 		return errors.New("container is closed")
@@ -105,13 +199,17 @@ func Register[T any](c *Container, constructor any, scope Scope, tag ...string)
 
 	// Validate inputs: disallow primitives (string, bool, numbers, etc.)
 	// unless there's already a registration for that exact type.
+	// Parameter objects (structs embedding In) must have valid field options.
 	for i := 0; i < ctorType.NumIn(); i++ {
 		inT := ctorType.In(i)
+		if isIn(inT) {
+			if _, err := inFields(inT); err != nil {
+				return fmt.Errorf("constructor parameter %d: %w", i, err)
+			}
+			continue
+		}
 		if isPrimitiveKind(inT.Kind()) {
-			c.mu.RLock()
-			_, ok := c.registrations[typeKey{typ: inT, tag: ""}]
-			c.mu.RUnlock()
-			if !ok {
+			if regList, _ := c.lookup(typeKey{typ: inT, tag: ""}); regList == nil {
 				return fmt.Errorf("constructor parameter %d is primitive %v and not registered", i, inT)
 			}
 		}
@@ -256,20 +354,13 @@ func MustResolveWithTag[T any](c *Container, tag string) T {
 func (c *Container) resolveType(t reflect.Type, tag string) (any, error) {
 	key := typeKey{typ: t, tag: tag}
 	keyStr := key.String()
-	// resolving key: keyStr
-
-	// Circular dependency detection
-	if _, isResolving := c.resolving.LoadOrStore(keyStr, true); isResolving {
-		return nil, fmt.Errorf("circular dependency detected for type %s", keyStr)
-	}
-	defer c.resolving.Delete(keyStr)
-
-	// Read registrations
-	c.mu.RLock()
-	regList := c.registrations[key]
-	c.mu.RUnlock()
 
+	// Read registrations from c or the containers it was created from
+	regList, owner := c.lookup(key)
 	if regList == nil {
+		if s := c.scope(); s != nil && t == contextType && tag == "" {
+			return s.ctx, nil
+		}
 		return nil, fmt.Errorf("no registration found for type %s", keyStr)
 	}
 
@@ -288,32 +379,120 @@ func (c *Container) resolveType(t reflect.Type, tag string) (any, error) {
 	reg := regList.items[0]
 	regList.mu.RUnlock()
 
-	if reg.scope == Singleton {
-		if instance, ok := c.singletons.Load(keyStr); ok {
-			return instance, nil
+	switch reg.scope {
+	case Singleton:
+		// Singletons live outside scopes, in the nearest container that is
+		// not a scope, and only depend on what that container can resolve.
+		// A child container shares its parent's singletons unless they
+		// depend on one of its overrides; those it builds, and stops, itself.
+		home := c.home()
+		if owner.ctx != nil {
+			home = owner
+		}
+		if owner != home && home.inherits(owner, reg) {
+			return home.sharedInstance(key, owner)
 		}
-		// per-type creation mutex
-		mutexI, _ := c.singletonsMutex.LoadOrStore(keyStr, &sync.Mutex{})
-		m := mutexI.(*sync.Mutex)
-		m.Lock()
-		defer m.Unlock()
-
-		// Double-check after locking
-		if instance, ok := c.singletons.Load(keyStr); ok {
-			return instance, nil
+		return home.cachedInstance(key, reg)
+	case Scoped:
+		s := c.scope()
+		if s == nil {
+			return nil, fmt.Errorf("%s is scoped: resolve it from a scope created with NewScope", keyStr)
 		}
+		return s.cachedInstance(key, reg)
+	default:
+		return c.newInstance(key, reg)
+	}
+}
 
-		result, err := c.createInstance(reg)
-		if err != nil {
-			return nil, err
+// cachedInstance returns the instance of reg cached in c, creating it on
+// first use. c runs its lifecycle hooks.
+func (c *Container) cachedInstance(key typeKey, reg *registration) (any, error) {
+	keyStr := key.String()
+	if instance, ok := c.singletons.Load(keyStr); ok {
+		return instance, nil
+	}
+
+	// Circular dependency detection
+	if _, isResolving := c.resolving.LoadOrStore(keyStr, true); isResolving {
+		return nil, fmt.Errorf("circular dependency detected for type %s", keyStr)
+	}
+	defer c.resolving.Delete(keyStr)
+
+	// per-type creation mutex
+	mutexI, _ := c.singletonsMutex.LoadOrStore(keyStr, &sync.Mutex{})
+	m := mutexI.(*sync.Mutex)
+	m.Lock()
+	defer m.Unlock()
+
+	// Double-check after locking
+	if instance, ok := c.singletons.Load(keyStr); ok {
+		return instance, nil
+	}
+
+	result, err := c.createInstance(reg)
+	if err != nil {
+		return nil, err
+	}
+	inst := c.track(key, reg, result)
+	if c.ctx != nil && inst.start != nil {
+		// A scope is already running, so scoped instances start on creation
+		if err := runHook(c.ctx, c.hookTimeout(), inst.start, result); err != nil {
+			return nil, fmt.Errorf("failed to start %s: %w", keyStr, err)
 		}
-		c.singletons.Store(keyStr, result)
-		// Avoid leaking per-type mutexes after successful creation
-		c.singletonsMutex.Delete(keyStr)
-		return result, nil
+		inst.started = true
+	}
+	// Lifecycle hooks use the instance itself, callers get it decorated
+	if result, err = c.decorate(key, result, nil); err != nil {
+		return nil, err
+	}
+	c.singletons.Store(keyStr, result)
+	// Avoid leaking per-type mutexes after successful creation
+	c.singletonsMutex.Delete(keyStr)
+	return result, nil
+}
+
+// sharedInstance returns the singleton key of owner, an ancestor of c, with
+// the decorators of c and the containers in between applied. owner runs its
+// lifecycle hooks.
+func (c *Container) sharedInstance(key typeKey, owner *Container) (any, error) {
+	keyStr := key.String()
+	if instance, ok := c.singletons.Load(keyStr); ok {
+		return instance, nil
 	}
 
-	return c.createInstance(reg)
+	mutexI, _ := c.singletonsMutex.LoadOrStore(keyStr, &sync.Mutex{})
+	m := mutexI.(*sync.Mutex)
+	m.Lock()
+	defer m.Unlock()
+	if instance, ok := c.singletons.Load(keyStr); ok {
+		return instance, nil
+	}
+
+	result, err := owner.resolveType(key.typ, key.tag)
+	if err != nil {
+		return nil, err
+	}
+	if result, err = c.decorate(key, result, owner); err != nil {
+		return nil, err
+	}
+	c.singletons.Store(keyStr, result)
+	c.singletonsMutex.Delete(keyStr)
+	return result, nil
+}
+
+// newInstance creates a decorated transient instance with circular
+// dependency detection.
+func (c *Container) newInstance(key typeKey, reg *registration) (any, error) {
+	keyStr := key.String()
+	if _, isResolving := c.resolving.LoadOrStore(keyStr, true); isResolving {
+		return nil, fmt.Errorf("circular dependency detected for type %s", keyStr)
+	}
+	defer c.resolving.Delete(keyStr)
+	result, err := c.createInstance(reg)
+	if err != nil {
+		return nil, err
+	}
+	return c.decorate(key, result, nil)
 }
 
 // createInstance calls the constructor (supporting T or (T,error)) and returns the created value.
@@ -322,6 +501,9 @@ func (c *Container) createInstance(reg *registration) (any, error) {
 	if reg.directFactory != nil {
 		return reg.directFactory()
 	}
+	if reg.provider != nil {
+		return reg.provider(c)
+	}
 
 	ctorType := reg.constructor.Type()
 	numIn := ctorType.NumIn()
@@ -329,6 +511,14 @@ func (c *Container) createInstance(reg *registration) (any, error) {
 
 	for i := 0; i < numIn; i++ {
 		paramType := ctorType.In(i)
+		if isIn(paramType) {
+			v, err := c.resolveIn(paramType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve parameter %d (%v): %w", i, paramType, err)
+			}
+			args[i] = v
+			continue
+		}
 		arg, err := c.resolveType(paramType, "")
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve parameter %d (%v): %w", i, paramType, err)
@@ -355,14 +545,14 @@ func (c *Container) createInstance(reg *registration) (any, error) {
 // It uses resolveType(elemType, reg.tag) to preserve singleton semantics.
 func (c *Container) resolveSlice(elemType reflect.Type) (any, error) {
 	// Gather matching registrations by (type, tag)
-	c.mu.RLock()
 	var entries []typeKey
-	for k := range c.registrations {
+	for _, k := range c.keys() {
 		if k.typ == elemType {
 			entries = append(entries, k)
 		}
 	}
-	c.mu.RUnlock()
+	// Map iteration order is random; keep slice order stable across resolutions
+	sort.Slice(entries, func(i, j int) bool { return entries[i].tag < entries[j].tag })
 
 	// Resolve each entry with its tag via resolveType to respect Singleton/Transient
 	resolved := make([]reflect.Value, 0, len(entries))
@@ -392,10 +582,7 @@ func IsRegistered[T any](c *Container, tag ...string) bool {
 	if len(tag) > 0 {
 		regTag = tag[0]
 	}
-	key := typeKey{typ: t, tag: regTag}
-	c.mu.RLock()
-	regList := c.registrations[key]
-	c.mu.RUnlock()
+	regList, _ := c.lookup(typeKey{typ: t, tag: regTag})
 	if regList == nil {
 		return false
 	}
@@ -409,24 +596,43 @@ func (c *Container) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.registrations = make(map[typeKey]*registrationList)
+	c.decorators = nil
 	c.singletons.Range(func(key, _ any) bool { c.singletons.Delete(key); return true })
 	c.singletonsMutex.Range(func(key, _ any) bool { c.singletonsMutex.Delete(key); return true })
 	c.resolving.Range(func(key, _ any) bool { c.resolving.Delete(key); return true })
+	c.lifecycle.reset()
 }
 
 // GetRegisteredTypes returns all registered types (with tags) for debugging.
 func (c *Container) GetRegisteredTypes() []string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
 	var types []string
-	for key := range c.registrations {
+	for _, key := range c.keys() {
 		types = append(types, key.String())
 	}
 	return types
 }
 
+// keys returns the registered keys of c and its ancestors, each once.
+func (c *Container) keys() []typeKey {
+	seen := make(map[typeKey]bool)
+	var keys []typeKey
+	for owner := c; owner != nil; owner = owner.parent {
+		owner.mu.RLock()
+		for key := range owner.registrations {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		owner.mu.RUnlock()
+	}
+	return keys
+}
+
 // --- helpers ---
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 func isErrorType(t reflect.Type) bool {
 	var errType = reflect.TypeOf((*error)(nil)).Elem()
 	return t.Implements(errType)
@@ -1,7 +1,11 @@
 package di
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 type A struct{ Val int }
@@ -163,3 +167,411 @@ func TestProvideNonInterface(t *testing.T) {
 		t.Error("expected error when using Provide with non-interface type")
 	}
 }
+
+// service records lifecycle calls for the lifecycle tests.
+type service struct {
+	name string
+	log  *[]string
+	fail bool
+}
+
+func (s *service) Start(ctx context.Context) error {
+	if s.fail {
+		return errors.New("boom")
+	}
+	*s.log = append(*s.log, "start "+s.name)
+	return nil
+}
+
+func (s *service) Stop(ctx context.Context) error {
+	*s.log = append(*s.log, "stop "+s.name)
+	return nil
+}
+
+type closer struct{ closed int }
+
+func (c *closer) Close() error { c.closed++; return nil }
+
+func TestLifecycleOrder(t *testing.T) {
+	type Cache struct{ *service }
+	type API struct{ *service }
+
+	var log []string
+	c := New()
+	Register[*service](c, func() *service { return &service{name: "db", log: &log} }, Singleton)
+	Register[*Cache](c, func(db *service) *Cache { return &Cache{&service{name: "cache", log: &log}} }, Singleton)
+	Register[*API](c, func(cache *Cache, db *service) *API { return &API{&service{name: "api", log: &log}} }, Singleton)
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := "start db,start cache,start api,stop api,stop cache,stop db"
+	if got := strings.Join(log, ","); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLifecycleHooksAndClosers(t *testing.T) {
+	type I interface{ Close() error }
+
+	c := New()
+	cl := &closer{}
+	Register[*closer](c, func() *closer { return cl }, Singleton)
+	Provide[I](c, cl)
+	Register[*A](c, newA, Singleton)
+	stopped := false
+	if err := OnStop[*A](c, func(ctx context.Context, a *A) error { stopped = true; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := OnStop[*B](c, func(ctx context.Context, b *B) error { return nil }); err == nil {
+		t.Error("expected error declaring a hook without registration")
+	}
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(context.Background()); err == nil {
+		t.Error("expected error starting twice")
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !stopped {
+		t.Error("expected OnStop hook to run")
+	}
+	if cl.closed != 1 {
+		t.Errorf("expected closer closed once, got %d", cl.closed)
+	}
+}
+
+func TestLifecycleStartFailureAndTimeout(t *testing.T) {
+	var log []string
+	c := New()
+	c.SetHookTimeout(10 * time.Millisecond)
+	Register[*service](c, func() *service { return &service{name: "ok", log: &log} }, Singleton, "a")
+	Register[*service](c, func() *service { return &service{name: "bad", fail: true, log: &log} }, Singleton, "b")
+	Register[*A](c, newA, Singleton)
+	OnStop[*A](c, func(ctx context.Context, a *A) error { <-ctx.Done(); return ctx.Err() })
+
+	err := c.Start(context.Background())
+	if err == nil {
+		t.Fatal("expected start error")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the stop timeout joined to the start error, got %v", err)
+	}
+	// *A resolves first, then "a" starts and "b" fails: "a" is stopped again
+	if got := strings.Join(log, ","); got != "start ok,stop ok" {
+		t.Errorf("unexpected lifecycle calls %q", got)
+	}
+}
+
+func TestScopedResolution(t *testing.T) {
+	type Request struct {
+		ctx context.Context
+		A   *A
+	}
+
+	c := New()
+	Register[*A](c, newA, Singleton)
+	Register[*closer](c, func() *closer { return &closer{} }, Scoped)
+	Register[*Request](c, func(ctx context.Context, a *A, _ *closer) *Request { return &Request{ctx: ctx, A: a} }, Scoped)
+
+	if _, err := Resolve[*Request](c); err == nil {
+		t.Error("expected error resolving a scoped type outside a scope")
+	}
+
+	ctx := context.WithValue(context.Background(), "k", "v")
+	s1, s2 := c.NewScope(ctx), c.NewScope(ctx)
+	r1, _ := Resolve[*Request](s1)
+	r1again, _ := Resolve[*Request](s1)
+	r2, err := Resolve[*Request](s2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1 != r1again {
+		t.Error("expected one instance per scope")
+	}
+	if r1 == r2 {
+		t.Error("expected different instances in different scopes")
+	}
+	if r1.A != r2.A || r1.A != MustResolve[*A](c) {
+		t.Error("expected singletons shared with the root container")
+	}
+	if r1.ctx != ctx {
+		t.Error("expected the scope context to be injected")
+	}
+
+	cl := MustResolve[*closer](s1)
+	if err := s1.Dispose(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if cl.closed != 1 {
+		t.Error("expected scoped closer closed on dispose")
+	}
+	if _, err := Resolve[*Request](s1); err == nil {
+		t.Error("expected error resolving from a disposed scope")
+	}
+}
+
+func TestSingletonCannotCaptureScoped(t *testing.T) {
+	c := New()
+	Register[*A](c, newA, Scoped)
+	Register[*B](c, newB, Singleton)
+	if _, err := Resolve[*B](c.NewScope(context.Background())); err == nil {
+		t.Error("expected error when a singleton depends on a scoped type")
+	}
+}
+
+func TestParameterObject(t *testing.T) {
+	type Params struct {
+		In
+		Primary *A `di:"tag=primary"`
+		Replica *A `di:"tag=replica,optional"`
+		All     []*A
+		B       *B  `di:"optional"`
+		Skip    int `di:"-"`
+	}
+	type Repo struct{ Params }
+
+	c := New()
+	primary, other := &A{Val: 1}, &A{Val: 2}
+	Register[*A](c, func() *A { return primary }, Singleton, "primary")
+	Register[*A](c, func() *A { return other }, Singleton, "other")
+	if err := Register[*Repo](c, func(p Params) *Repo { return &Repo{p} }, Singleton); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Resolve[*Repo](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Primary != primary {
+		t.Error("expected the tagged registration")
+	}
+	if r.Replica != nil || r.B != nil {
+		t.Error("expected missing optional fields to stay nil")
+	}
+	if len(r.All) != 2 {
+		t.Errorf("expected a group of 2, got %d", len(r.All))
+	}
+
+	type Bad struct {
+		In
+		A *A `di:"named=x"`
+	}
+	if err := Register[*Repo](c, func(Bad) *Repo { return nil }, Singleton, "bad"); err == nil {
+		t.Error("expected error for an unknown di option")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := New()
+	Register[*A](c, newA, Singleton)
+	Register[*B](c, newB, Singleton)
+	if err := c.Validate(); err != nil {
+		t.Fatalf("expected a valid graph, got %v", err)
+	}
+
+	type E struct{}
+	c = New()
+	Register[*B](c, newB, Singleton)                                 // *A missing
+	Register[*C](c, func(d *D) *C { return &C{D: d} }, Singleton)    // cycle
+	Register[*D](c, func(cc *C) *D { return &D{C: cc} }, Singleton)  // cycle
+	Register[*E](c, func() *E { return &E{} }, Scoped)               // captured below
+	Register[*Impl](c, func(*E) *Impl { return &Impl{} }, Singleton) // captive
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"(*di.A) has no registration", "circular dependency: *di.C -> *di.D -> *di.C", "singleton depends on scoped *di.E"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestGraph(t *testing.T) {
+	c := New()
+	Register[*B](c, newB, Singleton)
+	g := c.Graph()
+	if len(g.Nodes) != 2 || len(g.Edges) != 1 || !g.Nodes[0].Missing {
+		t.Fatalf("unexpected graph %+v", g)
+	}
+	if dot := g.DOT(); !strings.Contains(dot, `"*di.B" -> "*di.A";`) {
+		t.Errorf("unexpected DOT output:\n%s", dot)
+	}
+	if _, err := g.JSON(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithOverrides(t *testing.T) {
+	c := New()
+	Register[*A](c, func() *A { return &A{Val: 1} }, Singleton)
+	Register[*B](c, newB, Singleton)
+	parentB := MustResolve[*B](c)
+
+	fake := &A{Val: 42}
+	child, err := c.WithOverrides(With[*A](fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := MustResolve[*B](child)
+	if b.A != fake {
+		t.Error("expected the child's singletons to use the override")
+	}
+	if MustResolve[*A](c).Val != 1 || MustResolve[*B](c) != parentB {
+		t.Error("expected the parent to be unchanged")
+	}
+
+	if err := Override[*A](child, newA, Transient); err != nil {
+		t.Fatal(err)
+	}
+	if MustResolve[*A](child) == MustResolve[*A](child) {
+		t.Error("expected Override to replace the registration")
+	}
+	if _, err := Resolve[[]*A](child); err != nil {
+		t.Errorf("expected no duplicate registration after Override: %v", err)
+	}
+}
+
+func TestChildSingletonOwnership(t *testing.T) {
+	type Pool struct{ closer }
+	type Repo struct {
+		closer
+		a *A
+	}
+
+	c := New()
+	pools := 0
+	Register[*A](c, newA, Singleton)
+	Register[*Pool](c, func() *Pool { pools++; return &Pool{} }, Singleton)
+	Register[*Repo](c, func(a *A, p *Pool) *Repo { return &Repo{a: a} }, Singleton)
+	parentPool, parentRepo := MustResolve[*Pool](c), MustResolve[*Repo](c)
+
+	child, err := c.WithOverrides(With[*A](&A{Val: 42}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if MustResolve[*Pool](child) != parentPool || pools != 1 {
+		t.Error("expected the child to share a singleton without overridden dependencies")
+	}
+	childRepo := MustResolve[*Repo](child)
+	if childRepo == parentRepo || childRepo.a.Val != 42 {
+		t.Error("expected the child to build a singleton that depends on an override")
+	}
+
+	if err := child.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if childRepo.closed != 1 {
+		t.Errorf("expected the child to close the singleton it built, closed %d times", childRepo.closed)
+	}
+	if parentPool.closed != 0 || parentRepo.closed != 0 {
+		t.Error("expected the child to leave the parent's singletons open")
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if parentPool.closed != 1 || parentRepo.closed != 1 {
+		t.Errorf("expected the parent to close its singletons once, closed %d and %d times", parentPool.closed, parentRepo.closed)
+	}
+}
+
+func TestOverrideForgetsReplacedInstance(t *testing.T) {
+	c := New()
+	first, second := &closer{}, &closer{}
+	Register[*closer](c, func() *closer { return first }, Singleton)
+	MustResolve[*closer](c)
+
+	if err := Override[*closer](c, func() *closer { return second }, Singleton); err != nil {
+		t.Fatal(err)
+	}
+	if MustResolve[*closer](c) != second {
+		t.Error("expected Override to replace the singleton")
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if first.closed != 0 || second.closed != 1 {
+		t.Errorf("expected only the override closed, closed %d and %d times", first.closed, second.closed)
+	}
+}
+
+type Greeter interface{ Greet() string }
+
+type greeting string
+
+func (g greeting) Greet() string { return string(g) }
+
+type wrapped struct {
+	next  Greeter
+	label string
+}
+
+func (w wrapped) Greet() string { return w.label + "(" + w.next.Greet() + ")" }
+
+func TestDecorate(t *testing.T) {
+	c := New()
+	created := 0
+	Register[Greeter](c, func() Greeter { created++; return greeting("hi") }, Singleton)
+	Decorate[Greeter](c, func(g Greeter) Greeter { return wrapped{g, "log"} })
+	Decorate[Greeter](c, func(g Greeter) Greeter { return wrapped{g, "metrics"} })
+
+	g1 := MustResolve[Greeter](c)
+	if got := g1.Greet(); got != "metrics(log(hi))" {
+		t.Errorf("expected decorators applied in order, got %q", got)
+	}
+	if g2 := MustResolve[Greeter](c); g1 != g2 || created != 1 {
+		t.Error("expected the decorated singleton to be created and cached once")
+	}
+
+	child := c.NewChild()
+	Decorate[Greeter](child, func(g Greeter) Greeter { return wrapped{g, "cache"} })
+	if got := MustResolve[Greeter](child).Greet(); got != "cache(metrics(log(hi)))" {
+		t.Errorf("expected child decorators to wrap the parent's, got %q", got)
+	}
+	if got := MustResolve[Greeter](c).Greet(); got != "metrics(log(hi))" {
+		t.Errorf("expected parent unchanged, got %q", got)
+	}
+
+	if err := Decorate[*B](c, func(b *B) *B { return b }); err == nil {
+		t.Error("expected error decorating an unregistered type")
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	provideB := func(c *Container) (*B, error) {
+		a, err := Resolve[*A](c)
+		if err != nil {
+			return nil, err
+		}
+		return newB(a)
+	}
+
+	c := New()
+	RegisterProvider(c, provideB, Singleton, Dep[*A]())
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "(*di.A) has no registration") {
+		t.Errorf("expected Validate to see the declared dependency, got %v", err)
+	}
+	if _, err := Resolve[*B](c); err == nil {
+		t.Error("expected the provider's resolve error")
+	}
+
+	fake := &A{Val: 7}
+	child, err := c.WithOverrides(With[*A](fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := MustResolve[*B](child)
+	if b.A != fake || MustResolve[*B](child) != b {
+		t.Error("expected a cached singleton built from the child's override")
+	}
+	if err := child.Validate(); err != nil {
+		t.Errorf("expected a valid graph, got %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package di
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// dependency is one thing a registration needs: a constructor parameter or
+// a field of a parameter object.
+type dependency struct {
+	name     string
+	key      typeKey
+	optional bool
+	group    bool
+}
+
+// dependencies lists what reg needs, in constructor order.
+func dependencies(reg *registration) ([]dependency, error) {
+	if reg.directFactory != nil {
+		return nil, nil
+	}
+	var deps []dependency
+	if reg.provider != nil {
+		for i, dep := range reg.deps {
+			deps = append(deps, dependency{name: fmt.Sprintf("dependency %d", i), key: dep.key})
+		}
+		return deps, nil
+	}
+	ctorType := reg.constructor.Type()
+	for i := 0; i < ctorType.NumIn(); i++ {
+		paramType := ctorType.In(i)
+		if !isIn(paramType) {
+			deps = append(deps, dependency{
+				name: fmt.Sprintf("parameter %d", i),
+				key:  typeKey{typ: paramType},
+			})
+			continue
+		}
+		fields, err := inFields(paramType)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			dep := dependency{name: "field " + f.name, key: typeKey{typ: f.typ, tag: f.tag}, optional: f.optional, group: f.group}
+			if f.group {
+				dep.key = typeKey{typ: f.typ.Elem()}
+			}
+			deps = append(deps, dep)
+		}
+	}
+	return deps, nil
+}
+
+// registrationsOf returns the registrations of every key visible from c.
+func (c *Container) registrationsOf() map[typeKey][]*registration {
+	regs := make(map[typeKey][]*registration)
+	for _, key := range c.keys() {
+		regList, _ := c.lookup(key)
+		regList.mu.RLock()
+		regs[key] = append([]*registration(nil), regList.items...)
+		regList.mu.RUnlock()
+	}
+	return regs
+}
+
+// groupKeys returns the keys a group of typ resolves to.
+func groupKeys(regs map[typeKey][]*registration, typ reflect.Type) []typeKey {
+	var keys []typeKey
+	for key := range regs {
+		if key.typ == typ {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].tag < keys[j].tag })
+	return keys
+}
+
+// Validate checks every registration without creating anything and reports
+// all problems at once: missing and ambiguous dependencies, unregistered
+// primitive parameters, singletons that depend on scoped types and
+// dependency cycles. It returns nil when the graph can be resolved.
+func (c *Container) Validate() error {
+	regs := c.registrationsOf()
+	keys := sortedKeys(regs)
+
+	var errs []error
+	edges := make(map[typeKey][]typeKey)
+	for _, key := range keys {
+		if n := len(regs[key]); n > 1 {
+			errs = append(errs, fmt.Errorf("%s: registered %d times; use a tag for each registration", key, n))
+			continue
+		}
+		reg := regs[key][0]
+		deps, err := dependencies(reg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		for _, dep := range deps {
+			if dep.group {
+				edges[key] = append(edges[key], groupKeys(regs, dep.key.typ)...)
+				continue
+			}
+			depRegs, ok := regs[dep.key]
+			switch {
+			case !ok && dep.key.typ == contextType && dep.key.tag == "" && reg.scope != Singleton:
+				// Supplied by the scope the instance is resolved in
+			case !ok && dep.optional:
+			case !ok && isPrimitiveKind(dep.key.typ.Kind()):
+				errs = append(errs, fmt.Errorf("%s: %s is primitive %s and not registered", key, dep.name, dep.key))
+			case !ok:
+				errs = append(errs, fmt.Errorf("%s: %s (%s) has no registration", key, dep.name, dep.key))
+			case len(depRegs) > 1:
+				errs = append(errs, fmt.Errorf("%s: %s (%s) is ambiguous: %d registrations", key, dep.name, dep.key, len(depRegs)))
+			case reg.scope == Singleton && depRegs[0].scope == Scoped:
+				errs = append(errs, fmt.Errorf("%s: singleton depends on scoped %s through %s", key, dep.key, dep.name))
+			default:
+				edges[key] = append(edges[key], dep.key)
+			}
+		}
+	}
+
+	for _, cycle := range findCycles(keys, edges) {
+		errs = append(errs, fmt.Errorf("circular dependency: %s", cycle))
+	}
+	return errors.Join(errs...)
+}
+
+// findCycles returns each dependency cycle once, as "A -> B -> A".
+func findCycles(keys []typeKey, edges map[typeKey][]typeKey) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[typeKey]int)
+	var stack []typeKey
+	var cycles []string
+
+	var visit func(key typeKey)
+	visit = func(key typeKey) {
+		state[key] = visiting
+		stack = append(stack, key)
+		for _, dep := range edges[key] {
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				var path []string
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == dep {
+						for _, k := range stack[i:] {
+							path = append(path, k.String())
+						}
+						break
+					}
+				}
+				cycles = append(cycles, strings.Join(append(path, dep.String()), " -> "))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[key] = done
+	}
+	for _, key := range keys {
+		if state[key] == unvisited {
+			visit(key)
+		}
+	}
+	return cycles
+}
+
+func sortedKeys(regs map[typeKey][]*registration) []typeKey {
+	keys := make([]typeKey, 0, len(regs))
+	for key := range regs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
+
+// Graph is the dependency graph of a container.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a registered type, or a dependency that has no registration.
+type GraphNode struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Tag     string `json:"tag,omitempty"`
+	Scope   string `json:"scope,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// GraphEdge points from a registration to one of its dependencies.
+type GraphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Via      string `json:"via"`
+	Optional bool   `json:"optional,omitempty"`
+	Group    bool   `json:"group,omitempty"`
+}
+
+// Graph returns the dependency graph of every registration visible from c.
+// Nodes and edges are sorted, so the output is stable.
+func (c *Container) Graph() *Graph {
+	regs := c.registrationsOf()
+	g := &Graph{}
+	missing := make(map[typeKey]bool)
+
+	for _, key := range sortedKeys(regs) {
+		node := GraphNode{ID: key.String(), Type: key.typ.String(), Tag: key.tag}
+		if len(regs[key]) > 0 {
+			node.Scope = regs[key][0].scope.String()
+		}
+		g.Nodes = append(g.Nodes, node)
+
+		for _, reg := range regs[key] {
+			deps, _ := dependencies(reg)
+			for _, dep := range deps {
+				edge := GraphEdge{From: key.String(), Via: dep.name, Optional: dep.optional, Group: dep.group}
+				if dep.group {
+					for _, k := range groupKeys(regs, dep.key.typ) {
+						edge.To = k.String()
+						g.Edges = append(g.Edges, edge)
+					}
+					continue
+				}
+				if _, ok := regs[dep.key]; !ok {
+					missing[dep.key] = true
+				}
+				edge.To = dep.key.String()
+				g.Edges = append(g.Edges, edge)
+			}
+		}
+	}
+
+	for key := range missing {
+		g.Nodes = append(g.Nodes, GraphNode{ID: key.String(), Type: key.typ.String(), Tag: key.tag, Missing: true})
+	}
+	sort.SliceStable(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// JSON encodes the graph as indented JSON.
+func (g *Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// DOT renders the graph in the Graphviz DOT language, e.g. for
+// `dot -Tsvg`. Missing dependencies are red, optional edges dashed and
+// group edges labelled [].
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph di {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		if n.Missing {
+			fmt.Fprintf(&b, "\t%q [label=%q, color=red, fontcolor=red];\n", n.ID, n.ID+"\nmissing")
+			continue
+		}
+		fmt.Fprintf(&b, "\t%q [label=%q];\n", n.ID, n.ID+"\n"+n.Scope)
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Optional {
+			attrs = append(attrs, "style=dashed")
+		}
+		if e.Group {
+			attrs = append(attrs, `label="[]"`)
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, "\t%q -> %q [%s];\n", e.From, e.To, strings.Join(attrs, ", "))
+			continue
+		}
+		fmt.Fprintf(&b, "\t%q -> %q;\n", e.From, e.To)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
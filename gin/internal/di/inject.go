@@ -0,0 +1,123 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// In marks a parameter object: a struct, embedding In, that a constructor
+// takes instead of separate parameters. The container fills its exported
+// fields, reading options from the `di` struct tag:
+//
+//	type RepoParams struct {
+//		di.In
+//		Primary *gorm.DB `di:"tag=primary"`
+//		Replica *gorm.DB `di:"tag=replica,optional"`
+//		Hooks   []Hook   // every registered Hook, whatever its tag
+//		Cache   Cache    `di:"optional"`
+//	}
+//
+//	di.Register[*Repo](c, func(p RepoParams) *Repo { ... }, di.Singleton)
+//
+// tag=name resolves the registration with that tag. optional leaves the
+// field at its zero value when nothing is registered for it. A slice field
+// without a tag is a group: it receives all registrations of its element
+// type, as Resolve[[]T] does. Fields tagged `di:"-"` are left alone.
+type In struct{}
+
+var inType = reflect.TypeOf(In{})
+
+// inField describes one field of a parameter object.
+type inField struct {
+	index    int
+	name     string
+	typ      reflect.Type
+	tag      string
+	optional bool
+	group    bool
+}
+
+// isIn reports whether t is a parameter object.
+func isIn(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Anonymous && f.Type == inType {
+			return true
+		}
+	}
+	return false
+}
+
+// inFields parses the fields of the parameter object t.
+func inFields(t reflect.Type) ([]inField, error) {
+	var fields []inField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type == inType {
+			continue
+		}
+		opts := f.Tag.Get("di")
+		if opts == "-" {
+			continue
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("field %s of %v must be exported or tagged `di:\"-\"`", f.Name, t)
+		}
+
+		field := inField{index: i, name: f.Name, typ: f.Type}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt = strings.TrimSpace(opt); {
+			case opt == "":
+			case opt == "optional":
+				field.optional = true
+			case strings.HasPrefix(opt, "tag="):
+				field.tag = strings.TrimPrefix(opt, "tag=")
+			default:
+				return nil, fmt.Errorf("field %s of %v has unknown di option %q", f.Name, t, opt)
+			}
+		}
+		field.group = f.Type.Kind() == reflect.Slice && field.tag == ""
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// resolveIn builds the parameter object t with its fields resolved from c.
+func (c *Container) resolveIn(t reflect.Type) (reflect.Value, error) {
+	fields, err := inFields(t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	v := reflect.New(t).Elem()
+	for _, f := range fields {
+		var res any
+		switch {
+		case f.group:
+			res, err = c.resolveSlice(f.typ.Elem())
+		case f.optional && !c.canResolve(f.typ, f.tag):
+			continue
+		default:
+			res, err = c.resolveType(f.typ, f.tag)
+		}
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("failed to resolve field %s (%v): %w", f.name, f.typ, err)
+		}
+		if res != nil {
+			v.Field(f.index).Set(reflect.ValueOf(res))
+		}
+	}
+	return v, nil
+}
+
+// canResolve reports whether c has a registration for t and tag, or can
+// supply it otherwise.
+func (c *Container) canResolve(t reflect.Type, tag string) bool {
+	if regList, _ := c.lookup(typeKey{typ: t, tag: tag}); regList != nil {
+		return true
+	}
+	return t == contextType && tag == "" && c.scope() != nil
+}
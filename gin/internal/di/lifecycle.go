@@ -0,0 +1,272 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHookTimeout bounds every start and stop hook unless SetHookTimeout
+// changes it.
+const DefaultHookTimeout = 15 * time.Second
+
+// Lifecycle is implemented by singletons that run in the background, such as
+// workers and job runners. Start must not block: it launches the work and
+// returns. Stop ends it and waits until it has finished or ctx is done.
+type Lifecycle interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+type hookFunc func(ctx context.Context, v any) error
+
+type lifecycle struct {
+	mu        sync.Mutex
+	instances []*instance
+	started   bool
+	stopped   bool
+	timeout   time.Duration
+}
+
+// instance is a singleton created by the container. Instances are recorded
+// in creation order, which puts every dependency before its dependents.
+type instance struct {
+	key     typeKey
+	value   any
+	start   hookFunc
+	stop    hookFunc
+	started bool
+}
+
+func (l *lifecycle) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.instances = nil
+	l.started = false
+	l.stopped = false
+}
+
+// OnStart declares a hook that runs with the singleton T (and optional tag)
+// when the container starts, or with a scoped T when a scope creates it.
+// It replaces the Start method of a Lifecycle.
+func OnStart[T any](c *Container, hook func(ctx context.Context, v T) error, tag ...string) error {
+	return setHook[T](c, tag, func(reg *registration) {
+		reg.onStart = func(ctx context.Context, v any) error { return hook(ctx, v.(T)) }
+	})
+}
+
+// OnStop declares a hook that runs with the singleton T (and optional tag)
+// when the container stops, or with a scoped T when its scope is disposed.
+// It replaces the Stop method of a Lifecycle and
+// the Close method of an io.Closer.
+func OnStop[T any](c *Container, hook func(ctx context.Context, v T) error, tag ...string) error {
+	return setHook[T](c, tag, func(reg *registration) {
+		reg.onStop = func(ctx context.Context, v any) error { return hook(ctx, v.(T)) }
+	})
+}
+
+func setHook[T any](c *Container, tag []string, set func(reg *registration)) error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	regTag := ""
+	if len(tag) > 0 {
+		regTag = tag[0]
+	}
+	key := typeKey{typ: t, tag: regTag}
+
+	regList, _ := c.lookup(key)
+	if regList == nil {
+		return fmt.Errorf("no registration found for type %s", key)
+	}
+
+	regList.mu.Lock()
+	defer regList.mu.Unlock()
+	if len(regList.items) == 0 {
+		return fmt.Errorf("no registration found for type %s", key)
+	}
+	reg := regList.items[len(regList.items)-1]
+	if reg.scope == Transient {
+		return fmt.Errorf("lifecycle hooks need a singleton or scoped registration for %s", key)
+	}
+	set(reg)
+	return nil
+}
+
+// forget drops the instances of key, which Override replaced, so Start and
+// Stop skip them.
+func (l *lifecycle) forget(key typeKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kept := l.instances[:0]
+	for _, inst := range l.instances {
+		if inst.key != key {
+			kept = append(kept, inst)
+		}
+	}
+	l.instances = kept
+}
+
+// SetHookTimeout sets how long a single start or stop hook may run.
+// A zero or negative d restores DefaultHookTimeout.
+func (c *Container) SetHookTimeout(d time.Duration) {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	c.lifecycle.timeout = d
+}
+
+// track records a newly created singleton together with its hooks.
+func (c *Container) track(key typeKey, reg *registration, v any) *instance {
+	inst := &instance{key: key, value: v, start: reg.onStart, stop: reg.onStop}
+	if lc, ok := v.(Lifecycle); ok {
+		if inst.start == nil {
+			inst.start = func(ctx context.Context, _ any) error { return lc.Start(ctx) }
+		}
+		if inst.stop == nil {
+			inst.stop = func(ctx context.Context, _ any) error { return lc.Stop(ctx) }
+		}
+	}
+	if closer, ok := v.(io.Closer); ok && inst.stop == nil {
+		inst.stop = func(context.Context, any) error { return closer.Close() }
+	}
+
+	c.lifecycle.mu.Lock()
+	c.lifecycle.instances = append(c.lifecycle.instances, inst)
+	c.lifecycle.mu.Unlock()
+	return inst
+}
+
+// Start creates every singleton that has not been resolved yet and runs the
+// start hooks in dependency order. If a hook fails, the singletons started so
+// far are stopped again and the error is returned. A container starts once.
+func (c *Container) Start(ctx context.Context) error {
+	if c.isClosed() {
+		return errors.New("container is closed")
+	}
+
+	c.lifecycle.mu.Lock()
+	if c.lifecycle.started || c.lifecycle.stopped {
+		c.lifecycle.mu.Unlock()
+		return errors.New("container already started")
+	}
+	c.lifecycle.started = true
+	c.lifecycle.mu.Unlock()
+
+	if err := c.resolveSingletons(); err != nil {
+		return c.rollback(ctx, err)
+	}
+
+	c.lifecycle.mu.Lock()
+	instances := append([]*instance(nil), c.lifecycle.instances...)
+	c.lifecycle.mu.Unlock()
+	timeout := c.hookTimeout()
+
+	for _, inst := range instances {
+		if inst.start == nil {
+			continue
+		}
+		if err := runHook(ctx, timeout, inst.start, inst.value); err != nil {
+			return c.rollback(ctx, fmt.Errorf("failed to start %s: %w", inst.key, err))
+		}
+		inst.started = true
+	}
+	return nil
+}
+
+// rollback stops what a failed Start created, with a context that outlives
+// a cancelled ctx, and returns err joined with the stop errors.
+func (c *Container) rollback(ctx context.Context, err error) error {
+	if stopErr := c.Stop(context.WithoutCancel(ctx)); stopErr != nil {
+		return errors.Join(err, stopErr)
+	}
+	return err
+}
+
+// Stop runs the stop hooks in the reverse order of Start, each within the
+// hook timeout, and returns the joined errors of every hook that failed.
+// A singleton with a start hook is only stopped if it was started, and a
+// pointer registered under several types is stopped once.
+func (c *Container) Stop(ctx context.Context) error {
+	c.lifecycle.mu.Lock()
+	instances := c.lifecycle.instances
+	c.lifecycle.instances = nil
+	c.lifecycle.stopped = true
+	c.lifecycle.mu.Unlock()
+	timeout := c.hookTimeout()
+
+	var errs []error
+	seen := make(map[any]bool)
+	for i := len(instances) - 1; i >= 0; i-- {
+		inst := instances[i]
+		if inst.stop == nil || (inst.start != nil && !inst.started) {
+			continue
+		}
+		if v := reflect.ValueOf(inst.value); v.Kind() == reflect.Pointer {
+			if seen[inst.value] {
+				continue
+			}
+			seen[inst.value] = true
+		}
+		if err := runHook(ctx, timeout, inst.stop, inst.value); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", inst.key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveSingletons resolves every unambiguous singleton registration so its
+// hooks take part in Start. Keys are sorted to keep the start order stable.
+func (c *Container) resolveSingletons() error {
+	var keys []typeKey
+	for _, key := range c.keys() {
+		regList, _ := c.lookup(key)
+		regList.mu.RLock()
+		if len(regList.items) == 1 && regList.items[0].scope == Singleton {
+			keys = append(keys, key)
+		}
+		regList.mu.RUnlock()
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	for _, key := range keys {
+		if _, err := c.resolveType(key.typ, key.tag); err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (c *Container) hookTimeout() time.Duration {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	if c.lifecycle.timeout <= 0 {
+		return DefaultHookTimeout
+	}
+	return c.lifecycle.timeout
+}
+
+// runHook runs hook within timeout. A hook that ignores its context is
+// abandoned once the timeout expires, and a panic is returned as an error.
+func runHook(ctx context.Context, timeout time.Duration, hook hookFunc, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- hook(ctx, v)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,121 @@
+package di
+
+import "reflect"
+
+// NewChild returns a container that resolves everything c can resolve but
+// takes its own registrations first, so Override on the child replaces
+// dependencies without mutating c. The child shares the singletons of c
+// unless they depend, directly or not, on an override; those it builds
+// itself, so they see the overrides, and stops with its own.
+func (c *Container) NewChild() *Container {
+	child := New()
+	child.parent = c
+	if c.isClosed() {
+		child.closed = 1
+	}
+	return child
+}
+
+// WithOverrides returns a child container (see NewChild) with every
+// override applied, e.g. With to swap in a fake:
+//
+//	child, err := c.WithOverrides(
+//		di.With[repo.UserRepository](fakeRepo),
+//		func(c *di.Container) error {
+//			return di.Override[Clock](c, newFrozenClock, di.Singleton)
+//		},
+//	)
+func (c *Container) WithOverrides(overrides ...func(*Container) error) (*Container, error) {
+	child := c.NewChild()
+	for _, override := range overrides {
+		if err := override(child); err != nil {
+			return nil, err
+		}
+	}
+	return child, nil
+}
+
+// Override replaces the registrations of T (and optional tag) in c with
+// constructor, like Register does for a new type. On a child container the
+// parent keeps its registrations. Override before resolving from c.
+func Override[T any](c *Container, constructor any, scope Scope, tag ...string) error {
+	regTag := ""
+	if len(tag) > 0 {
+		regTag = tag[0]
+	}
+	key := typeKey{typ: reflect.TypeOf((*T)(nil)).Elem(), tag: regTag}
+
+	c.mu.Lock()
+	delete(c.registrations, key)
+	c.mu.Unlock()
+	c.singletons.Delete(key.String())
+	c.lifecycle.forget(key)
+
+	return Register[T](c, constructor, scope, tag...)
+}
+
+// inherits reports whether c, a descendant of ancestor, resolves the
+// singleton reg of ancestor to the same instance: nothing reg depends on,
+// directly or not, is registered or decorated in c or the containers
+// between c and ancestor.
+func (c *Container) inherits(ancestor *Container, reg *registration) bool {
+	seen := make(map[typeKey]bool)
+	var visit func(reg *registration) bool
+	visit = func(reg *registration) bool {
+		deps, err := dependencies(reg)
+		if err != nil {
+			return false
+		}
+		for _, dep := range deps {
+			keys := []typeKey{dep.key}
+			if dep.group {
+				keys = groupKeys(c.registrationsOf(), dep.key.typ)
+			}
+			for _, key := range keys {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				if c.overrides(ancestor, key) {
+					return false
+				}
+				regList, _ := ancestor.lookup(key)
+				if regList == nil {
+					continue
+				}
+				regList.mu.RLock()
+				regs := append([]*registration(nil), regList.items...)
+				regList.mu.RUnlock()
+				for _, r := range regs {
+					if !visit(r) {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	return visit(reg)
+}
+
+// overrides reports whether key is registered or decorated in c or the
+// containers between c and ancestor.
+func (c *Container) overrides(ancestor *Container, key typeKey) bool {
+	for x := c; x != ancestor; x = x.parent {
+		x.mu.RLock()
+		_, registered := x.registrations[key]
+		_, decorated := x.decorators[key]
+		x.mu.RUnlock()
+		if registered || decorated {
+			return true
+		}
+	}
+	return false
+}
+
+// With is an override for WithOverrides that makes impl the singleton T.
+func With[T any](impl T, tag ...string) func(*Container) error {
+	return func(c *Container) error {
+		return Override[T](c, func() T { return impl }, Singleton, tag...)
+	}
+}
@@ -0,0 +1,72 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Dependency is a type (and optional tag) that a provider resolves, see
+// RegisterProvider.
+type Dependency struct {
+	key typeKey
+}
+
+// Dep declares that a provider resolves T with the optional tag.
+func Dep[T any](tag ...string) Dependency {
+	regTag := ""
+	if len(tag) > 0 {
+		regTag = tag[0]
+	}
+	return Dependency{key: typeKey{typ: reflect.TypeOf((*T)(nil)).Elem(), tag: regTag}}
+}
+
+// RegisterProvider registers provider for T with the given scope. Unlike
+// Register, the container calls provider directly instead of through
+// reflection: provider resolves its own dependencies from the container it
+// is given, which is the one the instance is created in, and declares them
+// in deps so Validate and Graph still see them:
+//
+//	di.RegisterProvider(c, func(c *di.Container) (*user.UserService, error) {
+//		userRepo, err := di.Resolve[repo.UserRepository](c)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return user.NewUserService(userRepo), nil
+//	}, di.Singleton, di.Dep[repo.UserRepository]())
+//
+// This is the code that cmd/diwire generates from providers.go; singletons,
+// scopes, lifecycle hooks, decorators and overrides work as with Register.
+func RegisterProvider[T any](c *Container, provider func(c *Container) (T, error), scope Scope, deps ...Dependency) error {
+	if c.isClosed() {
+		return errors.New("container is closed")
+	}
+
+	key := typeKey{typ: reflect.TypeOf((*T)(nil)).Elem()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isClosed() {
+		return errors.New("container is closed")
+	}
+
+	regList := c.registrations[key]
+	if regList == nil {
+		regList = &registrationList{}
+		c.registrations[key] = regList
+	}
+
+	reg := &registration{scope: scope, deps: deps, provider: func(c *Container) (any, error) {
+		v, err := provider(c)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}}
+
+	regList.mu.Lock()
+	regList.items = append(regList.items, reg)
+	regList.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package di
+
+//go:generate go run ../../cmd/diwire
+
+import (
+	"github.com/SOG-web/goinit/gin/internal/app/user"
+	dataRepo "github.com/SOG-web/goinit/gin/internal/data/user/repo"
+	// goinit:if email
+	"github.com/SOG-web/goinit/gin/internal/lib/email"
+	// goinit:end
+	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
+	// goinit:if pwreset
+	"github.com/SOG-web/goinit/gin/internal/lib/pwreset"
+	// goinit:end
+	// goinit:if storage
+	"github.com/SOG-web/goinit/gin/internal/lib/storage"
+	// goinit:end
+	// goinit:if redis
+	"github.com/redis/go-redis/v9"
+	// goinit:end
+	"gorm.io/gorm"
+)
+
+// providers lists the constructors of the repositories and application
+// services. cmd/diwire turns them into registerProviders (wire_gen.go),
+// which registers each as a singleton of its result type and resolves its
+// parameters without reflection. Every parameter must be the result of
+// another provider or listed in external; run `go generate ./internal/di`
+// after changing the list.
+var providers = []any{
+	dataRepo.NewGormUserRepository,
+	user.NewUserService,
+	// goinit:scaffold:providers
+}
+
+// external lists the types that BuildContainer registers itself, because
+// they are built from the configuration, so providers can depend on them.
+var external = []any{
+	new(*gorm.DB),
+	// goinit:if redis
+	new(*redis.Client),
+	// goinit:end
+	// goinit:if email
+	new(email.EmailServiceInterface),
+	// goinit:end
+	new(*jwtLib.Keyring),
+	new(jwtLib.JWTServiceInterface),
+	// goinit:if pwreset
+	new(pwreset.PasswordResetServiceInterface),
+	// goinit:end
+	// goinit:if storage
+	new(storage.Storage),
+	// goinit:end
+}
@@ -0,0 +1,46 @@
+package di
+
+import "context"
+
+// TB is the part of testing.TB that NewTestContainer uses, so this package
+// does not import testing.
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...any)
+	Cleanup(func())
+}
+
+// NewTestContainer returns a container with the application services
+// (RegisterServices) and the given overrides, typically in-memory fakes for
+// the repositories and infrastructure:
+//
+//	c := di.NewTestContainer(t,
+//		di.With[repo.UserRepository](newFakeUserRepo()),
+//		di.With[email.EmailServiceInterface](&fakeEmail{}),
+//	)
+//	h := handler.NewUserHandler(c)
+//
+// It fails t when the container does not validate, so a missing fake is
+// reported up front, and stops the container when the test ends.
+func NewTestContainer(t TB, overrides ...func(*Container) error) *Container {
+	t.Helper()
+	base := New()
+	if err := RegisterServices(base); err != nil {
+		t.Fatalf("failed to register services: %v", err)
+	}
+	c, err := base.WithOverrides(overrides...)
+	if err != nil {
+		t.Fatalf("failed to apply overrides: %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("invalid test container:\n%v", err)
+	}
+	t.Cleanup(func() {
+		// The child stops what it built, base the singletons it shared
+		c.Stop(context.Background())
+		c.Close()
+		base.Stop(context.Background())
+		base.Close()
+	})
+	return c
+}
@@ -0,0 +1,72 @@
+// Code generated by diwire from providers.go. DO NOT EDIT.
+
+package di
+
+import (
+	"github.com/SOG-web/goinit/gin/internal/app/user"
+	dataRepo "github.com/SOG-web/goinit/gin/internal/data/user/repo"
+	"github.com/SOG-web/goinit/gin/internal/domain/user/repo"
+	// goinit:if email
+	"github.com/SOG-web/goinit/gin/internal/lib/email"
+	// goinit:end
+	jwtLib "github.com/SOG-web/goinit/gin/internal/lib/jwt"
+	"gorm.io/gorm"
+)
+
+// registerProviders registers the constructors listed in providers.go as
+// singletons that resolve their parameters without reflection.
+func registerProviders(c *Container) error {
+	// repo.UserRepository from dataRepo.NewGormUserRepository
+	if err := RegisterProvider(c, func(c *Container) (repo.UserRepository, error) {
+		db, err := Resolve[*gorm.DB](c)
+		if err != nil {
+			var zero repo.UserRepository
+			return zero, err
+		}
+		return dataRepo.NewGormUserRepository(
+			db,
+		), nil
+	}, Singleton,
+		Dep[*gorm.DB](),
+	); err != nil {
+		return err
+	}
+
+	// *user.UserService from user.NewUserService
+	if err := RegisterProvider(c, func(c *Container) (*user.UserService, error) {
+		userRepo, err := Resolve[repo.UserRepository](c)
+		if err != nil {
+			var zero *user.UserService
+			return zero, err
+		}
+		tokens, err := Resolve[jwtLib.JWTServiceInterface](c)
+		if err != nil {
+			var zero *user.UserService
+			return zero, err
+		}
+		// goinit:if email
+		emailService, err := Resolve[email.EmailServiceInterface](c)
+		if err != nil {
+			var zero *user.UserService
+			return zero, err
+		}
+		// goinit:end
+		return user.NewUserService(
+			userRepo,
+			tokens,
+			// goinit:if email
+			emailService,
+		// goinit:end
+		), nil
+	}, Singleton,
+		Dep[repo.UserRepository](),
+		Dep[jwtLib.JWTServiceInterface](),
+		// goinit:if email
+		Dep[email.EmailServiceInterface](),
+	// goinit:end
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -16,6 +16,8 @@ type User struct {
 	OTP           string    `json:"-"` // Never expose OTP in JSON
 	IsActive      bool      `json:"is_active"`
 	IsVerified    bool      `json:"is_verified"`
+	IsStaff       bool      `json:"is_staff"`
+	IsSuperuser   bool      `json:"is_superuser"`
 	DateJoined    time.Time `json:"date_joined"`
 	LastLogin     *time.Time `json:"last_login"` // Can be null
 	ProfileImageURL string   `json:"profile_image_url,omitempty"`
@@ -2,10 +2,12 @@ package email
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"sync"
+	"time"
 
 	"gopkg.in/gomail.v2"
 )
@@ -16,10 +18,14 @@ type EmailService struct {
 	username string
 	password string
 	from     string
+	appName  string
 
 	// Email queue for async processing
 	emailQueue chan EmailRequest
 	wg         *sync.WaitGroup
+	workerDone chan struct{} // closed when the worker has taken every queued email
+	mu         sync.RWMutex  // guards closed against sends racing Close
+	closed     bool
 }
 
 type EmailConfig struct {
@@ -28,6 +34,7 @@ type EmailConfig struct {
 	Username string
 	Password string
 	From     string
+	AppName  string // used in subjects and email bodies
 }
 
 type EmailRequest struct {
@@ -38,8 +45,10 @@ type EmailRequest struct {
 }
 
 type OTPEmailData struct {
-	Name string
-	OTP  string
+	AppName string
+	Name    string
+	OTP     string
+	Year    int
 }
 
 // EmailServiceInterface defines the interface for email operations
@@ -50,6 +59,7 @@ type EmailServiceInterface interface {
 	SendBulkEmail(emails []string, subject, htmlContent string) error
 	TestEmailConnection() error
 	GetQueueLength() int
+	Close() error
 }
 
 func NewEmailService(config EmailConfig) *EmailService {
@@ -59,8 +69,10 @@ func NewEmailService(config EmailConfig) *EmailService {
 		username:   config.Username,
 		password:   config.Password,
 		from:       config.From,
+		appName:    config.AppName,
 		emailQueue: make(chan EmailRequest, 100), // Buffer of 100 emails
 		wg:         &sync.WaitGroup{},
+		workerDone: make(chan struct{}),
 	}
 
 	// Start the email worker goroutine (equivalent to Django's EmailThread)
@@ -71,6 +83,7 @@ func NewEmailService(config EmailConfig) *EmailService {
 
 // emailWorker processes emails asynchronously (Django's EmailThread equivalent)
 func (e *EmailService) emailWorker() {
+	defer close(e.workerDone)
 	for emailReq := range e.emailQueue {
 		e.wg.Add(1)
 		go func(req EmailRequest) {
@@ -103,8 +116,10 @@ func (e *EmailService) emailWorker() {
 func (e *EmailService) SendOTPEmail(email, firstName, otp string) error {
 	// Create email data
 	data := OTPEmailData{
-		Name: firstName,
-		OTP:  otp,
+		AppName: e.appName,
+		Name:    firstName,
+		OTP:     otp,
+		Year:    time.Now().Year(),
 	}
 
 	// Generate HTML content from template
@@ -116,18 +131,12 @@ func (e *EmailService) SendOTPEmail(email, firstName, otp string) error {
 	// Queue email for async sending (Django's EmailThread equivalent)
 	emailReq := EmailRequest{
 		To:      []string{email},
-		Subject: "GoPadi Account Verification",
+		Subject: fmt.Sprintf("%s Account Verification", e.appName),
 		Body:    htmlContent,
 		IsHTML:  true,
 	}
 
-	select {
-	case e.emailQueue <- emailReq:
-		return nil
-	default:
-		// Queue is full, send synchronously as fallback
-		return e.sendEmailSync(emailReq)
-	}
+	return e.enqueue(emailReq)
 }
 
 // SendPasswordResetEmail sends password reset email asynchronously
@@ -136,7 +145,7 @@ func (e *EmailService) SendPasswordResetEmail(email, resetLink string) error {
 		<html>
 		<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
 			<div style="background-color: #dc3545; color: white; padding: 20px; text-align: center;">
-				<h1>Password Reset - GoPadi</h1>
+				<h1>Password Reset - %s</h1>
 			</div>
 			<div style="padding: 20px;">
 				<h2>Password Reset Request</h2>
@@ -152,22 +161,17 @@ func (e *EmailService) SendPasswordResetEmail(email, resetLink string) error {
 			</div>
 		</body>
 		</html>
-	`, resetLink)
+	`, e.appName, resetLink)
 
 	// Queue email for async sending
 	emailReq := EmailRequest{
 		To:      []string{email},
-		Subject: "Password Reset - GoPadi",
+		Subject: fmt.Sprintf("Password Reset - %s", e.appName),
 		Body:    htmlContent,
 		IsHTML:  true,
 	}
 
-	select {
-	case e.emailQueue <- emailReq:
-		return nil
-	default:
-		return e.sendEmailSync(emailReq)
-	}
+	return e.enqueue(emailReq)
 }
 
 // SendWelcomeEmail sends welcome email after verification asynchronously
@@ -176,45 +180,30 @@ func (e *EmailService) SendWelcomeEmail(email, firstName string) error {
 		<html>
 		<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
 			<div style="background-color: #28a745; color: white; padding: 20px; text-align: center;">
-				<h1>Welcome to GoPadi!</h1>
+				<h1>Welcome to %[1]s!</h1>
 			</div>
 			<div style="padding: 20px;">
-				<h2>Hello %s!</h2>
-				<p>Your account has been successfully verified. Welcome to the GoPadi community!</p>
-				<p>You can now:</p>
-				<ul>
-					<li>Join campaigns and challenges</li>
-					<li>Track your fitness activities</li>
-					<li>Connect with other fitness enthusiasts</li>
-					<li>Make a positive impact through fitness</li>
-				</ul>
-				<p>Get started by exploring our latest campaigns and challenges.</p>
-				<div style="text-align: center; margin: 30px 0;">
-					<a href="#" style="background-color: #28a745; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px;">Explore Campaigns</a>
-				</div>
+				<h2>Hello %[2]s!</h2>
+				<p>Your account has been successfully verified. Welcome to %[1]s!</p>
+				<p>You can now sign in and start using your account.</p>
 			</div>
 			<div style="background-color: #f8f9fa; padding: 20px; text-align: center; color: #6c757d;">
-				<p>Thank you for joining GoPadi!</p>
-				<p>&copy; 2024 GoPadi. All rights reserved.</p>
+				<p>Thank you for joining %[1]s!</p>
+				<p>&copy; %[3]d %[1]s. All rights reserved.</p>
 			</div>
 		</body>
 		</html>
-	`, firstName)
+	`, e.appName, firstName, time.Now().Year())
 
 	// Queue email for async sending
 	emailReq := EmailRequest{
 		To:      []string{email},
-		Subject: "Welcome to GoPadi!",
+		Subject: fmt.Sprintf("Welcome to %s!", e.appName),
 		Body:    htmlContent,
 		IsHTML:  true,
 	}
 
-	select {
-	case e.emailQueue <- emailReq:
-		return nil
-	default:
-		return e.sendEmailSync(emailReq)
-	}
+	return e.enqueue(emailReq)
 }
 
 
@@ -241,11 +230,11 @@ func (e *EmailService) generateOTPHTML(data OTPEmailData) (string, error) {
 	<html>
 	<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
 		<div style="background-color: #007bff; color: white; padding: 20px; text-align: center;">
-			<h1>GoPadi Account Verification</h1>
+			<h1>{{.AppName}} Account Verification</h1>
 		</div>
 		<div style="padding: 20px;">
 			<h2>Hello {{.Name}}!</h2>
-			<p>Thank you for registering with GoPadi. To complete your registration, please use the verification code below:</p>
+			<p>Thank you for registering with {{.AppName}}. To complete your registration, please use the verification code below:</p>
 			
 			<div style="background-color: #f8f9fa; padding: 20px; text-align: center; margin: 20px 0; border-radius: 5px;">
 				<h1 style="color: #007bff; font-size: 36px; margin: 0; letter-spacing: 5px;">{{.OTP}}</h1>
@@ -254,11 +243,11 @@ func (e *EmailService) generateOTPHTML(data OTPEmailData) (string, error) {
 			<p>This verification code will expire in 10 minutes for security reasons.</p>
 			<p>If you didn't request this verification, please ignore this email.</p>
 			
-			<p>Welcome to the GoPadi community!</p>
+			<p>Welcome to {{.AppName}}!</p>
 		</div>
 		<div style="background-color: #f8f9fa; padding: 20px; text-align: center; color: #6c757d;">
 			<p>This is an automated message, please do not reply to this email.</p>
-			<p>&copy; 2024 GoPadi. All rights reserved.</p>
+			<p>&copy; {{.Year}} {{.AppName}}. All rights reserved.</p>
 		</div>
 	</body>
 	</html>
@@ -302,24 +291,48 @@ func (e *EmailService) SendBulkEmail(emails []string, subject, htmlContent strin
 			IsHTML:  true,
 		}
 
-		select {
-		case e.emailQueue <- emailReq:
-			// Successfully queued
-		default:
-			// Queue is full, send synchronously
-			if err := e.sendEmailSync(emailReq); err != nil {
-				log.Printf("Failed to send bulk email to %s: %v", email, err)
-			}
+		if err := e.enqueue(emailReq); err != nil {
+			log.Printf("Failed to send bulk email to %s: %v", email, err)
 		}
 	}
 
 	return nil
 }
 
-// Close gracefully shuts down the email service
-func (e *EmailService) Close() {
+// ErrClosed is returned when an email is sent after Close.
+var ErrClosed = errors.New("email service is closed")
+
+// enqueue queues req for the worker, or sends it synchronously when the queue is full
+func (e *EmailService) enqueue(req EmailRequest) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return ErrClosed
+	}
+
+	select {
+	case e.emailQueue <- req:
+		return nil
+	default:
+		// Queue is full, send synchronously as fallback
+		return e.sendEmailSync(req)
+	}
+}
+
+// Close stops accepting emails and waits until every queued email has been sent
+func (e *EmailService) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
 	close(e.emailQueue)
+	e.mu.Unlock()
+
+	<-e.workerDone
 	e.wg.Wait()
+	return nil
 }
 
 // GetQueueLength returns the current number of emails in the queue
@@ -0,0 +1,55 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTokenBlacklisted is returned for a token that was logged out.
+	ErrTokenBlacklisted = errors.New("token has been invalidated")
+	// ErrStoreUnavailable is returned, under FailClosed, when a token cannot
+	// be checked because a revocation store failed, and by RefreshToken when
+	// the refresh token store fails.
+	ErrStoreUnavailable = errors.New("token store unavailable")
+)
+
+// Blacklist stores accepted by ServiceConfig.Blacklist
+const (
+	BlacklistRedis    = "redis"
+	BlacklistDatabase = "database"
+	BlacklistMemory   = "memory"
+)
+
+// TokenBlacklist records tokens that were logged out until they expire.
+type TokenBlacklist interface {
+	// BlacklistToken rejects token until expiresAt.
+	BlacklistToken(token string, expiresAt time.Time) error
+	// IsTokenBlacklisted reports whether token was blacklisted. An error
+	// means the store could not answer; see FailurePolicy.
+	IsTokenBlacklisted(token string) (bool, error)
+	// GetBlacklistedCount returns the number of blacklisted tokens.
+	GetBlacklistedCount() (int64, error)
+}
+
+// FailurePolicy decides what happens to a token when the blacklist, the
+// valid-after store or the session store fails while checking it.
+type FailurePolicy int
+
+const (
+	// FailClosed rejects the token: an outage logs everyone out, but a
+	// revoked token is never accepted.
+	FailClosed FailurePolicy = iota
+	// FailOpen accepts the token as if it had not been revoked, keeping the
+	// API available while the store is down.
+	FailOpen
+)
+
+// hashToken returns the SHA256 hash under which a token is blacklisted, so
+// the stores never hold usable tokens
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
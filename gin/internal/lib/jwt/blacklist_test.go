@@ -0,0 +1,273 @@
+package jwt
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingBlacklist is a blacklist store that counts its lookups
+type countingBlacklist struct {
+	mu      sync.Mutex
+	tokens  map[string]bool
+	lookups int
+}
+
+func newCountingBlacklist() *countingBlacklist {
+	return &countingBlacklist{tokens: make(map[string]bool)}
+}
+
+func (b *countingBlacklist) BlacklistToken(token string, expiresAt time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens[token] = true
+	return nil
+}
+
+func (b *countingBlacklist) IsTokenBlacklisted(token string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lookups++
+	return b.tokens[token], nil
+}
+
+func (b *countingBlacklist) GetBlacklistedCount() (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int64(len(b.tokens)), nil
+}
+
+func (b *countingBlacklist) lookupCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lookups
+}
+
+func TestMemoryTokenBlacklistSweep(t *testing.T) {
+	b := NewMemoryTokenBlacklist(10 * time.Millisecond)
+	defer b.Close()
+
+	if err := b.BlacklistToken("short", time.Now().Add(30*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.BlacklistToken("long", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.BlacklistToken("expired", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := b.GetBlacklistedCount(); count != 2 {
+		t.Fatalf("GetBlacklistedCount = %d, want 2: an expired token is not stored", count)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		count, _ := b.GetBlacklistedCount()
+		if count == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GetBlacklistedCount = %d after the short token expired, want 1", count)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	for token, want := range map[string]bool{"short": false, "long": true, "expired": false} {
+		if got, err := b.IsTokenBlacklisted(token); err != nil || got != want {
+			t.Errorf("IsTokenBlacklisted(%q) = %v, %v, want %v", token, got, err, want)
+		}
+	}
+}
+
+func TestLayeredTokenBlacklistEviction(t *testing.T) {
+	store := newCountingBlacklist()
+	b := NewLayeredTokenBlacklist(store, 2, time.Hour)
+	expiresAt := time.Now().Add(time.Hour)
+
+	for _, token := range []string{"a", "b", "c"} {
+		if err := b.BlacklistToken(token, expiresAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		token   string
+		want    bool
+		lookups int // store lookups so far
+	}{
+		{token: "c", want: true, lookups: 0},
+		{token: "b", want: true, lookups: 0},
+		// The least recently used token was evicted
+		{token: "a", want: true, lookups: 1},
+		// Caching a evicted c, then the least recently used
+		{token: "b", want: true, lookups: 1},
+		{token: "c", want: true, lookups: 2},
+		// An unknown token is cached for the ttl, evicting b
+		{token: "d", want: false, lookups: 3},
+		{token: "d", want: false, lookups: 3},
+		{token: "a", want: true, lookups: 4},
+	}
+	for _, tt := range tests {
+		got, err := b.IsTokenBlacklisted(tt.token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("IsTokenBlacklisted(%q) = %v, want %v", tt.token, got, tt.want)
+		}
+		if n := store.lookupCount(); n != tt.lookups {
+			t.Errorf("after looking up %q the store was asked %d times, want %d", tt.token, n, tt.lookups)
+		}
+	}
+}
+
+func TestLayeredTokenBlacklistTTL(t *testing.T) {
+	const ttl = 100 * time.Millisecond
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		// stale is whether the other instance's blacklisting goes unseen
+		// until the cache expires
+		stale bool
+	}{
+		{name: "cached", ttl: ttl, stale: true},
+		{name: "uncached", ttl: 0, stale: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Two instances share the database, each with its own cache
+			store := NewDatabaseTokenBlacklist(newTestDB(t))
+			first := NewLayeredTokenBlacklist(store, 10, tt.ttl)
+			second := NewLayeredTokenBlacklist(store, 10, tt.ttl)
+
+			if blacklisted, err := second.IsTokenBlacklisted("token"); err != nil || blacklisted {
+				t.Fatalf("IsTokenBlacklisted = %v, %v, want false", blacklisted, err)
+			}
+			if err := first.BlacklistToken("token", time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+
+			if blacklisted, _ := first.IsTokenBlacklisted("token"); !blacklisted {
+				t.Error("the instance that blacklisted the token accepts it")
+			}
+			if blacklisted, _ := second.IsTokenBlacklisted("token"); blacklisted == tt.stale {
+				t.Errorf("IsTokenBlacklisted on the other instance = %v before the cache expired, want %v", blacklisted, !tt.stale)
+			}
+			time.Sleep(ttl + 50*time.Millisecond)
+			if blacklisted, _ := second.IsTokenBlacklisted("token"); !blacklisted {
+				t.Error("the other instance accepts the token after the cache expired")
+			}
+		})
+	}
+}
+
+var errStoreDown = errors.New("store down")
+
+// flakyBlacklist, flakyValidAfter and flakyRefresh fail their lookups
+// while down is set
+type flakyBlacklist struct {
+	TokenBlacklist
+	down *atomic.Bool
+}
+
+func (b flakyBlacklist) IsTokenBlacklisted(token string) (bool, error) {
+	if b.down.Load() {
+		return false, errStoreDown
+	}
+	return b.TokenBlacklist.IsTokenBlacklisted(token)
+}
+
+type flakyValidAfter struct {
+	ValidAfterStore
+	down *atomic.Bool
+}
+
+func (s flakyValidAfter) ValidAfter(userID string) (time.Time, error) {
+	if s.down.Load() {
+		return time.Time{}, errStoreDown
+	}
+	return s.ValidAfterStore.ValidAfter(userID)
+}
+
+type flakyRefresh struct {
+	RefreshTokenStore
+	down *atomic.Bool
+}
+
+func (s flakyRefresh) Touch(family string) (bool, error) {
+	if s.down.Load() {
+		return false, errStoreDown
+	}
+	return s.RefreshTokenStore.Touch(family)
+}
+
+func (s flakyRefresh) Rotate(family, jti, next string, expiresAt time.Time) error {
+	if s.down.Load() {
+		return errStoreDown
+	}
+	return s.RefreshTokenStore.Rotate(family, jti, next, expiresAt)
+}
+
+func TestFailurePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		store  string // the store that fails
+		policy FailurePolicy
+		want   error
+	}{
+		{name: "blacklist fails closed", store: "blacklist", policy: FailClosed, want: ErrStoreUnavailable},
+		{name: "blacklist fails open", store: "blacklist", policy: FailOpen},
+		{name: "valid-after fails closed", store: "valid-after", policy: FailClosed, want: ErrStoreUnavailable},
+		{name: "valid-after fails open", store: "valid-after", policy: FailOpen},
+		{name: "session fails closed", store: "session", policy: FailClosed, want: ErrStoreUnavailable},
+		{name: "session fails open", store: "session", policy: FailOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			down := new(atomic.Bool)
+			stores := Stores{
+				Blacklist:  NewDatabaseTokenBlacklist(db),
+				Refresh:    NewDatabaseRefreshTokenStore(db),
+				ValidAfter: NewDatabaseValidAfterStore(db),
+			}
+			switch tt.store {
+			case "blacklist":
+				stores.Blacklist = flakyBlacklist{stores.Blacklist, down}
+			case "valid-after":
+				stores.ValidAfter = flakyValidAfter{stores.ValidAfter, down}
+			case "session":
+				stores.Refresh = flakyRefresh{stores.Refresh, down}
+			}
+			s := NewJWTService(NewKeyring(NewHMACKey("test-secret")), time.Hour, 24*time.Hour, stores, tt.policy)
+
+			pair, err := s.GenerateTokenPair(testUser("alice"), ClientInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			down.Store(true)
+
+			// Check the access token the way the auth middleware does
+			claims, err := s.ValidateToken(pair.AccessToken)
+			if err == nil {
+				err = s.ValidateSession(claims.SessionID)
+			}
+			if tt.want == nil && err != nil {
+				t.Fatalf("token rejected with %v, want it accepted", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("token check = %v, want %v", err, tt.want)
+			}
+
+			if tt.store == "blacklist" {
+				if got, want := s.IsTokenBlacklisted(pair.AccessToken), tt.policy == FailClosed; got != want {
+					t.Errorf("IsTokenBlacklisted = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
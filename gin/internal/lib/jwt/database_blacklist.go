@@ -1,11 +1,10 @@
 package jwt
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"time"
 
 	"github.com/SOG-web/goinit/gin/internal/domain/model"
+	"github.com/SOG-web/goinit/gin/internal/lib/id"
 	"gorm.io/gorm"
 )
 
@@ -17,6 +16,14 @@ type BlacklistedToken struct {
 	Token      string    `gorm:"-" json:"-"` // Don't store the actual token
 }
 
+// BeforeCreate hook to set ID if not provided
+func (bt *BlacklistedToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if bt.ID == "" {
+		bt.ID = id.New()
+	}
+	return
+}
+
 // DatabaseTokenBlacklist manages blacklisted JWT tokens using database
 type DatabaseTokenBlacklist struct {
 	db     *gorm.DB
@@ -25,24 +32,15 @@ type DatabaseTokenBlacklist struct {
 
 // NewDatabaseTokenBlacklist creates a new database-based token blacklist
 func NewDatabaseTokenBlacklist(db *gorm.DB) *DatabaseTokenBlacklist {
-	// Auto-migrate the table
-	db.AutoMigrate(&BlacklistedToken{})
-
 	return &DatabaseTokenBlacklist{
 		db:     db,
 		prefix: "jwt_blacklist:",
 	}
 }
 
-// hashToken creates a SHA256 hash of the token for storage
-func (dtb *DatabaseTokenBlacklist) hashToken(token string) string {
-	hash := sha256.Sum256([]byte(token))
-	return hex.EncodeToString(hash[:])
-}
-
 // BlacklistToken adds a token to the database blacklist with expiration
 func (dtb *DatabaseTokenBlacklist) BlacklistToken(token string, expiresAt time.Time) error {
-	tokenHash := dtb.hashToken(token)
+	tokenHash := hashToken(token)
 
 	// Create the blacklisted token record
 	blacklistedToken := &BlacklistedToken{
@@ -57,15 +55,15 @@ func (dtb *DatabaseTokenBlacklist) BlacklistToken(token string, expiresAt time.T
 }
 
 // IsTokenBlacklisted checks if a token is in the database blacklist
-func (dtb *DatabaseTokenBlacklist) IsTokenBlacklisted(token string) bool {
-	tokenHash := dtb.hashToken(token)
+func (dtb *DatabaseTokenBlacklist) IsTokenBlacklisted(token string) (bool, error) {
+	tokenHash := hashToken(token)
 
 	var count int64
-	dtb.db.Model(&BlacklistedToken{}).
+	err := dtb.db.Model(&BlacklistedToken{}).
 		Where("token_hash = ? AND expires_at > ?", dtb.prefix+tokenHash, time.Now()).
-		Count(&count)
+		Count(&count).Error
 
-	return count > 0
+	return count > 0, err
 }
 
 // GetBlacklistedCount returns the number of active blacklisted tokens
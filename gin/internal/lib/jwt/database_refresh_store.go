@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenFamily is the database record of a refresh token family
+type RefreshTokenFamily struct {
+	ID         string     `gorm:"primaryKey;size:32" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UserID     string     `gorm:"not null;index" json:"user_id"`
+	CurrentJTI string     `gorm:"column:current_jti;not null" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Device     string     `gorm:"size:255;not null;default:''" json:"device"`
+	IP         string     `gorm:"column:ip;size:64;not null;default:''" json:"ip"`
+	UserAgent  string     `gorm:"size:512;not null;default:''" json:"user_agent"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// session returns the family as a session
+func (f *RefreshTokenFamily) session() Session {
+	lastSeen := f.CreatedAt
+	if f.LastSeenAt != nil {
+		lastSeen = *f.LastSeenAt
+	}
+	return Session{
+		ID:         f.ID,
+		UserID:     f.UserID,
+		Device:     f.Device,
+		IP:         f.IP,
+		UserAgent:  f.UserAgent,
+		CreatedAt:  f.CreatedAt,
+		LastSeenAt: lastSeen,
+		ExpiresAt:  f.ExpiresAt,
+	}
+}
+
+// DatabaseRefreshTokenStore keeps refresh token families in the database
+type DatabaseRefreshTokenStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseRefreshTokenStore creates a database-based refresh token store
+func NewDatabaseRefreshTokenStore(db *gorm.DB) *DatabaseRefreshTokenStore {
+	return &DatabaseRefreshTokenStore{db: db}
+}
+
+// Issue starts a token family
+func (s *DatabaseRefreshTokenStore) Issue(family, userID, jti string, expiresAt time.Time, client ClientInfo) error {
+	now := time.Now()
+	return s.db.Create(&RefreshTokenFamily{
+		ID:         family,
+		UserID:     userID,
+		CurrentJTI: jti,
+		ExpiresAt:  expiresAt,
+		Device:     truncate(client.Device, 255),
+		IP:         truncate(client.IP, 64),
+		UserAgent:  truncate(client.UserAgent, 512),
+		LastSeenAt: &now,
+	}).Error
+}
+
+// Rotate replaces the current token of a family. The conditional update
+// lets only one of two concurrent refreshes with the same token succeed.
+func (s *DatabaseRefreshTokenStore) Rotate(family, jti, next string, expiresAt time.Time) error {
+	now := time.Now()
+	result := s.db.Model(&RefreshTokenFamily{}).
+		Where("id = ? AND current_jti = ? AND revoked_at IS NULL AND expires_at > ?", family, jti, now).
+		Updates(map[string]any{"current_jti": next, "expires_at": expiresAt, "last_seen_at": now, "updated_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil
+	}
+
+	var fam RefreshTokenFamily
+	if err := s.db.Where("id = ?", family).First(&fam).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefreshTokenRevoked
+		}
+		return err
+	}
+	if fam.RevokedAt != nil || !fam.ExpiresAt.After(now) {
+		return ErrRefreshTokenRevoked
+	}
+	if err := s.RevokeFamily(family); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// RevokeFamily marks a family as revoked. The record is kept until it
+// expires so that replays of its tokens are still recognised.
+func (s *DatabaseRefreshTokenStore) RevokeFamily(family string) error {
+	return s.db.Model(&RefreshTokenFamily{}).
+		Where("id = ? AND revoked_at IS NULL", family).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Touch reports whether a family is active and updates its last-seen time
+// at most once per sessionTouchInterval
+func (s *DatabaseRefreshTokenStore) Touch(family string) (bool, error) {
+	now := time.Now()
+
+	var fam RefreshTokenFamily
+	if err := s.db.Select("id", "expires_at", "revoked_at", "last_seen_at").Where("id = ?", family).First(&fam).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if fam.RevokedAt != nil || !fam.ExpiresAt.After(now) {
+		return false, nil
+	}
+
+	if fam.LastSeenAt == nil || now.Sub(*fam.LastSeenAt) >= sessionTouchInterval {
+		if err := s.db.Model(&RefreshTokenFamily{}).Where("id = ?", family).UpdateColumn("last_seen_at", now).Error; err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Sessions returns the active families of a user, most recently seen first
+func (s *DatabaseRefreshTokenStore) Sessions(userID string) ([]Session, error) {
+	var families []RefreshTokenFamily
+	err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Find(&families).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(families))
+	for i := range families {
+		sessions = append(sessions, families[i].session())
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+// ClearExpiredTokens removes expired families from the database
+func (s *DatabaseRefreshTokenStore) ClearExpiredTokens() error {
+	return s.db.Where("expires_at <= ?", time.Now()).Delete(&RefreshTokenFamily{}).Error
+}
+
+// GetExpiredTokenCount returns the number of expired families that can be cleaned up
+func (s *DatabaseRefreshTokenStore) GetExpiredTokenCount() (int64, error) {
+	var count int64
+	err := s.db.Model(&RefreshTokenFamily{}).
+		Where("expires_at <= ?", time.Now()).
+		Count(&count).Error
+
+	return count, err
+}
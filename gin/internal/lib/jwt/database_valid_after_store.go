@@ -0,0 +1,74 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenValidAfter is the database record of the valid-after time of a user
+type TokenValidAfter struct {
+	UserID     string    `gorm:"primaryKey;size:32" json:"user_id"`
+	ValidAfter time.Time `gorm:"not null" json:"valid_after"`
+	ExpiresAt  time.Time `gorm:"not null;index" json:"expires_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName keeps one row per user in token_valid_after
+func (TokenValidAfter) TableName() string {
+	return "token_valid_after"
+}
+
+// DatabaseValidAfterStore keeps the valid-after time of each user in the database
+type DatabaseValidAfterStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseValidAfterStore creates a database-based valid-after store
+func NewDatabaseValidAfterStore(db *gorm.DB) *DatabaseValidAfterStore {
+	return &DatabaseValidAfterStore{db: db}
+}
+
+// SetValidAfter records the valid-after time of a user, replacing an earlier
+// one. It is truncated to milliseconds, the precision of every driver's
+// column, since MySQL would round it up instead.
+func (s *DatabaseValidAfterStore) SetValidAfter(userID string, t, expiresAt time.Time) error {
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"valid_after", "expires_at", "updated_at"}),
+	}).Create(&TokenValidAfter{
+		UserID:     userID,
+		ValidAfter: t.Truncate(time.Millisecond),
+		ExpiresAt:  expiresAt,
+	}).Error
+}
+
+// ValidAfter returns the valid-after time of a user, or the zero time
+func (s *DatabaseValidAfterStore) ValidAfter(userID string) (time.Time, error) {
+	var record TokenValidAfter
+	err := s.db.Select("valid_after").Where("user_id = ?", userID).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return record.ValidAfter, nil
+}
+
+// ClearExpiredTokens removes the records whose tokens have all expired
+func (s *DatabaseValidAfterStore) ClearExpiredTokens() error {
+	return s.db.Where("expires_at <= ?", time.Now()).Delete(&TokenValidAfter{}).Error
+}
+
+// GetExpiredTokenCount returns the number of records that can be cleaned up
+func (s *DatabaseValidAfterStore) GetExpiredTokenCount() (int64, error) {
+	var count int64
+	err := s.db.Model(&TokenValidAfter{}).
+		Where("expires_at <= ?", time.Now()).
+		Count(&count).Error
+
+	return count, err
+}
@@ -2,21 +2,39 @@ package jwt
 
 import (
 	"errors"
-	"os"
+	"fmt"
+	"io"
+	"log/slog"
 	"time"
 
 	"github.com/SOG-web/goinit/gin/internal/domain/model"
 	userModel "github.com/SOG-web/goinit/gin/internal/domain/user/model"
+	"github.com/SOG-web/goinit/gin/internal/lib/id"
 	"github.com/golang-jwt/jwt/v5"
+	// goinit:if redis
 	"github.com/redis/go-redis/v9"
+	// goinit:end
 	"gorm.io/gorm"
 )
 
+// JWTService issues and validates tokens. Logged out tokens, sessions and
+// the valid-after times of users are kept in its stores, which
+// NewJWTServiceFactory picks from the configuration.
 type JWTService struct {
-	secretKey     string
+	keys          *Keyring
 	tokenExpiry   time.Duration
 	refreshExpiry time.Duration
-	blacklist     *RedisTokenBlacklist
+	blacklist     TokenBlacklist
+	refresh       RefreshTokenStore
+	validAfter    ValidAfterStore
+	policy        FailurePolicy
+}
+
+// Stores are the stores of a JWTService
+type Stores struct {
+	Blacklist  TokenBlacklist
+	Refresh    RefreshTokenStore
+	ValidAfter ValidAfterStore
 }
 
 type Claims struct {
@@ -24,6 +42,15 @@ type Claims struct {
 	Email       string `json:"email"`
 	Username    string `json:"username"`
 	IsVerified  bool   `json:"is_verified"`
+	IsStaff     bool   `json:"is_staff,omitempty"`
+	IsSuperuser bool   `json:"is_superuser,omitempty"`
+	// Family is the refresh token family of a refresh token
+	Family string `json:"fam,omitempty"`
+	// SessionID is the session (refresh token family) of an access token
+	SessionID string `json:"sid,omitempty"`
+	// IssuedAtMillis is the issue time in Unix milliseconds; the iat claim
+	// only has seconds, too coarse to compare with valid-after times
+	IssuedAtMillis int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -35,45 +62,92 @@ type TokenPair struct {
 
 // JWTServiceInterface defines the interface for JWT operations
 type JWTServiceInterface interface {
-	GenerateTokenPair(user *userModel.User) (*TokenPair, error)
+	GenerateTokenPair(user *userModel.User, client ClientInfo) (*TokenPair, error)
 	ValidateToken(tokenString string) (*Claims, error)
-	RefreshToken(refreshToken string, user *userModel.User) (string, error)
+	RefreshToken(claims *Claims, user *userModel.User) (*TokenPair, error)
 	BlacklistToken(tokenString string) error
 	IsTokenBlacklisted(tokenString string) bool
 	ExtractTokenFromHeader(authHeader string) (string, error)
 	GetUserFromToken(tokenString string) (*userModel.User, error)
+	ValidateSession(sessionID string) error
+	ListSessions(userID string) ([]Session, error)
+	RevokeSession(userID, sessionID string) error
+	RevokeAllSessions(userID, exceptSessionID string) (int, error)
+	RevokeUserTokens(userID string) error
+}
+
+// newAccessClaims returns the claims of an access token for user's session
+func newAccessClaims(user *userModel.User, expiry time.Duration, sessionID string) *Claims {
+	now := time.Now()
+	return &Claims{
+		UserID:         user.ID,
+		Email:          user.Email,
+		Username:       user.Username,
+		IsVerified:     user.IsVerified,
+		IsStaff:        user.IsStaff,
+		IsSuperuser:    user.IsSuperuser,
+		SessionID:      sessionID,
+		IssuedAtMillis: now.UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id.New(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Subject:   "access",
+		},
+	}
+}
+
+// newRefreshClaims returns the claims of refresh token jti of family
+func newRefreshClaims(userID, family, jti string, expiresAt time.Time) *Claims {
+	now := time.Now()
+	return &Claims{
+		UserID:         userID,
+		Family:         family,
+		IssuedAtMillis: now.UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Subject:   "refresh",
+		},
+	}
 }
 
-func NewJWTService(secretKey string, tokenExpiry, refreshExpiry time.Duration, redisClient *redis.Client) *JWTService {
+// NewJWTService creates a JWT service that signs with keys and keeps its
+// state in stores. policy decides whether tokens are accepted while a store
+// is failing.
+func NewJWTService(keys *Keyring, tokenExpiry, refreshExpiry time.Duration, stores Stores, policy FailurePolicy) *JWTService {
 	return &JWTService{
-		secretKey:     secretKey,
+		keys:          keys,
 		tokenExpiry:   tokenExpiry,
 		refreshExpiry: refreshExpiry,
-		blacklist:     NewRedisTokenBlacklist(redisClient, "jwt_blacklist:"),
+		blacklist:     stores.Blacklist,
+		refresh:       stores.Refresh,
+		validAfter:    stores.ValidAfter,
+		policy:        policy,
 	}
 }
 
-// GenerateTokenPair generates both access and refresh tokens for a user
-func (j *JWTService) GenerateTokenPair(user *userModel.User) (*TokenPair, error) {
+// GenerateTokenPair generates both access and refresh tokens for a user,
+// starting a new refresh token family
+func (j *JWTService) GenerateTokenPair(user *userModel.User, client ClientInfo) (*TokenPair, error) {
+	// Start the session; its ID is the refresh token family
+	family, jti := id.New(), id.New()
+	expiresAt := time.Now().Add(j.refreshExpiry)
+	if err := j.refresh.Issue(family, user.ID, jti, expiresAt, client); err != nil {
+		return nil, err
+	}
+
 	// Generate access token
-	accessToken, err := j.generateToken(user, j.tokenExpiry)
+	accessToken, err := j.generateToken(user, j.tokenExpiry, family)
 	if err != nil {
 		return nil, err
 	}
 
 	// Generate refresh token (longer expiry, no detailed claims)
-	refreshClaims := &Claims{
-		UserID: user.ID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.refreshExpiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Subject:   "refresh",
-		},
-	}
-
-	refreshTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err := refreshTokenObj.SignedString([]byte(j.secretKey))
+	refreshToken, err := j.keys.Sign(newRefreshClaims(user.ID, family, jti, expiresAt))
 	if err != nil {
 		return nil, err
 	}
@@ -85,69 +159,86 @@ func (j *JWTService) GenerateTokenPair(user *userModel.User) (*TokenPair, error)
 	}, nil
 }
 
-// generateToken creates a JWT token for a user
-func (j *JWTService) generateToken(user *userModel.User, expiry time.Duration) (string, error) {
-	claims := &Claims{
-		UserID:      user.ID,
-		Email:       user.Email,
-		Username:    user.Username,
-		IsVerified:  user.IsVerified,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Subject:   "access",
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+// generateToken creates a JWT token for a user's session
+func (j *JWTService) generateToken(user *userModel.User, expiry time.Duration, sessionID string) (string, error) {
+	return j.keys.Sign(newAccessClaims(user, expiry, sessionID))
 }
 
 // ValidateToken validates a JWT token and returns the claims
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 	// Check if token is blacklisted first
-	if j.blacklist.IsTokenBlacklisted(tokenString) {
-		return nil, errors.New("token has been invalidated")
+	blacklisted, err := j.blacklist.IsTokenBlacklisted(tokenString)
+	if err != nil {
+		if err := j.storeFailed("blacklist", err); err != nil {
+			return nil, err
+		}
+	} else if blacklisted {
+		return nil, ErrTokenBlacklisted
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
+	token, err := j.keys.Parse(tokenString, &Claims{})
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		// Reject tokens issued before the user's tokens were revoked
+		validAfter, err := j.validAfter.ValidAfter(claims.UserID)
+		if err != nil {
+			if err := j.storeFailed("valid-after", err); err != nil {
+				return nil, err
+			}
+		} else if issuedBy(claims, validAfter) {
+			return nil, ErrTokenRevoked
+		}
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
 
-// RefreshToken generates a new access token from a valid refresh token
-func (j *JWTService) RefreshToken(refreshToken string, user *userModel.User) (string, error) {
-	claims, err := j.ValidateToken(refreshToken)
-	if err != nil {
-		return "", err
-	}
-
+// RefreshToken exchanges a refresh token for a new token pair of the same
+// family. claims are what ValidateToken returned for the refresh token, which
+// callers validate first to look up the user. The refresh token can only be
+// used once: presenting it again revokes the family.
+func (j *JWTService) RefreshToken(claims *Claims, user *userModel.User) (*TokenPair, error) {
 	// Verify this is a refresh token
-	if claims.Subject != "refresh" {
-		return "", errors.New("invalid refresh token")
+	if claims.Subject != "refresh" || claims.Family == "" {
+		return nil, errors.New("invalid refresh token")
 	}
 
 	// Verify the user ID matches
 	if claims.UserID != user.ID {
-		return "", errors.New("token user mismatch")
+		return nil, errors.New("token user mismatch")
 	}
 
-	// Generate new access token
-	return j.generateToken(user, j.tokenExpiry)
+	// Replace the refresh token of the family; fails if it was used before.
+	// A token that cannot be rotated cannot be refreshed, so a store
+	// failure is ErrStoreUnavailable whatever the failure policy
+	next := id.New()
+	expiresAt := time.Now().Add(j.refreshExpiry)
+	if err := j.refresh.Rotate(claims.Family, claims.ID, next, expiresAt); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) || errors.Is(err, ErrRefreshTokenRevoked) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: refresh: %v", ErrStoreUnavailable, err)
+	}
+
+	accessToken, err := j.generateToken(user, j.tokenExpiry, claims.Family)
+	if err != nil {
+		return nil, err
+	}
+	newRefreshToken, err := j.keys.Sign(newRefreshClaims(user.ID, claims.Family, next, expiresAt))
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(j.tokenExpiry.Seconds()),
+	}, nil
 }
 
 // ExtractTokenFromHeader extracts JWT token from Authorization header
@@ -180,6 +271,8 @@ func (j *JWTService) GetUserFromToken(tokenString string) (*userModel.User, erro
 		Email:       claims.Email,
 		Username:    claims.Username,
 		IsVerified:  claims.IsVerified,
+		IsStaff:     claims.IsStaff,
+		IsSuperuser: claims.IsSuperuser,
 	}
 
 	return user, nil
@@ -188,12 +281,7 @@ func (j *JWTService) GetUserFromToken(tokenString string) (*userModel.User, erro
 // BlacklistToken adds a token to the blacklist
 func (j *JWTService) BlacklistToken(tokenString string) error {
 	// Parse the token to get its expiration time
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
+	token, err := j.keys.Parse(tokenString, &Claims{})
 
 	if err != nil {
 		return err
@@ -201,16 +289,20 @@ func (j *JWTService) BlacklistToken(tokenString string) error {
 
 	if claims, ok := token.Claims.(*Claims); ok {
 		// Add to blacklist with its expiration time
-		j.blacklist.BlacklistToken(tokenString, claims.ExpiresAt.Time)
-		return nil
+		return j.blacklist.BlacklistToken(tokenString, claims.ExpiresAt.Time)
 	}
 
 	return errors.New("invalid token claims")
 }
 
-// IsTokenBlacklisted checks if a token is blacklisted
+// IsTokenBlacklisted checks if a token is blacklisted; whether it is when
+// the blacklist fails depends on the failure policy
 func (j *JWTService) IsTokenBlacklisted(tokenString string) bool {
-	return j.blacklist.IsTokenBlacklisted(tokenString)
+	blacklisted, err := j.blacklist.IsTokenBlacklisted(tokenString)
+	if err != nil {
+		return j.storeFailed("blacklist", err) != nil
+	}
+	return blacklisted
 }
 
 // GetBlacklistedTokenCount returns the number of blacklisted tokens
@@ -218,197 +310,164 @@ func (j *JWTService) GetBlacklistedTokenCount() (int64, error) {
 	return j.blacklist.GetBlacklistedCount()
 }
 
-// DatabaseJWTService uses database for token blacklisting instead of Redis
-type DatabaseJWTService struct {
-	secretKey     string
-	tokenExpiry   time.Duration
-	refreshExpiry time.Duration
-	blacklist     *DatabaseTokenBlacklist
-}
-
-// NewDatabaseJWTService creates a JWT service that uses database for token storage
-func NewDatabaseJWTService(secretKey string, tokenExpiry, refreshExpiry time.Duration, db *gorm.DB) *DatabaseJWTService {
-	return &DatabaseJWTService{
-		secretKey:     secretKey,
-		tokenExpiry:   tokenExpiry,
-		refreshExpiry: refreshExpiry,
-		blacklist:     NewDatabaseTokenBlacklist(db),
-	}
-}
-
-// GenerateTokenPair generates both access and refresh tokens for a user
-func (j *DatabaseJWTService) GenerateTokenPair(user *userModel.User) (*TokenPair, error) {
-	// Generate access token
-	accessToken, err := j.generateToken(user, j.tokenExpiry)
+// ValidateSession returns ErrSessionRevoked unless the session is active
+func (j *JWTService) ValidateSession(sessionID string) error {
+	active, err := j.refresh.Touch(sessionID)
 	if err != nil {
-		return nil, err
+		return j.storeFailed("session", err)
 	}
-
-	// Generate refresh token (longer expiry, no detailed claims)
-	refreshClaims := &Claims{
-		UserID: user.ID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.refreshExpiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Subject:   "refresh",
-		},
+	if !active {
+		return ErrSessionRevoked
 	}
-
-	refreshTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err := refreshTokenObj.SignedString([]byte(j.secretKey))
-	if err != nil {
-		return nil, err
-	}
-
-	return &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64(j.tokenExpiry.Seconds()),
-	}, nil
+	return nil
 }
 
-// generateToken creates a JWT token for a user
-func (j *DatabaseJWTService) generateToken(user *userModel.User, expiry time.Duration) (string, error) {
-	claims := &Claims{
-		UserID:      user.ID,
-		Email:       user.Email,
-		Username:    user.Username,
-		IsVerified:  user.IsVerified,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Subject:   "access",
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+// ListSessions returns the active sessions of a user
+func (j *JWTService) ListSessions(userID string) ([]Session, error) {
+	return j.refresh.Sessions(userID)
 }
 
-// ValidateToken validates a JWT token and returns the claims
-func (j *DatabaseJWTService) ValidateToken(tokenString string) (*Claims, error) {
-	// Check if token is blacklisted first
-	if j.blacklist.IsTokenBlacklisted(tokenString) {
-		return nil, errors.New("token has been invalidated")
-	}
-
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
-
+// RevokeSession revokes a session of a user; its access tokens stop working
+// and its refresh token can no longer be used. It returns
+// ErrSessionNotFound unless the session is an active session of the user.
+func (j *JWTService) RevokeSession(userID, sessionID string) error {
+	sessions, err := j.refresh.Sessions(userID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	for _, s := range sessions {
+		if s.ID == sessionID {
+			return j.refresh.RevokeFamily(sessionID)
+		}
 	}
-
-	return nil, errors.New("invalid token")
+	return ErrSessionNotFound
 }
 
-// RefreshToken generates a new access token from a valid refresh token
-func (j *DatabaseJWTService) RefreshToken(refreshToken string, user *userModel.User) (string, error) {
-	claims, err := j.ValidateToken(refreshToken)
+// RevokeAllSessions revokes every session of a user except exceptSessionID
+// (none if empty) and returns how many were revoked
+func (j *JWTService) RevokeAllSessions(userID, exceptSessionID string) (int, error) {
+	sessions, err := j.refresh.Sessions(userID)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
-
-	// Verify this is a refresh token
-	if claims.Subject != "refresh" {
-		return "", errors.New("invalid refresh token")
-	}
-
-	// Verify the user ID matches
-	if claims.UserID != user.ID {
-		return "", errors.New("token user mismatch")
+	revoked := 0
+	for _, s := range sessions {
+		if s.ID == exceptSessionID {
+			continue
+		}
+		if err := j.refresh.RevokeFamily(s.ID); err != nil {
+			return revoked, err
+		}
+		revoked++
 	}
-
-	// Generate new access token
-	return j.generateToken(user, j.tokenExpiry)
+	return revoked, nil
 }
 
-// ExtractTokenFromHeader extracts JWT token from Authorization header
-func (j *DatabaseJWTService) ExtractTokenFromHeader(authHeader string) (string, error) {
-	if authHeader == "" {
-		return "", errors.New("authorization header required")
-	}
-
-	// Check if it starts with "Bearer "
-	const bearerPrefix = "Bearer "
-	if len(authHeader) < len(bearerPrefix) || authHeader[:len(bearerPrefix)] != bearerPrefix {
-		return "", errors.New("invalid authorization header format")
+// RevokeUserTokens rejects every token issued to a user so far and revokes
+// the user's sessions. Tokens are compared by their issue time in
+// milliseconds, and those issued up to the millisecond of the revocation are
+// rejected: a login in that same millisecond has to be repeated. Callers save
+// the change that calls for the revocation first, so that no token issued
+// after it predates the change.
+func (j *JWTService) RevokeUserTokens(userID string) error {
+	validAfter := time.Now().Truncate(time.Millisecond)
+	if err := j.validAfter.SetValidAfter(userID, validAfter, validAfter.Add(max(j.tokenExpiry, j.refreshExpiry))); err != nil {
+		return err
 	}
-
-	return authHeader[len(bearerPrefix):], nil
+	_, err := j.RevokeAllSessions(userID, "")
+	return err
 }
 
-// GetUserFromToken extracts user information from token claims
-func (j *DatabaseJWTService) GetUserFromToken(tokenString string) (*userModel.User, error) {
-	claims, err := j.ValidateToken(tokenString)
-	if err != nil {
-		return nil, err
+// Close stops the blacklist if it runs in the background
+func (j *JWTService) Close() error {
+	if closer, ok := j.blacklist.(io.Closer); ok {
+		return closer.Close()
 	}
-
-	// Create a user object from claims (minimal info)
-	user := &userModel.User{
-		Base: model.Base{
-			ID: claims.UserID,
-		},
-		Email:       claims.Email,
-		Username:    claims.Username,
-		IsVerified:  claims.IsVerified,
-	}
-
-	return user, nil
+	return nil
 }
 
-// BlacklistToken adds a token to the blacklist
-func (j *DatabaseJWTService) BlacklistToken(tokenString string) error {
-	// Parse the token to get its expiration time
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
-
-	if err != nil {
-		return err
-	}
-
-	if claims, ok := token.Claims.(*Claims); ok {
-		// Add to blacklist with its expiration time
-		j.blacklist.BlacklistToken(tokenString, claims.ExpiresAt.Time)
+// storeFailed applies the failure policy to err, an error of the named
+// store while checking a token: FailOpen logs it and accepts the token,
+// FailClosed rejects the token with ErrStoreUnavailable
+func (j *JWTService) storeFailed(store string, err error) error {
+	if j.policy == FailOpen {
+		slog.Warn("token store failed, accepting token", "store", store, "err", err)
 		return nil
 	}
-
-	return errors.New("invalid token claims")
-}
-
-// IsTokenBlacklisted checks if a token is blacklisted
-func (j *DatabaseJWTService) IsTokenBlacklisted(tokenString string) bool {
-	return j.blacklist.IsTokenBlacklisted(tokenString)
+	return fmt.Errorf("%w: %s: %v", ErrStoreUnavailable, store, err)
 }
 
-// GetBlacklistedTokenCount returns the number of blacklisted tokens
-func (j *DatabaseJWTService) GetBlacklistedTokenCount() (int64, error) {
-	return j.blacklist.GetBlacklistedCount()
+// ServiceConfig configures NewJWTServiceFactory
+type ServiceConfig struct {
+	TokenExpiry   time.Duration
+	RefreshExpiry time.Duration
+	// UseDatabase keeps the stores in the database instead of Redis
+	UseDatabase bool
+	// Blacklist is BlacklistRedis, BlacklistDatabase or BlacklistMemory;
+	// empty uses the store chosen by UseDatabase
+	Blacklist string
+	// BlacklistCacheSize caches the answers of a Redis or database
+	// blacklist for that many tokens in memory; 0 disables the cache
+	BlacklistCacheSize int
+	// BlacklistCacheTTL is how long the cache trusts that a token is not
+	// blacklisted
+	BlacklistCacheTTL time.Duration
+	// FailOpen accepts tokens while a store fails instead of rejecting them
+	FailOpen bool
 }
 
-// NewJWTServiceFactory creates JWT service based on environment configuration
-func NewJWTServiceFactory(secretKey string, tokenExpiry, refreshExpiry time.Duration, redisClient *redis.Client, db *gorm.DB) JWTServiceInterface {
-	// Check environment variable to choose implementation
-	useDatabase := os.Getenv("USE_DATABASE_JWT") == "true"
-
-	if useDatabase {
-		return NewDatabaseJWTService(secretKey, tokenExpiry, refreshExpiry, db)
-	}
-
-	return NewJWTService(secretKey, tokenExpiry, refreshExpiry, redisClient)
+// memorySweepInterval is how often the in-memory blacklist drops expired tokens
+const memorySweepInterval = time.Minute
+
+// NewJWTServiceFactory creates the JWT service with the stores cfg selects
+func NewJWTServiceFactory(
+	cfg ServiceConfig,
+	keys *Keyring,
+	// goinit:if redis
+	redisClient *redis.Client,
+	// goinit:end
+	db *gorm.DB,
+) (JWTServiceInterface, error) {
+	stores := Stores{
+		Refresh:    NewDatabaseRefreshTokenStore(db),
+		ValidAfter: NewDatabaseValidAfterStore(db),
+	}
+	// goinit:if redis
+	if !cfg.UseDatabase {
+		stores.Refresh = NewRedisRefreshTokenStore(redisClient, "jwt_refresh:")
+		stores.ValidAfter = NewRedisValidAfterStore(redisClient, "jwt_valid_after:")
+	}
+	// goinit:end
+
+	blacklist := cfg.Blacklist
+	if blacklist == "" {
+		blacklist = BlacklistDatabase
+		// goinit:if redis
+		if !cfg.UseDatabase {
+			blacklist = BlacklistRedis
+		}
+		// goinit:end
+	}
+	switch blacklist {
+	// goinit:if redis
+	case BlacklistRedis:
+		stores.Blacklist = NewRedisTokenBlacklist(redisClient, "jwt_blacklist:")
+	// goinit:end
+	case BlacklistDatabase:
+		stores.Blacklist = NewDatabaseTokenBlacklist(db)
+	case BlacklistMemory:
+		stores.Blacklist = NewMemoryTokenBlacklist(memorySweepInterval)
+	default:
+		return nil, fmt.Errorf("unknown JWT blacklist %q", blacklist)
+	}
+	// An in-memory blacklist needs no cache in front of it
+	if cfg.BlacklistCacheSize > 0 && blacklist != BlacklistMemory {
+		stores.Blacklist = NewLayeredTokenBlacklist(stores.Blacklist, cfg.BlacklistCacheSize, cfg.BlacklistCacheTTL)
+	}
+
+	policy := FailClosed
+	if cfg.FailOpen {
+		policy = FailOpen
+	}
+	return NewJWTService(keys, cfg.TokenExpiry, cfg.RefreshExpiry, stores, policy), nil
 }
@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SOG-web/goinit/gin/internal/domain/model"
+	userModel "github.com/SOG-web/goinit/gin/internal/domain/user/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newTestService returns a service with its stores in a test database
+func newTestService(t *testing.T) *JWTService {
+	t.Helper()
+	db := newTestDB(t)
+	return NewJWTService(NewKeyring(NewHMACKey("test-secret")), time.Hour, 24*time.Hour, Stores{
+		Blacklist:  NewDatabaseTokenBlacklist(db),
+		Refresh:    NewDatabaseRefreshTokenStore(db),
+		ValidAfter: NewDatabaseValidAfterStore(db),
+	}, FailClosed)
+}
+
+func testUser(id string) *userModel.User {
+	return &userModel.User{Base: model.Base{ID: id}, Email: id + "@example.com", Username: id}
+}
+
+func TestRefreshToken(t *testing.T) {
+	alice := testUser("alice")
+
+	tests := []struct {
+		name string
+		// present returns the token to refresh, given the pair of a login
+		present func(t *testing.T, s *JWTService, pair *TokenPair) string
+		user    *userModel.User
+		want    error // nil for success; errAny for any error
+		// sessionActive is whether the session of the login survives
+		sessionActive bool
+	}{
+		{
+			name:          "refresh token of a login",
+			present:       func(t *testing.T, s *JWTService, pair *TokenPair) string { return pair.RefreshToken },
+			sessionActive: true,
+		},
+		{
+			name: "refresh token from a refresh",
+			present: func(t *testing.T, s *JWTService, pair *TokenPair) string {
+				return mustRefresh(t, s, pair.RefreshToken, alice).RefreshToken
+			},
+			sessionActive: true,
+		},
+		{
+			name: "replayed refresh token",
+			present: func(t *testing.T, s *JWTService, pair *TokenPair) string {
+				mustRefresh(t, s, pair.RefreshToken, alice)
+				return pair.RefreshToken
+			},
+			want: ErrRefreshTokenReused,
+		},
+		{
+			name: "refresh token of a revoked session",
+			present: func(t *testing.T, s *JWTService, pair *TokenPair) string {
+				claims, err := s.ValidateToken(pair.AccessToken)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := s.RevokeSession(alice.ID, claims.SessionID); err != nil {
+					t.Fatal(err)
+				}
+				return pair.RefreshToken
+			},
+			want: ErrRefreshTokenRevoked,
+		},
+		{
+			name:          "access token",
+			present:       func(t *testing.T, s *JWTService, pair *TokenPair) string { return pair.AccessToken },
+			want:          errAny,
+			sessionActive: true,
+		},
+		{
+			name:          "refresh token of another user",
+			present:       func(t *testing.T, s *JWTService, pair *TokenPair) string { return pair.RefreshToken },
+			user:          testUser("mallory"),
+			want:          errAny,
+			sessionActive: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t)
+			pair, err := s.GenerateTokenPair(alice, ClientInfo{Device: "test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			token := tt.present(t, s, pair)
+			user := tt.user
+			if user == nil {
+				user = alice
+			}
+
+			next, err := refresh(s, token, user)
+			switch {
+			case tt.want == nil && err != nil:
+				t.Fatalf("RefreshToken = %v", err)
+			case tt.want == errAny && err == nil:
+				t.Fatal("RefreshToken succeeded, want an error")
+			case tt.want != nil && tt.want != errAny && !errors.Is(err, tt.want):
+				t.Fatalf("RefreshToken = %v, want %v", err, tt.want)
+			}
+			if err == nil {
+				if _, err := s.ValidateToken(next.AccessToken); err != nil {
+					t.Errorf("new access token: %v", err)
+				}
+			}
+
+			claims, err := s.ValidateToken(pair.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.ValidateSession(claims.SessionID)
+			if tt.sessionActive && err != nil {
+				t.Errorf("ValidateSession = %v, want the session active", err)
+			}
+			if !tt.sessionActive && !errors.Is(err, ErrSessionRevoked) {
+				t.Errorf("ValidateSession = %v, want %v", err, ErrSessionRevoked)
+			}
+		})
+	}
+}
+
+// TestRefreshTokenStoreUnavailable checks that a refresh token is not
+// refreshed when its rotation fails, even under FailOpen
+func TestRefreshTokenStoreUnavailable(t *testing.T) {
+	for name, policy := range map[string]FailurePolicy{"fails closed": FailClosed, "fails open": FailOpen} {
+		t.Run(name, func(t *testing.T) {
+			db := newTestDB(t)
+			down := new(atomic.Bool)
+			s := NewJWTService(NewKeyring(NewHMACKey("test-secret")), time.Hour, 24*time.Hour, Stores{
+				Blacklist:  NewDatabaseTokenBlacklist(db),
+				Refresh:    flakyRefresh{NewDatabaseRefreshTokenStore(db), down},
+				ValidAfter: NewDatabaseValidAfterStore(db),
+			}, policy)
+			alice := testUser("alice")
+			pair, err := s.GenerateTokenPair(alice, ClientInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			down.Store(true)
+			if _, err := refresh(s, pair.RefreshToken, alice); !errors.Is(err, ErrStoreUnavailable) {
+				t.Errorf("RefreshToken = %v, want %v", err, ErrStoreUnavailable)
+			}
+			// The token was not rotated, so it refreshes once the store is back
+			down.Store(false)
+			mustRefresh(t, s, pair.RefreshToken, alice)
+		})
+	}
+}
+
+// errAny stands for any error in test tables
+var errAny = errors.New("any error")
+
+// refresh exchanges token the way the refresh endpoint does: it is
+// validated, then rotated
+func refresh(s *JWTService, token string, user *userModel.User) (*TokenPair, error) {
+	claims, err := s.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.RefreshToken(claims, user)
+}
+
+// mustRefresh refreshes token or fails the test
+func mustRefresh(t *testing.T, s *JWTService, token string, user *userModel.User) *TokenPair {
+	t.Helper()
+	pair, err := refresh(s, token, user)
+	if err != nil {
+		t.Fatalf("RefreshToken = %v", err)
+	}
+	return pair
+}
+
+func TestIssuedBy(t *testing.T) {
+	validAfter := time.UnixMilli(1_700_000_000_500)
+
+	tests := []struct {
+		name   string
+		claims Claims
+		want   bool
+	}{
+		{name: "a millisecond before", claims: Claims{IssuedAtMillis: 1_700_000_000_499}, want: true},
+		{name: "the same millisecond", claims: Claims{IssuedAtMillis: 1_700_000_000_500}, want: true},
+		{name: "later", claims: Claims{IssuedAtMillis: 1_700_000_000_501}, want: false},
+		{name: "iat only, the same second", claims: claimsIssuedAt(time.Unix(1_700_000_000, 0)), want: true},
+		{name: "iat only, the next second", claims: claimsIssuedAt(time.Unix(1_700_000_001, 0)), want: false},
+		{name: "no issue time", claims: Claims{}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := issuedBy(&tt.claims, validAfter); got != tt.want {
+				t.Errorf("issuedBy = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// claimsIssuedAt returns claims with an iat but no iat_ms claim, like the
+// tokens issued before iat_ms was added
+func claimsIssuedAt(t time.Time) Claims {
+	return Claims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(t)}}
+}
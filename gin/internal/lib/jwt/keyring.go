@@ -0,0 +1,604 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms supported by the keyring.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is a key of the keyring: an HS256 secret or an RS256, ES256 or EdDSA
+// private key.
+type Key struct {
+	// ID is sent as the kid header: the RFC 7638 thumbprint of the public
+	// key, or empty for the HS256 secret, whose tokens carry no kid
+	ID        string
+	Algorithm string
+	Created   time.Time
+
+	method  jwt.SigningMethod
+	private any // []byte or crypto.Signer
+	public  any // []byte or crypto.PublicKey
+}
+
+// NewHMACKey returns the HS256 key for secret.
+func NewHMACKey(secret string) *Key {
+	return &Key{Algorithm: AlgHS256, method: jwt.SigningMethodHS256, private: []byte(secret), public: []byte(secret)}
+}
+
+// NewKey returns the key for an RSA, P-256 ECDSA or Ed25519 private key.
+func NewKey(private crypto.Signer, created time.Time) (*Key, error) {
+	k := &Key{Created: created, private: private, public: private.Public()}
+	switch p := private.(type) {
+	case *rsa.PrivateKey:
+		if p.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must have at least 2048 bits")
+		}
+		k.Algorithm, k.method = AlgRS256, jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		if p.Curve != elliptic.P256() {
+			return nil, errors.New("ECDSA keys must use the P-256 curve")
+		}
+		k.Algorithm, k.method = AlgES256, jwt.SigningMethodES256
+	case ed25519.PrivateKey:
+		k.Algorithm, k.method = AlgEdDSA, jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+
+	jwk, _ := k.PublicJWK()
+	k.ID = jwk.thumbprint()
+	return k, nil
+}
+
+// GenerateKey creates a new key for alg: RS256 (2048-bit RSA), ES256
+// (P-256) or EdDSA (Ed25519).
+func GenerateKey(alg string) (*Key, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("cannot generate a key for algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key: %v", alg, err)
+	}
+	return NewKey(private, time.Now().UTC())
+}
+
+// ParseKeyPEM parses a PKCS #8, PKCS #1 (RSA) or SEC 1 (EC) private key.
+func ParseKeyPEM(data []byte, created time.Time) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var private any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+	return NewKey(signer, created)
+}
+
+// MarshalPEM encodes the private key as PKCS #8.
+func (k *Key) MarshalPEM() ([]byte, error) {
+	if k.Algorithm == AlgHS256 {
+		return nil, errors.New("HS256 secrets are not stored as PEM")
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(k.private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK returns the public key as a JWK; HS256 secrets have none.
+func (k *Key) PublicJWK() (JWK, bool) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwk := JWK{Use: "sig", Alg: k.Algorithm, Kid: k.ID}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk.Kty, jwk.Crv = "EC", "P-256"
+		jwk.X = b64(pub.X.FillBytes(make([]byte, 32)))
+		jwk.Y = b64(pub.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+		jwk.X = b64(pub)
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
+
+// thumbprint returns the RFC 7638 SHA-256 thumbprint of the key, the
+// hash of its required members in lexicographic order.
+func (j JWK) thumbprint() string {
+	var members string
+	switch j.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, j.E, j.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, j.Crv, j.X, j.Y)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, j.Crv, j.X)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// retiredKey is a replaced key that still verifies tokens until its grace
+// period ends, or a published key that verifies tokens before it signs any,
+// whose until is zero.
+type retiredKey struct {
+	key   *Key
+	until time.Time
+}
+
+// Keyring signs tokens with its current key and verifies them with the
+// current key or any retired key whose grace period has not ended, picked
+// by the kid header; HS256 tokens have none, so each secret is tried. It is
+// safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	current *Key
+	retired []retiredKey
+}
+
+// NewKeyring returns a keyring that signs with current.
+func NewKeyring(current *Key) *Keyring {
+	return &Keyring{current: current}
+}
+
+// Rotate makes next the signing key. The previous key keeps verifying
+// tokens for grace, which should be at least the lifetime of the tokens it
+// signed so nobody is logged out.
+func (r *Keyring) Rotate(next *Key, grace time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retired = append(r.retired, retiredKey{key: r.current, until: time.Now().Add(grace)})
+	r.current = next
+}
+
+// Retire adds a key that only verifies tokens, until the given time.
+func (r *Keyring) Retire(key *Key, until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retired = append(r.retired, retiredKey{key: key, until: until})
+}
+
+// Publish adds a key that verifies tokens but does not sign yet. Publishing
+// the next signing key on every instance before any signs with it lets them
+// all verify its tokens from the start.
+func (r *Keyring) Publish(key *Key) {
+	r.Retire(key, time.Time{})
+}
+
+// Current returns the signing key.
+func (r *Keyring) Current() *Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// verificationKeys returns the current key and the retired keys that have
+// not expired.
+func (r *Keyring) verificationKeys() []*Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := []*Key{r.current}
+	now := time.Now()
+	for _, rk := range r.retired {
+		if rk.until.IsZero() || now.Before(rk.until) {
+			keys = append(keys, rk.key)
+		}
+	}
+	return keys
+}
+
+// Sign signs claims with the current key and sets its kid header.
+func (r *Keyring) Sign(claims jwt.Claims) (string, error) {
+	key := r.Current()
+	token := jwt.NewWithClaims(key.method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.private)
+}
+
+// Parse verifies tokenString with the key named by its kid header and
+// decodes its claims into claims.
+func (r *Keyring) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, r.keyFunc)
+}
+
+func (r *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	var set jwt.VerificationKeySet
+	for _, key := range r.verificationKeys() {
+		if key.ID != kid {
+			continue
+		}
+		// The algorithm comes from the key, never from the token
+		if token.Method.Alg() != key.Algorithm {
+			return nil, errors.New("unexpected signing method")
+		}
+		set.Keys = append(set.Keys, key.public)
+	}
+	switch len(set.Keys) {
+	case 0:
+		return nil, errors.New("unknown signing key")
+	case 1:
+		return set.Keys[0], nil
+	}
+	// HS256 secrets have no kid; the current and previous ones are tried
+	return set, nil
+}
+
+// JWKS returns the public keys that verify tokens, for other services to
+// verify them without sharing a secret.
+func (r *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range r.verificationKeys() {
+		if jwk, ok := key.PublicJWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+// KeyringConfig configures LoadKeyring.
+type KeyringConfig struct {
+	Algorithm string // HS256 (default), RS256, ES256 or EdDSA
+	Secret    string // the HS256 secret
+	// PreviousSecrets are replaced HS256 secrets, comma separated, each as
+	// "secret@time" with the RFC 3339 time it was replaced
+	PreviousSecrets string
+	KeyDir          string        // directory of the PEM private keys
+	GracePeriod     time.Duration // how long a replaced key still verifies tokens
+}
+
+// previousSecret is an entry of KeyringConfig.PreviousSecrets
+type previousSecret struct {
+	secret   string
+	replaced time.Time
+}
+
+// parsePreviousSecrets parses KeyringConfig.PreviousSecrets. Errors do not
+// quote the entries, which hold secrets.
+func parsePreviousSecrets(value string) ([]previousSecret, error) {
+	var secrets []previousSecret
+	for i, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		at := strings.LastIndex(entry, "@")
+		if at <= 0 {
+			return nil, fmt.Errorf("previous JWT secret %d: want secret@time, the RFC 3339 time it was replaced", i+1)
+		}
+		replaced, err := time.Parse(time.RFC3339, entry[at+1:])
+		if err != nil {
+			return nil, fmt.Errorf("previous JWT secret %d: invalid replacement time: %v", i+1, err)
+		}
+		secrets = append(secrets, previousSecret{secret: entry[:at], replaced: replaced})
+	}
+	return secrets, nil
+}
+
+// KeyFile is a key of the key directory and its state.
+type KeyFile struct {
+	Key     *Key
+	Path    string
+	Signing bool
+	// Pending keys were prepared by PrepareKey: they verify tokens until
+	// ActivateKey makes them sign
+	Pending bool
+	// ValidUntil is when a replaced key stops verifying tokens
+	ValidUntil time.Time
+}
+
+// Expired reports whether the key no longer verifies tokens.
+func (f KeyFile) Expired() bool {
+	return !f.Signing && !f.Pending && !time.Now().Before(f.ValidUntil)
+}
+
+// keyFileTime is the layout of the timestamp that key file names start
+// with; it records when the key was created, or activated, even if the file
+// is copied.
+const keyFileTime = "20060102T150405Z"
+
+// pendingKeySuffix ends the file names of pending keys.
+const pendingKeySuffix = ".pending.pem"
+
+// ErrNoPendingKey is returned by ActivateKey when no key was prepared.
+var ErrNoPendingKey = errors.New("no key has been prepared")
+
+// ListKeys reads the PEM files of cfg.KeyDir. Pending keys only verify
+// tokens. Of the others, the newest key of cfg.Algorithm signs; every other
+// key was replaced when the next newer key was created and verifies tokens
+// for cfg.GracePeriod after that. Keys are ordered by the timestamp their
+// file name starts with, or by modification time for files without one.
+func ListKeys(cfg KeyringConfig) ([]KeyFile, error) {
+	paths, err := filepath.Glob(filepath.Join(cfg.KeyDir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []KeyFile
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		created, err := time.Parse(keyFileTime, strings.SplitN(filepath.Base(path), "-", 2)[0])
+		if err != nil {
+			info, statErr := os.Stat(path)
+			if statErr != nil {
+				return nil, statErr
+			}
+			created = info.ModTime().UTC()
+		}
+		key, err := ParseKeyPEM(data, created)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		files = append(files, KeyFile{Key: key, Path: path, Pending: strings.HasSuffix(path, pendingKeySuffix)})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Key.Created.Before(files[j].Key.Created) })
+
+	var active []int // the indexes of the keys that are not pending
+	for i, f := range files {
+		if !f.Pending {
+			active = append(active, i)
+		}
+	}
+	signing := -1
+	for _, i := range active {
+		if files[i].Key.Algorithm == cfg.Algorithm {
+			signing = i
+		}
+	}
+	for n, i := range active {
+		if i == signing {
+			files[i].Signing = true
+			continue
+		}
+		// The newest key of another algorithm was replaced by switching
+		// JWT_ALGORITHM back; its grace period counts from its creation
+		replaced := files[i].Key.Created
+		if n+1 < len(active) {
+			replaced = files[active[n+1]].Key.Created
+		}
+		files[i].ValidUntil = replaced.Add(cfg.GracePeriod)
+	}
+	return files, nil
+}
+
+// WriteKeyFile stores key in dir, readable by the owner only.
+func WriteKeyFile(dir string, key *Key) (string, error) {
+	return writeKeyFile(dir, key, ".pem")
+}
+
+func writeKeyFile(dir string, key *Key, suffix string) (string, error) {
+	data, err := key.MarshalPEM()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := keyFilePath(dir, key, suffix)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// keyFilePath names the file of key after its creation time and kid
+func keyFilePath(dir string, key *Key, suffix string) string {
+	return filepath.Join(dir, key.Created.UTC().Format(keyFileTime)+"-"+key.ID[:8]+suffix)
+}
+
+// LoadKeyring builds the keyring described by cfg. For HS256 it signs with
+// the secret, and cfg.PreviousSecrets verify tokens for cfg.GracePeriod
+// after they were replaced. Otherwise it loads cfg.KeyDir (see ListKeys),
+// generating and storing a key of cfg.Algorithm when there is none, so the
+// first boot needs no setup. Every instance of a service must use the same
+// keys.
+func LoadKeyring(cfg KeyringConfig) (*Keyring, error) {
+	switch cfg.Algorithm {
+	case "", AlgHS256:
+		if cfg.Secret == "" {
+			return nil, errors.New("JWT secret is empty")
+		}
+		previous, err := parsePreviousSecrets(cfg.PreviousSecrets)
+		if err != nil {
+			return nil, err
+		}
+		ring := NewKeyring(NewHMACKey(cfg.Secret))
+		for _, p := range previous {
+			if until := p.replaced.Add(cfg.GracePeriod); time.Now().Before(until) {
+				ring.Retire(NewHMACKey(p.secret), until)
+			}
+		}
+		return ring, nil
+	case AlgRS256, AlgES256, AlgEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q (choose HS256, RS256, ES256 or EdDSA)", cfg.Algorithm)
+	}
+
+	files, err := ListKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var ring *Keyring
+	for _, f := range files {
+		if f.Signing {
+			ring = NewKeyring(f.Key)
+		}
+	}
+	if ring == nil {
+		key, err := GenerateKey(cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := WriteKeyFile(cfg.KeyDir, key); err != nil {
+			return nil, fmt.Errorf("failed to store generated key: %v", err)
+		}
+		ring = NewKeyring(key)
+	}
+	for _, f := range files {
+		switch {
+		case f.Pending:
+			ring.Publish(f.Key)
+		case !f.Signing && !f.Expired():
+			ring.Retire(f.Key, f.ValidUntil)
+		}
+	}
+	return ring, nil
+}
+
+// RotateKey generates a new signing key of cfg.Algorithm in cfg.KeyDir and
+// deletes the key files that no longer verify tokens. Instances that
+// restart sign with the new key at once, and until the others restart too
+// they reject its tokens, so it suits a single instance; with several, use
+// PrepareKey and ActivateKey.
+func RotateKey(cfg KeyringConfig) (*Key, []string, error) {
+	_, removed, err := PrepareKey(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := ActivateKey(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, removed, nil
+}
+
+// PrepareKey generates the next signing key of cfg.Algorithm in cfg.KeyDir
+// as a pending key, which the instances only verify tokens with once they
+// restart, and deletes the key files that no longer verify tokens. After
+// every instance has restarted, ActivateKey makes it the signing key.
+func PrepareKey(cfg KeyringConfig) (*Key, []string, error) {
+	files, err := ListKeys(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, f := range files {
+		if f.Pending {
+			return nil, nil, fmt.Errorf("key %s is already prepared; activate it first", f.Key.ID)
+		}
+	}
+
+	key, err := GenerateKey(cfg.Algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := writeKeyFile(cfg.KeyDir, key, pendingKeySuffix); err != nil {
+		return nil, nil, err
+	}
+
+	var removed []string
+	for _, f := range files {
+		if f.Expired() {
+			if err := os.Remove(f.Path); err != nil {
+				return nil, nil, err
+			}
+			removed = append(removed, f.Path)
+		}
+	}
+	return key, removed, nil
+}
+
+// ActivateKey makes the key PrepareKey created the signing key of
+// cfg.KeyDir, which the instances sign with once they restart. Its file is
+// renamed after the activation time, from which the grace period of the key
+// it replaces counts. It returns ErrNoPendingKey if no key was prepared.
+func ActivateKey(cfg KeyringConfig) (*Key, error) {
+	files, err := ListKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if !f.Pending {
+			continue
+		}
+		if f.Key.Algorithm != cfg.Algorithm {
+			return nil, fmt.Errorf("prepared key %s is %s, not %s", f.Key.ID, f.Key.Algorithm, cfg.Algorithm)
+		}
+		f.Key.Created = time.Now().UTC()
+		if err := os.Rename(f.Path, keyFilePath(cfg.KeyDir, f.Key, ".pem")); err != nil {
+			return nil, err
+		}
+		return f.Key, nil
+	}
+	return nil, ErrNoPendingKey
+}
@@ -0,0 +1,309 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signWith returns a token signed by key
+func signWith(t *testing.T, key *Key) string {
+	t.Helper()
+	token, err := NewKeyring(key).Sign(jwt.RegisteredClaims{
+		Subject:   "access",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestLoadKeyringPreviousSecrets(t *testing.T) {
+	replaced := func(ago time.Duration) string {
+		return time.Now().Add(-ago).UTC().Format(time.RFC3339)
+	}
+
+	tests := []struct {
+		name     string
+		previous string
+		signer   string // the secret the token is signed with
+		want     bool   // whether the token verifies
+	}{
+		{name: "current secret", signer: "current", want: true},
+		{name: "secret within the grace period", previous: "old@" + replaced(time.Hour), signer: "old", want: true},
+		{name: "secret after the grace period", previous: "old@" + replaced(48*time.Hour), signer: "old", want: false},
+		{name: "secret containing @", previous: "o@ld@" + replaced(time.Hour), signer: "o@ld", want: true},
+		{name: "one of several secrets", previous: "older@" + replaced(2*time.Hour) + ", old@" + replaced(time.Hour), signer: "older", want: true},
+		{name: "current secret with previous ones", previous: "old@" + replaced(time.Hour), signer: "current", want: true},
+		{name: "unknown secret", previous: "old@" + replaced(time.Hour), signer: "other", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := LoadKeyring(KeyringConfig{
+				Algorithm:       AlgHS256,
+				Secret:          "current",
+				PreviousSecrets: tt.previous,
+				GracePeriod:     24 * time.Hour,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ring.Parse(signWith(t, NewHMACKey(tt.signer)), &jwt.RegisteredClaims{})
+			if tt.want && err != nil {
+				t.Errorf("Parse = %v, want the token verified", err)
+			}
+			if !tt.want && err == nil {
+				t.Error("Parse verified the token, want it rejected")
+			}
+		})
+	}
+}
+
+func TestLoadKeyringInvalidPreviousSecrets(t *testing.T) {
+	for _, previous := range []string{"old", "@2025-01-02T15:04:05Z", "old@yesterday"} {
+		_, err := LoadKeyring(KeyringConfig{Algorithm: AlgHS256, Secret: "current", PreviousSecrets: previous})
+		if err == nil {
+			t.Errorf("LoadKeyring accepted previous secrets %q", previous)
+		}
+	}
+}
+
+// kids returns the key IDs of a JWK set
+func kids(set JWKSet) map[string]bool {
+	ids := make(map[string]bool)
+	for _, jwk := range set.Keys {
+		ids[jwk.Kid] = true
+	}
+	return ids
+}
+
+func TestPrepareAndActivateKey(t *testing.T) {
+	cfg := KeyringConfig{Algorithm: AlgEdDSA, KeyDir: t.TempDir(), GracePeriod: time.Hour}
+
+	old, err := GenerateKey(AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.Created = time.Now().Add(-2 * time.Hour).UTC()
+	if _, err := WriteKeyFile(cfg.KeyDir, old); err != nil {
+		t.Fatal(err)
+	}
+
+	next, _, err := PrepareKey(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := PrepareKey(cfg); err == nil {
+		t.Error("PrepareKey prepared a second key")
+	}
+
+	// Restarted instances verify the prepared key's tokens but sign with the old key
+	ring, err := LoadKeyring(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ring.Current().ID != old.ID {
+		t.Errorf("signing key after PrepareKey = %s, want the old key %s", ring.Current().ID, old.ID)
+	}
+	if _, err := ring.Parse(signWith(t, next), &jwt.RegisteredClaims{}); err != nil {
+		t.Errorf("token of the prepared key: %v", err)
+	}
+	if ids := kids(ring.JWKS()); !ids[old.ID] || !ids[next.ID] {
+		t.Errorf("JWKS after PrepareKey = %v, want both keys", ids)
+	}
+
+	activated, err := ActivateKey(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activated.ID != next.ID {
+		t.Errorf("ActivateKey activated %s, want the prepared key %s", activated.ID, next.ID)
+	}
+	if _, err := ActivateKey(cfg); !errors.Is(err, ErrNoPendingKey) {
+		t.Errorf("second ActivateKey = %v, want %v", err, ErrNoPendingKey)
+	}
+
+	// The old key was replaced at activation and verifies for the grace period
+	ring, err = LoadKeyring(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ring.Current().ID != next.ID {
+		t.Errorf("signing key after ActivateKey = %s, want the prepared key %s", ring.Current().ID, next.ID)
+	}
+	if _, err := ring.Parse(signWith(t, old), &jwt.RegisteredClaims{}); err != nil {
+		t.Errorf("token of the replaced key: %v", err)
+	}
+	files, err := ListKeys(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Key.ID == old.ID && f.ValidUntil.Before(time.Now().Add(cfg.GracePeriod-time.Minute)) {
+			t.Errorf("replaced key verifies until %v, want the grace period from activation", f.ValidUntil)
+		}
+	}
+}
+
+func TestKeyringRetiredKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		until time.Duration // from now, when the retired key stops verifying
+		want  bool
+	}{
+		{name: "within the grace period", until: time.Hour, want: true},
+		{name: "after the grace period", until: -time.Second, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, err := GenerateKey(AlgES256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			current, err := GenerateKey(AlgES256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token := signWith(t, old)
+
+			ring := NewKeyring(current)
+			ring.Retire(old, time.Now().Add(tt.until))
+
+			_, err = ring.Parse(token, &jwt.RegisteredClaims{})
+			if tt.want && err != nil {
+				t.Errorf("Parse = %v, want the token of the retired key verified", err)
+			}
+			if !tt.want && err == nil {
+				t.Error("Parse verified the token of the expired key")
+			}
+			if got := kids(ring.JWKS())[old.ID]; got != tt.want {
+				t.Errorf("retired key in the JWKS = %v, want %v", got, tt.want)
+			}
+			if _, err := ring.Parse(signWith(t, current), &jwt.RegisteredClaims{}); err != nil {
+				t.Errorf("token of the current key: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadKeyringGracePeriod(t *testing.T) {
+	tests := []struct {
+		name  string
+		grace time.Duration
+		want  bool // whether the replaced key verifies
+	}{
+		// The old key was replaced by the new one an hour ago
+		{name: "within the grace period", grace: 2 * time.Hour, want: true},
+		{name: "after the grace period", grace: 30 * time.Minute, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := KeyringConfig{Algorithm: AlgRS256, KeyDir: t.TempDir(), GracePeriod: tt.grace}
+			var keys []*Key
+			for _, age := range []time.Duration{3 * time.Hour, time.Hour} {
+				key, err := GenerateKey(AlgRS256)
+				if err != nil {
+					t.Fatal(err)
+				}
+				key.Created = time.Now().Add(-age).UTC()
+				if _, err := WriteKeyFile(cfg.KeyDir, key); err != nil {
+					t.Fatal(err)
+				}
+				keys = append(keys, key)
+			}
+			old, current := keys[0], keys[1]
+
+			ring, err := LoadKeyring(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ring.Current().ID != current.ID {
+				t.Errorf("signing key = %s, want the newest key %s", ring.Current().ID, current.ID)
+			}
+			_, err = ring.Parse(signWith(t, old), &jwt.RegisteredClaims{})
+			if tt.want && err != nil {
+				t.Errorf("Parse = %v, want the token of the replaced key verified", err)
+			}
+			if !tt.want && err == nil {
+				t.Error("Parse verified the token of the expired key")
+			}
+
+			// Rotating deletes the key files that no longer verify tokens
+			_, removed, err := RotateKey(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantRemoved := 0
+			if !tt.want {
+				wantRemoved = 1
+			}
+			if len(removed) != wantRemoved {
+				t.Errorf("RotateKey removed %v, want %d key files", removed, wantRemoved)
+			}
+		})
+	}
+}
+
+// TestJWKThumbprint checks the kid against the RFC 7638 section 3.1 RSA
+// example and the RFC 8037 appendix A.3 Ed25519 example.
+func TestJWKThumbprint(t *testing.T) {
+	b64 := base64.RawURLEncoding
+
+	t.Run("RSA", func(t *testing.T) {
+		const n = "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+		modulus, err := b64.DecodeString(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := &Key{Algorithm: AlgRS256, public: &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537}}
+
+		jwk, ok := key.PublicJWK()
+		if !ok {
+			t.Fatal("no JWK for an RSA key")
+		}
+		if jwk.N != n || jwk.E != "AQAB" {
+			t.Errorf("JWK n, e = %s, %s, want the RFC 7638 values", jwk.N, jwk.E)
+		}
+		if got, want := jwk.thumbprint(), "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+			t.Errorf("thumbprint = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Ed25519", func(t *testing.T) {
+		seed, err := b64.DecodeString("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := NewKey(ed25519.NewKeyFromSeed(seed), time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		const want = "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+		if key.ID != want {
+			t.Errorf("key ID = %s, want %s", key.ID, want)
+		}
+		set := NewKeyring(key).JWKS()
+		if len(set.Keys) != 1 || set.Keys[0].Kid != want || set.Keys[0].X != "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo" {
+			t.Errorf("JWKS = %+v, want the RFC 8037 key with kid %s", set.Keys, want)
+		}
+
+		token, err := jwt.Parse(signWith(t, key), func(*jwt.Token) (any, error) { return key.public, nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Header["kid"] != want {
+			t.Errorf("kid header = %v, want %s", token.Header["kid"], want)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package jwt
+
+import (
+	"container/list"
+	"io"
+	"sync"
+	"time"
+)
+
+// LayeredTokenBlacklist answers from an in-memory LRU cache in front of a
+// shared blacklist (Redis or the database). A blacklisted token stays
+// blacklisted, so that answer is cached until evicted. That a token is not
+// blacklisted is only cached for ttl, since another instance may blacklist
+// it meanwhile; with a zero ttl it is not cached.
+type LayeredTokenBlacklist struct {
+	store TokenBlacklist
+	size  int
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+}
+
+// cacheEntry is the cached answer for a token hash
+type cacheEntry struct {
+	hash        string
+	blacklisted bool
+	until       time.Time // zero for blacklisted tokens
+}
+
+// NewLayeredTokenBlacklist caches the answers of store for up to size tokens
+func NewLayeredTokenBlacklist(store TokenBlacklist, size int, ttl time.Duration) *LayeredTokenBlacklist {
+	return &LayeredTokenBlacklist{
+		store:   store,
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// BlacklistToken adds a token to the store and the cache
+func (b *LayeredTokenBlacklist) BlacklistToken(token string, expiresAt time.Time) error {
+	if err := b.store.BlacklistToken(token, expiresAt); err != nil {
+		return err
+	}
+	b.put(hashToken(token), true)
+	return nil
+}
+
+// IsTokenBlacklisted checks the cache, then the store. Store errors are not
+// cached.
+func (b *LayeredTokenBlacklist) IsTokenBlacklisted(token string) (bool, error) {
+	hash := hashToken(token)
+	if blacklisted, ok := b.get(hash); ok {
+		return blacklisted, nil
+	}
+
+	blacklisted, err := b.store.IsTokenBlacklisted(token)
+	if err != nil {
+		return false, err
+	}
+	if blacklisted || b.ttl > 0 {
+		b.put(hash, blacklisted)
+	}
+	return blacklisted, nil
+}
+
+// GetBlacklistedCount returns the number of blacklisted tokens in the store
+func (b *LayeredTokenBlacklist) GetBlacklistedCount() (int64, error) {
+	return b.store.GetBlacklistedCount()
+}
+
+// Close closes the store if it needs closing
+func (b *LayeredTokenBlacklist) Close() error {
+	if closer, ok := b.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (b *LayeredTokenBlacklist) get(hash string) (blacklisted, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	elem, ok := b.entries[hash]
+	if !ok {
+		return false, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.blacklisted && time.Now().After(entry.until) {
+		b.order.Remove(elem)
+		delete(b.entries, hash)
+		return false, false
+	}
+	b.order.MoveToFront(elem)
+	return entry.blacklisted, true
+}
+
+func (b *LayeredTokenBlacklist) put(hash string, blacklisted bool) {
+	entry := &cacheEntry{hash: hash, blacklisted: blacklisted}
+	if !blacklisted {
+		entry.until = time.Now().Add(b.ttl)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elem, ok := b.entries[hash]; ok {
+		// A lookup that raced with BlacklistToken must not undo it
+		if !elem.Value.(*cacheEntry).blacklisted {
+			elem.Value = entry
+		}
+		b.order.MoveToFront(elem)
+		return
+	}
+	b.entries[hash] = b.order.PushFront(entry)
+	for b.order.Len() > b.size {
+		oldest := b.order.Back()
+		b.order.Remove(oldest)
+		delete(b.entries, oldest.Value.(*cacheEntry).hash)
+	}
+}
@@ -0,0 +1,89 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryTokenBlacklist keeps blacklisted tokens in memory, for a single
+// instance or for tests. Blacklisted tokens are lost on restart and not
+// shared between instances.
+type MemoryTokenBlacklist struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time // token hash -> expiry
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewMemoryTokenBlacklist creates an in-memory token blacklist that drops
+// expired tokens every sweepInterval until Close is called
+func NewMemoryTokenBlacklist(sweepInterval time.Duration) *MemoryTokenBlacklist {
+	b := &MemoryTokenBlacklist{
+		tokens: make(map[string]time.Time),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go b.sweep(sweepInterval)
+	return b
+}
+
+func (b *MemoryTokenBlacklist) sweep(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case now := <-ticker.C:
+			b.mu.Lock()
+			for hash, expiresAt := range b.tokens {
+				if !expiresAt.After(now) {
+					delete(b.tokens, hash)
+				}
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// BlacklistToken adds a token to the blacklist until it expires
+func (b *MemoryTokenBlacklist) BlacklistToken(token string, expiresAt time.Time) error {
+	if !expiresAt.After(time.Now()) {
+		// Token already expired, no need to blacklist
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens[hashToken(token)] = expiresAt
+	return nil
+}
+
+// IsTokenBlacklisted checks if a token is in the blacklist
+func (b *MemoryTokenBlacklist) IsTokenBlacklisted(token string) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	expiresAt, ok := b.tokens[hashToken(token)]
+	return ok && expiresAt.After(time.Now()), nil
+}
+
+// GetBlacklistedCount returns the number of blacklisted tokens, including
+// expired ones not swept yet
+func (b *MemoryTokenBlacklist) GetBlacklistedCount() (int64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return int64(len(b.tokens)), nil
+}
+
+// Close stops sweeping expired tokens
+func (b *MemoryTokenBlacklist) Close() error {
+	select {
+	case <-b.stop:
+	default:
+		close(b.stop)
+	}
+	<-b.done
+	return nil
+}
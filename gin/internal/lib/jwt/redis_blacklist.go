@@ -2,8 +2,6 @@ package jwt
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -27,16 +25,10 @@ func NewRedisTokenBlacklist(client *redis.Client, prefix string) *RedisTokenBlac
 	}
 }
 
-// hashToken creates a SHA256 hash of the token for storage
-func (rtb *RedisTokenBlacklist) hashToken(token string) string {
-	hash := sha256.Sum256([]byte(token))
-	return hex.EncodeToString(hash[:])
-}
-
 // BlacklistToken adds a token to the Redis blacklist with TTL based on expiration
 func (rtb *RedisTokenBlacklist) BlacklistToken(token string, expiresAt time.Time) error {
 	ctx := context.Background()
-	tokenHash := rtb.hashToken(token)
+	tokenHash := hashToken(token)
 	key := rtb.prefix + tokenHash
 	
 	// Calculate TTL - how long until the token naturally expires
@@ -52,20 +44,19 @@ func (rtb *RedisTokenBlacklist) BlacklistToken(token string, expiresAt time.Time
 }
 
 // IsTokenBlacklisted checks if a token is in the Redis blacklist
-func (rtb *RedisTokenBlacklist) IsTokenBlacklisted(token string) bool {
+func (rtb *RedisTokenBlacklist) IsTokenBlacklisted(token string) (bool, error) {
 	ctx := context.Background()
-	tokenHash := rtb.hashToken(token)
+	tokenHash := hashToken(token)
 	key := rtb.prefix + tokenHash
 	
-	// Check if key exists in Redis
+	// Check if key exists in Redis; the service's FailurePolicy decides
+	// what an error means
 	exists, err := rtb.client.Exists(ctx, key).Result()
 	if err != nil {
-		// If Redis is down, we might want to handle this differently
-		// For now, we'll assume the token is not blacklisted
-		return false
+		return false, err
 	}
 	
-	return exists > 0
+	return exists > 0, nil
 }
 
 // GetBlacklistedCount returns the number of blacklisted tokens in Redis
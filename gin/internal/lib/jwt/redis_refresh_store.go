@@ -0,0 +1,198 @@
+package jwt
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// rotateScript replaces the current token of a family hash if KEYS[1]
+// holds ARGV[1], recording ARGV[4] as last seen. It returns the user ID on
+// success, 0 when the family is unknown or revoked and -1 on reuse, after
+// revoking the family.
+var rotateScript = redis.NewScript(`
+local fam = redis.call('HMGET', KEYS[1], 'jti', 'revoked', 'user_id')
+if not fam[1] or fam[2] == '1' then
+	return 0
+end
+if fam[1] ~= ARGV[1] then
+	redis.call('HSET', KEYS[1], 'revoked', '1')
+	return -1
+end
+redis.call('HSET', KEYS[1], 'jti', ARGV[2], 'expires', ARGV[3], 'last_seen', ARGV[4])
+redis.call('PEXPIREAT', KEYS[1], ARGV[3])
+return fam[3]
+`)
+
+// revokeScript revokes the family hash KEYS[1] if it still exists, so an
+// expired family is not recreated without a TTL.
+var revokeScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('HSET', KEYS[1], 'revoked', '1')
+end
+return 0
+`)
+
+// touchScript returns 1 if the family hash KEYS[1] is active, setting its
+// last_seen to ARGV[1] when that is ARGV[2] milliseconds or more later,
+// and 0 otherwise.
+var touchScript = redis.NewScript(`
+local fam = redis.call('HMGET', KEYS[1], 'revoked', 'last_seen')
+if not fam[1] or fam[1] == '1' then
+	return 0
+end
+if not fam[2] or tonumber(ARGV[1]) - tonumber(fam[2]) >= tonumber(ARGV[2]) then
+	redis.call('HSET', KEYS[1], 'last_seen', ARGV[1])
+end
+return 1
+`)
+
+// RedisRefreshTokenStore keeps refresh token families in Redis hashes that
+// expire with their last token, and the family IDs of each user in a set
+// that expires with the user's most recent family
+type RedisRefreshTokenStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisRefreshTokenStore creates a Redis-based refresh token store
+func NewRedisRefreshTokenStore(client *redis.Client, prefix string) *RedisRefreshTokenStore {
+	if prefix == "" {
+		prefix = "jwt_refresh:"
+	}
+
+	return &RedisRefreshTokenStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *RedisRefreshTokenStore) userKey(userID string) string {
+	return s.prefix + "user:" + userID
+}
+
+// Issue starts a token family
+func (s *RedisRefreshTokenStore) Issue(family, userID, jti string, expiresAt time.Time, client ClientInfo) error {
+	ctx := context.Background()
+	key := s.prefix + family
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"user_id", userID,
+			"jti", jti,
+			"revoked", "0",
+			"device", client.Device,
+			"ip", client.IP,
+			"user_agent", client.UserAgent,
+			"created", now,
+			"last_seen", now,
+			"expires", expiresAt.UnixMilli(),
+		)
+		pipe.ExpireAt(ctx, key, expiresAt)
+		pipe.SAdd(ctx, s.userKey(userID), family)
+		pipe.ExpireAt(ctx, s.userKey(userID), expiresAt)
+		return nil
+	})
+	return err
+}
+
+// Rotate replaces the current token of a family atomically
+func (s *RedisRefreshTokenStore) Rotate(family, jti, next string, expiresAt time.Time) error {
+	ctx := context.Background()
+
+	res, err := rotateScript.Run(ctx, s.client, []string{s.prefix + family},
+		jti, next, expiresAt.UnixMilli(), time.Now().UnixMilli()).Result()
+	if err != nil {
+		return err
+	}
+	switch v := res.(type) {
+	case string:
+		// The family now outlives the others of the user; so must the set
+		return s.client.ExpireAt(ctx, s.userKey(v), expiresAt).Err()
+	case int64:
+		if v == -1 {
+			return ErrRefreshTokenReused
+		}
+	}
+	return ErrRefreshTokenRevoked
+}
+
+// RevokeFamily marks a family as revoked. The hash is kept until it
+// expires so that replays of its tokens are still recognised.
+func (s *RedisRefreshTokenStore) RevokeFamily(family string) error {
+	ctx := context.Background()
+	return revokeScript.Run(ctx, s.client, []string{s.prefix + family}).Err()
+}
+
+// Touch reports whether a family is active and updates its last-seen time
+// at most once per sessionTouchInterval
+func (s *RedisRefreshTokenStore) Touch(family string) (bool, error) {
+	ctx := context.Background()
+
+	active, err := touchScript.Run(ctx, s.client, []string{s.prefix + family},
+		time.Now().UnixMilli(), sessionTouchInterval.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return active == 1, nil
+}
+
+// Sessions returns the active families of a user, most recently seen first.
+// Families that expired or were revoked are dropped from the user's set.
+func (s *RedisRefreshTokenStore) Sessions(userID string) ([]Session, error) {
+	ctx := context.Background()
+
+	families, err := s.client.SMembers(ctx, s.userKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(families))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, family := range families {
+			cmds[i] = pipe.HGetAll(ctx, s.prefix+family)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(families))
+	var stale []any
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 || fields["revoked"] == "1" {
+			stale = append(stale, families[i])
+			continue
+		}
+		sessions = append(sessions, Session{
+			ID:         families[i],
+			UserID:     fields["user_id"],
+			Device:     fields["device"],
+			IP:         fields["ip"],
+			UserAgent:  fields["user_agent"],
+			CreatedAt:  millisTime(fields["created"]),
+			LastSeenAt: millisTime(fields["last_seen"]),
+			ExpiresAt:  millisTime(fields["expires"]),
+		})
+	}
+	if len(stale) > 0 {
+		if err := s.client.SRem(ctx, s.userKey(userID), stale...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+// millisTime parses a Unix time in milliseconds as stored in the hashes
+func millisTime(ms string) time.Time {
+	n, _ := strconv.ParseInt(ms, 10, 64)
+	return time.UnixMilli(n)
+}
@@ -0,0 +1,27 @@
+package jwt
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis returns a client of an in-process Redis server
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRedisRefreshTokenStoreRotate(t *testing.T) {
+	testRotate(t, func(t *testing.T) RefreshTokenStore {
+		return NewRedisRefreshTokenStore(newTestRedis(t), "")
+	})
+}
+
+func TestRedisRefreshTokenStoreConcurrentRotate(t *testing.T) {
+	testConcurrentRotate(t, NewRedisRefreshTokenStore(newTestRedis(t), ""))
+}
@@ -0,0 +1,48 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisValidAfterStore keeps the valid-after time of each user in a key that
+// expires with the last token it rejects
+type RedisValidAfterStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisValidAfterStore creates a Redis-based valid-after store
+func NewRedisValidAfterStore(client *redis.Client, prefix string) *RedisValidAfterStore {
+	if prefix == "" {
+		prefix = "jwt_valid_after:"
+	}
+
+	return &RedisValidAfterStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// SetValidAfter records the valid-after time of a user
+func (s *RedisValidAfterStore) SetValidAfter(userID string, t, expiresAt time.Time) error {
+	ctx := context.Background()
+	return s.client.Set(ctx, s.prefix+userID, t.UnixMilli(), time.Until(expiresAt)).Err()
+}
+
+// ValidAfter returns the valid-after time of a user, or the zero time
+func (s *RedisValidAfterStore) ValidAfter(userID string) (time.Time, error) {
+	ctx := context.Background()
+
+	ms, err := s.client.Get(ctx, s.prefix+userID).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
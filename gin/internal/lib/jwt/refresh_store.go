@@ -0,0 +1,75 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrRefreshTokenRevoked is returned for a refresh token whose family
+	// was revoked, has expired or is unknown.
+	ErrRefreshTokenRevoked = errors.New("refresh token has been revoked")
+	// ErrRefreshTokenReused is returned when a refresh token that was
+	// already exchanged is presented again; its family is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrSessionRevoked is returned for an access token whose session was
+	// revoked or has expired.
+	ErrSessionRevoked = errors.New("session has been revoked")
+	// ErrSessionNotFound is returned when revoking a session that is not an
+	// active session of the user.
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// sessionTouchInterval is how often the last-seen time of a session is
+// updated, so that authenticated requests do not all write to the store.
+const sessionTouchInterval = time.Minute
+
+// ClientInfo describes the client that logged in.
+type ClientInfo struct {
+	Device    string
+	IP        string
+	UserAgent string
+}
+
+// Session is a login of a user on a device: a refresh token family and the
+// access tokens issued with it, which carry its ID as the sid claim.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// RefreshTokenStore records the current refresh token of each token family.
+// A family starts at login, and every refresh replaces its token, so each
+// refresh token can be used once. Presenting a replaced token means it was
+// stolen (or the client replayed it), and the whole family is revoked so
+// neither party can keep refreshing. The families of a user are the
+// sessions that user can list and revoke.
+type RefreshTokenStore interface {
+	// Issue starts family with jti as its current token.
+	Issue(family, userID, jti string, expiresAt time.Time, client ClientInfo) error
+	// Rotate replaces jti, the current token of family, with next. It
+	// returns ErrRefreshTokenReused, and revokes the family, when jti is
+	// not the current token, and ErrRefreshTokenRevoked when the family no
+	// longer exists.
+	Rotate(family, jti, next string, expiresAt time.Time) error
+	// RevokeFamily stops every token of family from being refreshed.
+	RevokeFamily(family string) error
+	// Touch reports whether family is active and records that it was seen.
+	Touch(family string) (bool, error)
+	// Sessions returns the active families of userID.
+	Sessions(userID string) ([]Session, error)
+}
+
+// truncate shortens s to at most n bytes to fit its column.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
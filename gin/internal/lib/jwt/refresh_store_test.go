@@ -0,0 +1,191 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB returns a migrated SQLite database in a temporary directory.
+// SQLite allows one writer, so the pool is one connection; concurrent
+// callers queue on it like transactions on a server database.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&RefreshTokenFamily{}, &TokenValidAfter{}, &BlacklistedToken{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// testRotate runs the rotation cases against the stores newStore returns.
+// Every case starts a family "fam" whose current token is "t1".
+func testRotate(t *testing.T, newStore func(t *testing.T) RefreshTokenStore) {
+	rotate := func(from, to string) func(RefreshTokenStore) error {
+		return func(s RefreshTokenStore) error {
+			return s.Rotate("fam", from, to, time.Now().Add(time.Hour))
+		}
+	}
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time                     // of the family; an hour from now if zero
+		prepare   func(RefreshTokenStore) error // runs before rotating
+		jti       string                        // the token presented
+		want      error
+		// current is the token of the family afterwards, which must only
+		// rotate if the family is still active
+		current string
+		active  bool
+	}{
+		{
+			name:    "current token rotates",
+			jti:     "t1",
+			current: "t2",
+			active:  true,
+		},
+		{
+			name:    "rotated token rotates again",
+			prepare: rotate("t1", "t0"),
+			jti:     "t0",
+			current: "t2",
+			active:  true,
+		},
+		{
+			name:    "replayed token revokes the family",
+			prepare: rotate("t1", "t0"),
+			jti:     "t1",
+			want:    ErrRefreshTokenReused,
+			current: "t0",
+		},
+		{
+			name:    "unknown token revokes the family",
+			jti:     "forged",
+			want:    ErrRefreshTokenReused,
+			current: "t1",
+		},
+		{
+			name:    "revoked family",
+			prepare: func(s RefreshTokenStore) error { return s.RevokeFamily("fam") },
+			jti:     "t1",
+			want:    ErrRefreshTokenRevoked,
+			current: "t1",
+		},
+		{
+			name:      "expired family",
+			expiresAt: time.Now().Add(-time.Minute),
+			jti:       "t1",
+			want:      ErrRefreshTokenRevoked,
+			current:   "t1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(t)
+			expiresAt := tt.expiresAt
+			if expiresAt.IsZero() {
+				expiresAt = time.Now().Add(time.Hour)
+			}
+			if err := s.Issue("fam", "user", "t1", expiresAt, ClientInfo{Device: "test"}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.prepare != nil {
+				if err := tt.prepare(s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := s.Rotate("fam", tt.jti, "t2", time.Now().Add(time.Hour))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Rotate(%q) = %v, want %v", tt.jti, err, tt.want)
+			}
+
+			active, err := s.Touch("fam")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if active != tt.active {
+				t.Errorf("Touch = %v, want %v", active, tt.active)
+			}
+			err = s.Rotate("fam", tt.current, "t3", time.Now().Add(time.Hour))
+			if tt.active && err != nil {
+				t.Errorf("Rotate(%q) of the active family = %v", tt.current, err)
+			}
+			if !tt.active && !errors.Is(err, ErrRefreshTokenRevoked) {
+				t.Errorf("Rotate(%q) of the inactive family = %v, want %v", tt.current, err, ErrRefreshTokenRevoked)
+			}
+		})
+	}
+}
+
+// testConcurrentRotate presents the same token from several goroutines at
+// once: exactly one rotation succeeds. The first other one is reuse and
+// revokes the family, so the rest find it revoked.
+func testConcurrentRotate(t *testing.T, s RefreshTokenStore) {
+	const attempts = 8
+	for round := 0; round < 10; round++ {
+		family := fmt.Sprintf("fam%d", round)
+		if err := s.Issue(family, "user", "t1", time.Now().Add(time.Hour), ClientInfo{}); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		errs := make([]error, attempts)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				errs[i] = s.Rotate(family, "t1", "next", time.Now().Add(time.Hour))
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		succeeded, reused := 0, 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, ErrRefreshTokenReused):
+				reused++
+			case !errors.Is(err, ErrRefreshTokenRevoked):
+				t.Fatalf("Rotate = %v, want nil, %v or %v", err, ErrRefreshTokenReused, ErrRefreshTokenRevoked)
+			}
+		}
+		if succeeded != 1 || reused == 0 {
+			t.Fatalf("%d of %d concurrent rotations succeeded and %d were reuse, want 1 and at least 1", succeeded, attempts, reused)
+		}
+	}
+}
+
+func TestDatabaseRefreshTokenStoreRotate(t *testing.T) {
+	testRotate(t, func(t *testing.T) RefreshTokenStore {
+		return NewDatabaseRefreshTokenStore(newTestDB(t))
+	})
+}
+
+func TestDatabaseRefreshTokenStoreConcurrentRotate(t *testing.T) {
+	testConcurrentRotate(t, NewDatabaseRefreshTokenStore(newTestDB(t)))
+}
@@ -0,0 +1,33 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTokenRevoked is returned for a token issued before, or in the same
+// millisecond as, the revocation of the tokens of its user.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// ValidAfterStore records, per user, the time up to which tokens issued to
+// the user are rejected. Moving it to now revokes every token of the user at
+// once, which the blacklist cannot do since it only knows single tokens.
+// Times are kept with millisecond precision, that of the iat_ms claim.
+type ValidAfterStore interface {
+	// SetValidAfter rejects the tokens of userID issued up to t. The record
+	// may be dropped after expiresAt, once those tokens have expired anyway.
+	SetValidAfter(userID string, t, expiresAt time.Time) error
+	// ValidAfter returns the time set for userID, or the zero time.
+	ValidAfter(userID string) (time.Time, error)
+}
+
+// issuedBy reports whether claims were issued at or before t, in
+// milliseconds. Tokens without the iat_ms claim only have the seconds of
+// iat, and are treated as issued by t if they were issued in the same
+// second.
+func issuedBy(claims *Claims, t time.Time) bool {
+	if claims.IssuedAtMillis != 0 {
+		return claims.IssuedAtMillis <= t.UnixMilli()
+	}
+	return claims.IssuedAt == nil || !claims.IssuedAt.Time.After(t.Truncate(time.Second))
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/SOG-web/goinit/gin/internal/domain/model"
+	"github.com/SOG-web/goinit/gin/internal/lib/id"
 	"gorm.io/gorm"
 )
 
@@ -18,6 +19,14 @@ type PasswordResetToken struct {
 	IsUsed    bool      `gorm:"default:false;index" json:"is_used"`
 }
 
+// BeforeCreate hook to set ID if not provided
+func (t *PasswordResetToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == "" {
+		t.ID = id.New()
+	}
+	return
+}
+
 // DatabaseService manages password reset tokens using database instead of Redis
 type DatabaseService struct {
 	db  *gorm.DB
@@ -26,9 +35,6 @@ type DatabaseService struct {
 
 // NewDatabaseService creates a new database-based password reset service
 func NewDatabaseService(db *gorm.DB, ttl time.Duration) *DatabaseService {
-	// Auto-migrate the table
-	db.AutoMigrate(&PasswordResetToken{})
-
 	return &DatabaseService{
 		db:  db,
 		ttl: ttl,
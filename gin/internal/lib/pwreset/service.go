@@ -4,15 +4,20 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	// goinit:if redis
 	"errors"
 	"fmt"
 	"os"
+	// goinit:end
 	"time"
 
+	// goinit:if redis
 	"github.com/redis/go-redis/v9"
+	// goinit:end
 	"gorm.io/gorm"
 )
 
+// goinit:if redis
 // Service manages password reset tokens using Redis with expiry and single-use semantics.
 type Service struct {
 	rdb    *redis.Client
@@ -69,6 +74,8 @@ func (s *Service) key(token string) string {
 	return fmt.Sprintf("%s%s", s.prefix, token)
 }
 
+// goinit:end
+
 func randomToken(n int) (string, error) {
 	b := make([]byte, n)
 	if _, err := rand.Read(b); err != nil {
@@ -86,13 +93,21 @@ type PasswordResetServiceInterface interface {
 }
 
 // NewPasswordResetServiceFactory creates password reset service based on environment configuration
-func NewPasswordResetServiceFactory(redisClient *redis.Client, db *gorm.DB, ttl time.Duration) PasswordResetServiceInterface {
+func NewPasswordResetServiceFactory(
+	// goinit:if redis
+	redisClient *redis.Client,
+	// goinit:end
+	db *gorm.DB,
+	ttl time.Duration,
+) PasswordResetServiceInterface {
+	// goinit:if redis
 	// Check environment variable to choose implementation
 	useDatabase := os.Getenv("USE_DATABASE_PWRESET") == "true"
 
-	if useDatabase {
-		return NewDatabaseService(db, ttl)
+	if !useDatabase {
+		return NewService(redisClient, ttl)
 	}
+	// goinit:end
 
-	return NewService(redisClient, ttl)
+	return NewDatabaseService(db, ttl)
 }
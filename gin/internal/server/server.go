@@ -1,7 +1,11 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 
@@ -12,21 +16,93 @@ import (
 type Server struct {
 	cfg    config.Config
 	engine *gin.Engine
+	http   *http.Server
+
+	// connClosers close long-lived connections before the HTTP drain
+	connClosers []func()
+	// closers release resources after the HTTP server has stopped, in order
+	closers []closer
+}
+
+type closer struct {
+	name  string
+	close func(ctx context.Context) error
 }
 
 func New(cfg config.Config, deps router.Dependencies) *Server {
 	if cfg.RunMode != "" {
 		gin.SetMode(cfg.RunMode)
 	}
-	r := router.New(deps)
-	return &Server{cfg: cfg, engine: r}
+
+	s := &Server{cfg: cfg}
+	deps.OnShutdown = func(close func()) {
+		s.connClosers = append(s.connClosers, close)
+	}
+	s.engine = router.New(deps)
+	s.http = &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.Port),
+		Handler:           s.engine,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		ReadHeaderTimeout: cfg.ServerReadTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+	}
+	return s
+}
+
+// OnShutdown registers a resource to release once the HTTP server has
+// stopped. Resources are released in registration order.
+func (s *Server) OnShutdown(name string, close func(ctx context.Context) error) {
+	s.closers = append(s.closers, closer{name: name, close: close})
+}
+
+// Run serves HTTP until the server fails or ctx is cancelled, in which case
+// it shuts down gracefully. A server that fails, for example because its port
+// is taken, still releases the registered resources.
+func (s *Server) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", s.http.Addr)
+		errCh <- s.http.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		slog.Error("http server failed, shutting down", "err", err)
+		return errors.Join(err, s.Shutdown())
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", "timeout", s.cfg.ShutdownTimeout)
+	return s.Shutdown()
 }
 
-func (s *Server) Run() error {
-	if s.cfg.RunMode != "debug" {
-		gin.SetMode(s.cfg.RunMode)
+// Shutdown stops the server within the configured shutdown timeout: it closes
+// WebSocket and SSE connections, waits for in-flight requests and then
+// releases the registered resources. Every step runs even if an earlier one
+// failed; the errors are joined.
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, close := range s.connClosers {
+		close()
 	}
+	if err := s.http.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	}
+	slog.Info("http server stopped")
 
-	addr := fmt.Sprintf(":%s", s.cfg.Port)
-	return s.engine.Run(addr)
+	for _, c := range s.closers {
+		if err := c.close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		slog.Info("closed", "name", c.name)
+	}
+	return errors.Join(errs...)
 }
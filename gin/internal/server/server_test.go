@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SOG-web/goinit/gin/config"
+)
+
+func TestRunReleasesResourcesWhenListenFails(t *testing.T) {
+	// Take the port so ListenAndServe fails
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	s := &Server{
+		cfg:  config.Config{ShutdownTimeout: time.Second},
+		http: &http.Server{Addr: ln.Addr().String()},
+	}
+	closed := false
+	s.OnShutdown("test", func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	if err := s.Run(context.Background()); err == nil {
+		t.Fatal("Run succeeded on a port in use")
+	}
+	if !closed {
+		t.Error("Run did not release the resources after the server failed")
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// The template's dependencies are pinned so generation is reproducible and
+// works without network access. Regenerate them after changing the template's
+// imports or goinit's own requirements.
+//
+//go:generate go run ./tools/pinmod
+
+//go:embed gomod
+var pinnedModFS embed.FS
+
+// generateOptions control how the generated module's dependencies are resolved and checked.
+type generateOptions struct {
+	Offline    bool   // never touch the network
+	SkipTidy   bool   // keep the pinned go.mod and go.sum as written
+	SkipVerify bool   // do not build, vet and test the generated project
+	GoProxy    string // local GOPROXY directory or module cache used by --offline
+}
+
+// writePinnedModule writes the embedded go.mod (with the project's module path) and go.sum.
+func writePinnedModule(projectPath string, config ProjectConfig) error {
+	if err := renderFile("go.mod", mustReadPinned("gomod/go.mod.tmpl"), filepath.Join(projectPath, "go.mod"), config); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(projectPath, "go.sum"), []byte(mustReadPinned("gomod/go.sum")), 0644)
+}
+
+// mergePinnedModule adds the requirements of the pinned go.mod in newDir that
+// the project's go.mod lacks or requires at an older version, along with the
+// go.sum lines of the pinned modules, so template code that imports a new
+// dependency builds after an upgrade. Modules the project replaces are left
+// alone. It returns the requirements it changed as module@version.
+func mergePinnedModule(projectPath, newDir string) ([]string, error) {
+	goModPath := filepath.Join(projectPath, "go.mod")
+	data, ok, err := readOptional(goModPath)
+	if err != nil || !ok {
+		return nil, err
+	}
+	project, err := modfile.Parse(goModPath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	pinnedPath := filepath.Join(newDir, "go.mod")
+	pinnedData, err := os.ReadFile(pinnedPath)
+	if err != nil {
+		return nil, err
+	}
+	pinned, err := modfile.Parse(pinnedPath, pinnedData, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	required := make(map[string]string, len(project.Require))
+	for _, r := range project.Require {
+		required[r.Mod.Path] = r.Mod.Version
+	}
+	replaced := make(map[string]bool, len(project.Replace))
+	for _, r := range project.Replace {
+		replaced[r.Old.Path] = true
+	}
+
+	var changed []string
+	for _, r := range pinned.Require {
+		current, ok := required[r.Mod.Path]
+		switch {
+		case replaced[r.Mod.Path]:
+			continue
+		case !ok:
+			project.AddNewRequire(r.Mod.Path, r.Mod.Version, r.Indirect)
+		case semver.Compare(current, r.Mod.Version) < 0:
+			if err := project.AddRequire(r.Mod.Path, r.Mod.Version); err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		changed = append(changed, r.Mod.Path+"@"+r.Mod.Version)
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	project.Cleanup()
+	out, err := project.Format()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(goModPath, out, 0644); err != nil {
+		return nil, err
+	}
+	return changed, mergeGoSum(filepath.Join(projectPath, "go.sum"), filepath.Join(newDir, "go.sum"))
+}
+
+// mergeGoSum appends the lines of the go.sum at src that dst is missing.
+func mergeGoSum(dst, src string) error {
+	current, _, err := readOptional(dst)
+	if err != nil {
+		return err
+	}
+	pinned, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	have := make(map[string]bool)
+	for _, line := range strings.Split(string(current), "\n") {
+		have[line] = true
+	}
+	out := current
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	for _, line := range strings.Split(string(pinned), "\n") {
+		if line != "" && !have[line] {
+			out = append(out, line+"\n"...)
+		}
+	}
+	return os.WriteFile(dst, out, 0644)
+}
+
+func mustReadPinned(name string) string {
+	data, err := pinnedModFS.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// tidyModule runs go mod tidy the way opts asks for. In offline mode the
+// module cache or the local proxy is the only source of modules, and a failure
+// aborts generation like any other: --skip-tidy is the way to keep the pinned
+// files untidied.
+func tidyModule(projectPath string, opts generateOptions) error {
+	if opts.SkipTidy {
+		fmt.Println("⏭️  Skipping go mod tidy; go.mod and go.sum are the pinned template versions")
+		return nil
+	}
+	if !opts.Offline {
+		return runGoModTidy(projectPath, nil)
+	}
+
+	env, err := opts.goEnv()
+	if err != nil {
+		return err
+	}
+	if err := runGoModTidy(projectPath, env); err != nil {
+		return fmt.Errorf("offline: %w\nFill the module cache with go mod download, pass --goproxy, or pass --skip-tidy to keep the pinned go.mod and go.sum", err)
+	}
+	return nil
+}
+
+// offlineGoEnv returns the environment that keeps the go tool off the network.
+func offlineGoEnv(goproxy string) ([]string, error) {
+	env := []string{
+		"GOFLAGS=-mod=mod",
+		"GOTOOLCHAIN=local",
+		"GOSUMDB=off", // go.sum is pinned; there is no checksum database to ask
+		"GOPROXY=off", // module cache only
+	}
+	if goproxy == "" {
+		return env, nil
+	}
+
+	dir, err := filepath.Abs(goproxy)
+	if err != nil {
+		return nil, err
+	}
+	// A module cache ($GOMODCACHE) serves its downloads in proxy layout under cache/download
+	if info, err := os.Stat(filepath.Join(dir, "cache", "download")); err == nil && info.IsDir() {
+		dir = filepath.Join(dir, "cache", "download")
+	} else if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("GOPROXY directory %s does not exist", goproxy)
+	}
+
+	url := filepath.ToSlash(dir)
+	if !strings.HasPrefix(url, "/") {
+		url = "/" + url
+	}
+	env[len(env)-1] = "GOPROXY=file://" + url
+	return env, nil
+}
+
+// goEnv returns the environment the go tool runs with for opts.
+func (opts generateOptions) goEnv() ([]string, error) {
+	if !opts.Offline {
+		return nil, nil
+	}
+	return offlineGoEnv(opts.GoProxy)
+}
@@ -10,86 +10,145 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"golang.org/x/mod/modfile"
 )
 
-//go:embed gin
+//go:embed gin templates
 var templateFS embed.FS
 
 const version = "v0.2.3"
 
 type ProjectConfig struct {
-	ProjectName    string
-	ModuleName     string
-	DatabaseDriver string
-	Port           string
+	ProjectName    string     `yaml:"name" json:"name"`
+	ModuleName     string     `yaml:"module" json:"module"`
+	DatabaseDriver string     `yaml:"db" json:"db"`
+	Port           string     `yaml:"port" json:"port"`
+	OutputDir      string     `yaml:"out" json:"-"`
+	Template       string     `yaml:"template" json:"template"`
+	Features       FeatureSet `yaml:"features" json:"features"`
 }
 
 func main() {
-	// Check for version flag
-	if len(os.Args) > 1 && os.Args[1] == "--version" {
+	os.Exit(run(os.Args[1:], os.Stdin))
+}
+
+// run executes goinit with the given arguments and returns the process exit code.
+func run(args []string, stdin io.Reader) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "generate":
+			return runGenerate(args[1:])
+		case "upgrade":
+			return runUpgrade(args[1:])
+		case "verify":
+			return runVerify(args[1:])
+		}
+	}
+
+	opts, err := parseFlags(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		fmt.Fprintln(os.Stderr, "Run 'goinit --help' for usage.")
+		return exitUsage
+	}
+
+	if opts.ShowVersion {
 		fmt.Printf("GoInit %s\n", version)
-		return
+		return exitOK
 	}
 
-	// Check for help flag
-	if len(os.Args) > 1 && (os.Args[1] == "--help" || os.Args[1] == "-h") {
+	if opts.ShowHelp {
 		printHelp()
-		return
+		return exitOK
 	}
 
 	fmt.Println("🚀 GoInit - Go Gin API Generator")
 	fmt.Printf("Version: %s\n", version)
 	fmt.Println("=================================")
 
-	// Get project configuration
-	config := getProjectConfig()
+	// Collect configuration: spec file first, then flags, then prompts for whatever is missing
+	var config ProjectConfig
+	if opts.ConfigPath != "" {
+		spec, err := loadSpecFile(opts.ConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return exitInvalidConfig
+		}
+		config = spec
+	}
+	config = mergeProjectConfig(config, opts.Flags)
+
+	if !opts.Yes {
+		config = getProjectConfig(bufio.NewReader(stdin), config)
+	}
+	config = applyDefaults(config)
 
-	// Create project directory
-	projectPath := config.ProjectName
-	if err := os.MkdirAll(projectPath, 0755); err != nil {
-		fmt.Printf("❌ Error creating project directory: %v\n", err)
-		return
+	if err := validateProjectConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid project configuration:\n%v\n", err)
+		return exitInvalidConfig
 	}
 
-	fmt.Printf("📁 Creating project in: %s\n", projectPath)
+	projectPath := config.OutputDir
+
+	if opts.DryRun {
+		if err := previewProject(projectPath, config, opts.Force, opts.Merge); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error previewing project: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
 
-	// Copy template files
-	if err := copyEmbeddedTemplate(projectPath, config); err != nil {
-		fmt.Printf("❌ Error copying template: %v\n", err)
-		return
+	if err := checkOutputDir(projectPath, opts.Force, opts.Merge); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		fmt.Fprintln(os.Stderr, "Run with --dry-run to preview the changes.")
+		return exitFailure
 	}
 
-	// Generate project files with templating
-	if err := generateTemplatedFiles(projectPath, config); err != nil {
-		fmt.Printf("❌ Error generating templated files: %v\n", err)
-		return
+	fmt.Printf("📁 Creating project in: %s\n", projectPath)
+
+	// Generate into a staging directory so a failure never leaves a half-generated project
+	stagingPath, err := newStagingDir(projectPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error creating project directory: %v\n", err)
+		return exitFailure
 	}
+	defer os.RemoveAll(stagingPath)
 
-	// Initialize Go module if not present
-	if err := initializeGoModule(projectPath, config); err != nil {
-		fmt.Printf("❌ Error initializing Go module: %v\n", err)
-		return
+	if opts.Merge {
+		if err := seedStagingDir(stagingPath, projectPath); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error reading existing go.mod: %v\n", err)
+			return exitFailure
+		}
 	}
 
-	// Replace module references in all Go files
-	if err := replaceModuleReferences(projectPath, config); err != nil {
-		fmt.Printf("❌ Error replacing module references: %v\n", err)
-		return
+	if err := generateProject(stagingPath, config, opts.Generate); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error generating project: %v\n", err)
+		fmt.Fprintf(os.Stderr, "📁 %s was left untouched\n", projectPath)
+		if !opts.Generate.SkipVerify {
+			fmt.Fprintln(os.Stderr, "Run with --skip-verify to keep a project that does not build.")
+		}
+		return exitFailure
 	}
 
-	// Run go mod tidy to download dependencies
-	if err := runGoModTidy(projectPath); err != nil {
-		fmt.Printf("❌ Error running go mod tidy: %v\n", err)
-		return
+	if err := commitProject(stagingPath, projectPath, opts.Force); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error moving project into place: %v\n", err)
+		return exitFailure
 	}
 
+	tmpl, _ := lookupTemplate(config.Template)
 	fmt.Println("\n✅ Project generated successfully!")
 	fmt.Printf("📁 Project location: %s\n", projectPath)
 	fmt.Println("\n🚀 Next steps:")
 	fmt.Printf("  cd %s\n", projectPath)
-	fmt.Println("  go run cmd/api/main.go")
-	fmt.Println("\n📚 API Documentation: http://localhost:8080/docs")
+	if tmpl.Run != "" {
+		fmt.Printf("  %s\n", tmpl.Run)
+	}
+	if tmpl.Docs {
+		fmt.Printf("\n📚 API Documentation: http://localhost:%s/docs\n", config.Port)
+	}
 	fmt.Println("🎉 Happy coding!")
+	return exitOK
 }
 
 func printHelp() {
@@ -98,91 +157,144 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("USAGE:")
 	fmt.Println("  goinit [flags]")
+	fmt.Println("  goinit generate resource <Name> [field:type ...] [--dir <dir>] [--force]")
+	fmt.Println("  goinit upgrade [--dir <dir>] [--rej]")
+	fmt.Println("  goinit verify [--dir <dir>] [--offline [--goproxy <dir>]]")
 	fmt.Println()
 	fmt.Println("FLAGS:")
-	fmt.Println("  --version, -v    Show version information")
-	fmt.Println("  --help, -h       Show this help message")
+	fmt.Println("  --name <name>      Project name")
+	fmt.Println("  --module <path>    Go module path (default: github.com/user/<name>)")
+	fmt.Println("  --db <driver>      Database driver: sqlite, mysql or postgres (default: sqlite)")
+	fmt.Println("  --port <port>      HTTP port (default: 8080)")
+	fmt.Println("  --out <dir>        Output directory (default: <name>)")
+	fmt.Println("  --template <name>  Project template or local template directory (default: gin-full)")
+	fmt.Println("  --features <list>  Comma separated features to include, gin-full only (default: all)")
+	fmt.Println("  --config <file>    Read project settings from a YAML spec file")
+	fmt.Println("  --offline          Never use the network: tidy the pinned go.mod/go.sum from the module cache")
+	fmt.Println("  --goproxy <dir>    With --offline, tidy from a local GOPROXY directory or module cache instead")
+	fmt.Println("  --skip-tidy        Do not run go mod tidy; keep the pinned go.mod and go.sum")
+	fmt.Println("  --skip-verify      Do not build, vet and test the generated project")
+	fmt.Println("  --dry-run          Print the file tree and diffs against the output directory; write nothing")
+	fmt.Println("  --force            Replace the contents of a non-empty output directory, keeping .git, .hg and .svn")
+	fmt.Println("  --merge            Write into a non-empty output directory, keeping other files")
+	fmt.Println("  --yes, -y          Accept defaults for anything not given; never prompt")
+	fmt.Println("  --version, -v      Show version information")
+	fmt.Println("  --help, -h         Show this help message")
+	fmt.Println()
+	fmt.Println("SPEC FILE:")
+	fmt.Println("  name: myapi")
+	fmt.Println("  module: github.com/acme/myapi")
+	fmt.Println("  db: postgres")
+	fmt.Println("  port: 8080")
+	fmt.Println("  out: ./myapi")
+	fmt.Println("  template: gin-full")
+	fmt.Println("  features: [auth, email, storage:s3, realtime:ws]")
+	fmt.Println()
+	fmt.Println("  Flags override values from the spec file.")
+	fmt.Println()
+	fmt.Println("TEMPLATES:")
+	for _, t := range projectTemplates {
+		fmt.Printf("  %-15s %s\n", t.Name, t.Description)
+	}
+	fmt.Println("  <directory>     A local house template; its go.mod module path is rewritten")
+	fmt.Println("                  to --module and .tmpl files are rendered like goinit's own")
+	fmt.Println()
+	fmt.Println("FEATURES:")
+	fmt.Println("  auth            Users, JWT authentication and profile routes (always enabled)")
+	fmt.Println("  admin           Admin routes for managing users")
+	fmt.Println("  email           SMTP email service with a local log fallback")
+	fmt.Println("  pwreset         Password reset by email (requires email)")
+	fmt.Println("  redis           Redis-backed token blacklist and reset tokens (database otherwise)")
+	fmt.Println("  storage[:local] Local file uploads")
+	fmt.Println("  storage:s3      S3-compatible file uploads")
+	fmt.Println("  realtime        Both realtime:sse and realtime:ws")
+	fmt.Println("  realtime:sse    Server-Sent Events endpoints")
+	fmt.Println("  realtime:ws     WebSocket endpoint")
+	fmt.Println()
+	fmt.Println("GENERATE RESOURCE:")
+	fmt.Println("  Run inside a generated project to add a CRUD resource across every layer")
+	fmt.Println("  (domain, data, service, DTOs, handler, routes) plus its SQL migrations, and")
+	fmt.Println("  wire it into the DI container and the router.")
+	fmt.Println()
+	fmt.Println("  Field types: string, text, int, int32, int64, uint, float32, float64, bool, time")
+	fmt.Println("  References:  <name>_id:ref:<resource>, e.g. owner_id:ref:user")
+	fmt.Println()
+	fmt.Println("  --dir <dir>        Project directory (default: .)")
+	fmt.Println("  --force            Overwrite files that already exist")
+	fmt.Println()
+	fmt.Println("VERIFY:")
+	fmt.Println("  Generation ends by running go build, go vet and go test in the new project;")
+	fmt.Println("  failures are reported with file locations and nothing is written.")
+	fmt.Println("  'goinit verify' runs the same checks on an existing project.")
+	fmt.Println()
+	fmt.Println("UPGRADE:")
+	fmt.Println("  Generated projects record the template version and a hash of every file in")
+	fmt.Println("  .goinit/. 'goinit upgrade' merges the changes between that template and the")
+	fmt.Println("  one in this goinit into the project, keeping local edits. Overlapping edits")
+	fmt.Println("  get conflict markers, or .rej files with --rej. Keep .goinit under version control.")
+	fmt.Println("  Modules the new template requires are added to go.mod and go.sum; run")
+	fmt.Println("  'go mod tidy' afterwards to drop the ones the project does not use.")
+	fmt.Println()
+	fmt.Println("  --dir <dir>        Project directory (default: .)")
+	fmt.Println("  --rej              Write rejected template hunks to <file>.rej instead of markers")
+	fmt.Println()
+	fmt.Println("EXIT CODES:")
+	fmt.Println("  0  Success")
+	fmt.Println("  1  Generation failed")
+	fmt.Println("  2  Invalid command line")
+	fmt.Println("  3  Invalid spec file or project configuration")
 	fmt.Println()
 	fmt.Println("DESCRIPTION:")
-	fmt.Println("  Interactive CLI tool to generate production-ready Go API projects")
+	fmt.Println("  CLI tool to generate production-ready Go API projects")
 	fmt.Println("  built with the Gin framework, featuring authentication, real-time")
 	fmt.Println("  communication, and comprehensive API documentation.")
 	fmt.Println()
 	fmt.Println("EXAMPLES:")
-	fmt.Println("  goinit              # Generate a new project interactively")
-	fmt.Println("  goinit --version    # Show version")
-	fmt.Println("  goinit --help       # Show this help")
+	fmt.Println("  goinit                                          # Generate a new project interactively")
+	fmt.Println("  goinit --name shop --db postgres --yes          # Generate without prompting")
+	fmt.Println("  goinit --name shop --features auth,email --yes  # Generate with selected features only")
+	fmt.Println("  goinit --name jobs --template worker --yes      # Generate a background worker")
+	fmt.Println("  goinit --name svc --template ./house --yes      # Generate from a local template")
+	fmt.Println("  goinit --config goinit.yaml --yes               # Generate from a spec file")
+	fmt.Println("  goinit --name shop --yes --dry-run              # Preview without writing")
+	fmt.Println("  goinit --name shop --yes --offline              # Generate on an air-gapped machine")
+	fmt.Println("  goinit generate resource Product name:string price:float64 owner_id:ref:user")
+	fmt.Println("  goinit --version                                # Show version")
+	fmt.Println("  goinit --help                                   # Show this help")
 }
 
-func getProjectConfig() ProjectConfig {
-	reader := bufio.NewReader(os.Stdin)
-
-	fmt.Print("Enter project name: ")
-	projectName, _ := reader.ReadString('\n')
-	projectName = strings.TrimSpace(projectName)
-
-	fmt.Print("Enter Go module name (e.g., github.com/username/project): ")
-	moduleName, _ := reader.ReadString('\n')
-	moduleName = strings.TrimSpace(moduleName)
-
-	if moduleName == "" {
-		moduleName = fmt.Sprintf("github.com/user/%s", projectName)
+// getProjectConfig prompts for every field of config that is still empty.
+func getProjectConfig(reader *bufio.Reader, config ProjectConfig) ProjectConfig {
+	if config.ProjectName == "" {
+		config.ProjectName = promptLine(reader, "Enter project name: ", "")
 	}
 
-	fmt.Print("Choose database driver (sqlite/mysql/postgres) [sqlite]: ")
-	dbDriver, _ := reader.ReadString('\n')
-	dbDriver = strings.TrimSpace(dbDriver)
-	if dbDriver == "" {
-		dbDriver = "sqlite"
+	if config.ModuleName == "" {
+		config.ModuleName = promptLine(reader, "Enter Go module name (e.g., github.com/username/project): ", "")
 	}
 
-	fmt.Print("Enter port [8080]: ")
-	port, _ := reader.ReadString('\n')
-	port = strings.TrimSpace(port)
-	if port == "" {
-		port = "8080"
+	if config.DatabaseDriver == "" {
+		config.DatabaseDriver = promptLine(reader, "Choose database driver (sqlite/mysql/postgres) [sqlite]: ", "sqlite")
 	}
 
-	return ProjectConfig{
-		ProjectName:    projectName,
-		ModuleName:     moduleName,
-		DatabaseDriver: dbDriver,
-		Port:           port,
+	if config.Port == "" {
+		config.Port = promptLine(reader, "Enter port [8080]: ", "8080")
 	}
-}
-
-func copyEmbeddedTemplate(dst string, config ProjectConfig) error {
-	return fs.WalkDir(templateFS, "gin", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip certain directories and files
-		if shouldSkipEmbedded(path, d) {
-			if d.IsDir() {
-				return fs.SkipDir
-			}
-			return nil
-		}
 
-		// Get relative path from gin/
-		relPath, err := filepath.Rel("gin", path)
-		if err != nil {
-			return err
-		}
-
-		dstPath := filepath.Join(dst, relPath)
+	if config.Template == "" {
+		config.Template = promptLine(reader, fmt.Sprintf("Template (%s or a directory) [%s]: ", strings.Join(templateNames(), "/"), defaultTemplate), defaultTemplate)
+	}
 
-		if d.IsDir() {
-			return os.MkdirAll(dstPath, 0755)
-		}
+	if tmpl, err := lookupTemplate(config.Template); err == nil && tmpl.Features && len(config.Features) == 0 {
+		config.Features = parseFeatureList(promptLine(reader, "Features, comma separated [all]: ", strings.Join(knownFeatures, ",")))
+	}
 
-		return copyEmbeddedFile(path, dstPath)
-	})
+	return config
 }
 
-func copyEmbeddedFile(src, dst string) error {
-	srcFile, err := templateFS.Open(src)
+// copyTemplateFile copies the file src of fsys to dst.
+func copyTemplateFile(fsys fs.FS, src, dst string) error {
+	srcFile, err := fsys.Open(src)
 	if err != nil {
 		return err
 	}
@@ -247,66 +359,107 @@ func shouldSkipEmbedded(path string, d fs.DirEntry) bool {
 func generateTemplatedFiles(projectPath string, config ProjectConfig) error {
 	// Note: go.mod will be created by initializeGoModule function
 
-	// Generate .env file
+	// Generate .env and .env.example files
 	if err := generateEnvFile(projectPath, config); err != nil {
 		return err
 	}
 
-	// Generate README
-	if err := generateReadme(projectPath, config); err != nil {
-		return err
-	}
-
 	return nil
 }
 
-func generateEnvFile(projectPath string, config ProjectConfig) error {
-	envContent := fmt.Sprintf(`# Server Configuration
-PORT=%s
-PUBLIC_HOST=http://localhost:%s
+// envFileTemplate is rendered with envFileData into the generated .env and
+// .env.example files.
+const envFileTemplate = `# Application
+APP_NAME={{.ProjectName}}
+
+# Server Configuration
+PORT={{.Port}}
+PUBLIC_HOST=http://localhost:{{.Port}}
+SERVER_READ_TIMEOUT=15s
+SERVER_WRITE_TIMEOUT=30s
+SERVER_IDLE_TIMEOUT=60s
+# Time allowed to drain requests, the email queue and connections on SIGTERM
+SHUTDOWN_TIMEOUT=20s
 
 # Database Configuration
-DB_DRIVER=%s
-DB_USER=root
-DB_PASSWORD=password
-DB_NAME=%s
+DB_DRIVER={{.DatabaseDriver}}
+{{- if eq .DatabaseDriver "sqlite"}}
+DB_NAME={{.SnakeName}}.db
+{{- else}}
 DB_HOST=127.0.0.1
-DB_PORT=3306
+DB_PORT={{.DatabasePort}}
+DB_USER={{.SnakeName}}_user
+DB_PASSWORD={{if not .Example}}{{.SnakeName}}_password{{end}}
+DB_NAME={{.SnakeName}}_db
+{{- if eq .DatabaseDriver "postgres"}}
+DB_SSLMODE=disable
+{{- end}}
+{{- end}}
+DB_PARAMS=
+# DB_DSN replaces every DB_* setting above with a complete connection string
+# DB_DSN=
+# Create tables from the GORM models at boot instead of migrations (development only)
+DB_AUTO_MIGRATE=false
+{{- if .Features.Has "auth"}}
 
 # Session Configuration
-SESSION_SECRET=dev-session-secret-change-me-in-production
-SESSION_NAME=hor_session
+SESSION_SECRET={{if not .Example}}dev-session-secret-change-me-in-production{{end}}
+SESSION_NAME={{.SnakeName}}_session
 SESSION_SECURE=false
 SESSION_DOMAIN=
 SESSION_MAX_AGE=86400
 
 # JWT Configuration
-JWT_SECRET=dev-jwt-secret-change-me-in-production
-USE_DATABASE_JWT=false
+JWT_SECRET={{if not .Example}}dev-jwt-secret-change-me-in-production{{end}}
+JWT_PREVIOUS_SECRETS=
+JWT_ALGORITHM=HS256
+JWT_KEY_DIR=./keys
+JWT_KEY_GRACE_PERIOD=720h
+USE_DATABASE_JWT={{if .Features.Has "redis"}}false{{else}}true{{end}}
+# Where logged out tokens are kept: redis, database or memory (single instance
+# only); empty follows USE_DATABASE_JWT
+JWT_BLACKLIST=
+# Cache blacklist answers for this many tokens in memory (0 is off); a logout on
+# another instance can take JWT_BLACKLIST_CACHE_TTL to apply
+JWT_BLACKLIST_CACHE_SIZE=0
+JWT_BLACKLIST_CACHE_TTL=5s
+# Accept tokens while the token stores are down instead of answering 503
+JWT_FAIL_OPEN=false
+{{- end}}
+{{- if .Features.Has "email"}}
 
 # Email Configuration
 EMAIL_HOST=smtp.gmail.com
 EMAIL_PORT=587
 EMAIL_USERNAME=
 EMAIL_PASSWORD=
-EMAIL_FROM=noreply@%s.com
+EMAIL_FROM=noreply@{{.Slug}}.com
 USE_LOCAL_EMAIL=true
 EMAIL_LOG_PATH=./logs/emails.log
+{{- end}}
+{{- if .Features.Has "redis"}}
 
 # Redis Configuration
 REDIS_ADDR=localhost:6379
 REDIS_PASSWORD=
 REDIS_DB=0
+{{- end}}
+{{- if .Features.Has "pwreset"}}
 
 # Password Reset Configuration
-USE_DATABASE_PWRESET=false
+USE_DATABASE_PWRESET={{if .Features.Has "redis"}}false{{else}}true{{end}}
+{{- end}}
+{{- if .Features.Has "storage"}}
 
 # Storage Configuration
-STORAGE_BACKEND=local
+STORAGE_BACKEND={{if .Features.Has "storage:local"}}local{{else}}s3{{end}}
+{{- if .Features.Has "storage:local"}}
 UPLOAD_BASE_DIR=./uploads
 UPLOAD_PUBLIC_BASE_URL=/uploads
+{{- end}}
+{{- if .Features.Has "storage:s3"}}
 
-# S3 Configuration (if using S3 storage)
+# S3 Configuration{{if .Features.Has "storage:local"}} (if using S3 storage){{end}}
 S3_ENDPOINT=
 S3_REGION=us-east-1
 S3_BUCKET=
@@ -315,167 +468,31 @@ S3_SECRET_ACCESS_KEY=
 S3_USE_SSL=true
 S3_FORCE_PATH_STYLE=false
 S3_PUBLIC_BASE_URL=
+{{- end}}
+{{- end}}
 
 # Logging
 LOG_LEVEL=info
 LOG_FILE=logs/app.log
 LOG_FILE_ENABLED=false
 GIN_MODE=debug
-`, config.Port, config.Port, config.DatabaseDriver, config.ProjectName, config.ProjectName)
+`
 
-	return os.WriteFile(filepath.Join(projectPath, ".env"), []byte(envContent), 0644)
+// envFileData is the ProjectConfig plus whether the example file is being
+// rendered; the example leaves secrets empty so it can be committed.
+type envFileData struct {
+	ProjectConfig
+	Example bool
 }
 
-func generateReadme(projectPath string, config ProjectConfig) error {
-	readmeContent := fmt.Sprintf(`# %s
-
-A Go API server built with Gin framework, featuring authentication, user management, real-time communication, and more.
-
-## Features
-
-- 🔐 **Authentication & Authorization**
-  - JWT-based authentication
-  - Session management
-  - Password reset functionality
-  - Admin role management
-
-- 👥 **User Management**
-  - User registration and login
-  - Profile management
-  - User roles and permissions
-  - Admin user controls
-
-- 📡 **Real-time Communication**
-  - Server-Sent Events (SSE)
-  - WebSocket support
-  - Real-time notifications
-
-- 🗄️ **Database Support**
-  - SQLite (default)
-  - MySQL
-  - PostgreSQL
-
-- 📧 **Email Integration**
-  - SMTP email sending
-  - Local email logging for development
-  - Password reset emails
-
-- ☁️ **Storage**
-  - Local file storage
-  - S3-compatible storage
-
-- 📚 **API Documentation**
-  - Swagger/OpenAPI documentation
-  - Auto-generated docs
-
-## Quick Start
-
-1. **Install dependencies:**
-   ` + "```" + `bash
-   go mod tidy
-   ` + "```" + `
-
-2. **Set up environment:**
-   ` + "```" + `bash
-   cp .env.example .env
-   # Edit .env with your configuration
-   ` + "```" + `
-
-3. **Run the server:**
-   ` + "```" + `bash
-   go run cmd/api/main.go
-   ` + "```" + `
-
-The server will start on http://localhost:%s
-
-## API Endpoints
-
-### Authentication
-- ` + "`POST /api/auth/register/`" + ` - User registration
-- ` + "`POST /api/auth/login/`" + ` - User login
-- ` + "`POST /api/auth/logout/`" + ` - User logout
-- ` + "`POST /api/auth/change-password/`" + ` - Change password
-
-### User Management
-- ` + "`GET /api/user/profile/`" + ` - Get user profile
-- ` + "`PUT /api/user/profile/`" + ` - Update user profile
-
-### Real-time
-- ` + "`GET /api/sse/events`" + ` - Server-Sent Events stream
-- ` + "`GET /api/ws/connect`" + ` - WebSocket connection
-
-### Admin
-- ` + "`GET /api/admin/users/`" + ` - List all users
-- ` + "`GET /api/admin/stats/`" + ` - User statistics
-
-## Configuration
-
-The application uses environment variables for configuration. Copy .env.example to .env and modify as needed.
-
-Key configuration options:
-- ` + "`DB_DRIVER`" + `: Database driver (sqlite/mysql/postgres)
-- ` + "`JWT_SECRET`" + `: JWT signing secret
-- ` + "`SESSION_SECRET`" + `: Session signing secret
-- ` + "`EMAIL_*`" + `: Email configuration
-- ` + "`REDIS_*`" + `: Redis configuration
-
-## Development
-
-### Project Structure
-` + "```" + `
-├── cmd/api/           # Application entry point
-├── config/            # Configuration management
-├── internal/
-│   ├── app/          # Application logic
-│   ├── data/         # Data layer (repositories)
-│   ├── domain/       # Domain models
-│   ├── lib/          # Shared libraries
-│   └── server/       # Server setup
-├── api/              # API handlers and routes
-└── docs/             # API documentation
-` + "```" + `
-
-### Building
-
-` + "```" + `bash
-go build -o bin/server cmd/api/main.go
-` + "```" + `
-
-### Testing
-
-` + "```" + `bash
-go test ./...
-` + "```" + `
-
-## Docker Support
-
-Build and run with Docker:
-
-` + "```" + `bash
-docker-compose up --build
-` + "```" + `
-
-## API Documentation
-
-Once the server is running, visit http://localhost:%s/docs/ for Swagger documentation.
-
-## Contributing
-
-1. Fork the repository
-2. Create a feature branch
-3. Make your changes
-4. Add tests
-5. Submit a pull request
-
-## License
-
-This project is licensed under the MIT License.
-`, config.ProjectName, config.Port, config.Port)
-
-	return os.WriteFile(filepath.Join(projectPath, "README.md"), []byte(readmeContent), 0644)
+func generateEnvFile(projectPath string, config ProjectConfig) error {
+	if err := renderFile("env", envFileTemplate, filepath.Join(projectPath, ".env"), envFileData{ProjectConfig: config}); err != nil {
+		return err
+	}
+	return renderFile("env.example", envFileTemplate, filepath.Join(projectPath, ".env.example"), envFileData{ProjectConfig: config, Example: true})
 }
 
-// initializeGoModule checks if go.mod exists and runs go mod init if not
+// initializeGoModule checks if go.mod exists and writes the pinned go.mod and go.sum if not
 func initializeGoModule(projectPath string, config ProjectConfig) error {
 	goModPath := filepath.Join(projectPath, "go.mod")
 
@@ -487,21 +504,18 @@ func initializeGoModule(projectPath string, config ProjectConfig) error {
 
 	fmt.Printf("📦 Initializing Go module: %s\n", config.ModuleName)
 
-	// Run go mod init
-	cmd := exec.Command("go", "mod", "init", config.ModuleName)
-	cmd.Dir = projectPath
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to initialize Go module: %v\nOutput: %s", err, string(output))
+	// Write the pinned template dependencies instead of resolving them from the network
+	if err := writePinnedModule(projectPath, config); err != nil {
+		return fmt.Errorf("failed to initialize Go module: %v", err)
 	}
 
 	fmt.Println("✅ Go module initialized successfully")
 	return nil
 }
 
-// replaceModuleReferences replaces all module references in Go files
-func replaceModuleReferences(projectPath string, config ProjectConfig) error {
+// replaceModuleReferences replaces the template module paths in all Go files
+// and in the go.mod copied from a local template
+func replaceModuleReferences(projectPath string, tmpl projectTemplate, config ProjectConfig) error {
 	fmt.Println("🔄 Replacing module references in Go files...")
 
 	// Find all Go files in the project
@@ -522,11 +536,19 @@ func replaceModuleReferences(projectPath string, config ProjectConfig) error {
 
 	// Replace module references in each Go file
 	replacements := 0
-	for _, filePath := range goFiles {
-		if replaced, err := replaceInFile(filePath, "github.com/SOG-web/goinit/gin", config.ModuleName); err != nil {
-			return fmt.Errorf("failed to replace in file %s: %v", filePath, err)
-		} else if replaced {
-			replacements++
+	for _, layer := range tmpl.Layers {
+		if layer.Module == "" || layer.Module == config.ModuleName {
+			continue
+		}
+		for _, filePath := range goFiles {
+			if replaced, err := replaceImportPath(filePath, layer.Module, config.ModuleName); err != nil {
+				return fmt.Errorf("failed to replace in file %s: %v", filePath, err)
+			} else if replaced {
+				replacements++
+			}
+		}
+		if err := setModulePath(filepath.Join(projectPath, "go.mod"), layer.Module, config.ModuleName); err != nil {
+			return fmt.Errorf("failed to update go.mod: %v", err)
 		}
 	}
 
@@ -539,6 +561,44 @@ func replaceModuleReferences(projectPath string, config ProjectConfig) error {
 	return nil
 }
 
+// replaceImportPath rewrites the quoted import path oldPath and the paths of
+// its packages to newPath, leaving modules that merely share the prefix alone.
+func replaceImportPath(filePath, oldPath, newPath string) (bool, error) {
+	exact, err := replaceInFile(filePath, `"`+oldPath+`"`, `"`+newPath+`"`)
+	if err != nil {
+		return false, err
+	}
+	nested, err := replaceInFile(filePath, `"`+oldPath+`/`, `"`+newPath+`/`)
+	return exact || nested, err
+}
+
+// setModulePath changes the module path of the go.mod at path from oldPath to
+// newPath. A missing go.mod, or one for another module, is left alone.
+func setModulePath(path, oldPath, newPath string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	f, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return err
+	}
+	if f.Module == nil || f.Module.Mod.Path != oldPath {
+		return nil
+	}
+	if err := f.AddModuleStmt(newPath); err != nil {
+		return err
+	}
+	out, err := f.Format()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0644)
+}
+
 // replaceInFile replaces all occurrences of oldString with newString in the given file
 func replaceInFile(filePath, oldString, newString string) (bool, error) {
 	// Read the file
@@ -564,13 +624,17 @@ func replaceInFile(filePath, oldString, newString string) (bool, error) {
 	return true, nil
 }
 
-// runGoModTidy runs go mod tidy to download dependencies and update go.sum
-func runGoModTidy(projectPath string) error {
+// runGoModTidy runs go mod tidy to download dependencies and update go.sum.
+// env is added to the current environment.
+func runGoModTidy(projectPath string, env []string) error {
 	fmt.Println("📦 Running go mod tidy to download dependencies...")
 
 	// Run go mod tidy
 	cmd := exec.Command("go", "mod", "tidy")
 	cmd.Dir = projectPath
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
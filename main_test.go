@@ -0,0 +1,699 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestGeneratedProjectsBuild generates the template for every supported
+// database driver and checks that the result builds, vets and passes its
+// tests. It resolves modules from the local module cache only.
+func TestGeneratedProjectsBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates and builds full projects")
+	}
+
+	for _, driver := range supportedDatabaseDrivers {
+		t.Run(driver, func(t *testing.T) {
+			config := applyDefaults(ProjectConfig{
+				ProjectName:    "selftest-" + driver,
+				ModuleName:     "example.com/selftest/" + driver,
+				DatabaseDriver: driver,
+			})
+			if err := validateProjectConfig(config); err != nil {
+				t.Fatalf("invalid config: %v", err)
+			}
+
+			dir := filepath.Join(t.TempDir(), config.ProjectName)
+			if err := generateProject(dir, config, generateOptions{Offline: true}); err != nil {
+				t.Fatalf("generated %s project does not verify: %v", driver, err)
+			}
+
+			// The pruned wiring must be what diwire generates in the project
+			cmd := exec.Command("go", "run", "./cmd/diwire", "-check", "-dir", "internal/di")
+			cmd.Dir = dir
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("generated %s project has stale DI wiring: %v\n%s", driver, err, output)
+			}
+		})
+	}
+}
+
+// TestTemplatesBuild generates every other built-in template and checks that
+// it builds, vets and passes its tests.
+func TestTemplatesBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates and builds full projects")
+	}
+
+	for _, tmpl := range projectTemplates {
+		if tmpl.Name == defaultTemplate {
+			continue // covered by TestGeneratedProjectsBuild
+		}
+		t.Run(tmpl.Name, func(t *testing.T) {
+			config := applyDefaults(ProjectConfig{
+				ProjectName: "selftest-" + tmpl.Name,
+				ModuleName:  "example.com/selftest/" + tmpl.Name,
+				Template:    tmpl.Name,
+			})
+			if err := validateProjectConfig(config); err != nil {
+				t.Fatalf("invalid config: %v", err)
+			}
+
+			dir := filepath.Join(t.TempDir(), config.ProjectName)
+			if err := generateProject(dir, config, generateOptions{Offline: true}); err != nil {
+				t.Fatalf("generated %s project does not verify: %v", tmpl.Name, err)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateGitignore(t *testing.T) {
+	tests := []struct {
+		template string
+		keys     bool // whether the JWT key directory is ignored
+	}{
+		{template: "gin-full", keys: true},
+		{template: "gin-minimal"},
+		{template: "worker"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			config := applyDefaults(ProjectConfig{ProjectName: "shop", ModuleName: "example.com/shop", Template: tt.template})
+			dir := t.TempDir()
+			if err := renderTemplate(dir, config); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(string(content), "\n")
+			if got := slices.Contains(lines, "keys/"); got != tt.keys {
+				t.Errorf(".gitignore ignores keys/ = %v, want %v:\n%s", got, tt.keys, content)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "gitignore")); !os.IsNotExist(err) {
+				t.Errorf("gitignore generated under its template name (%v)", err)
+			}
+		})
+	}
+}
+
+// TestGenerateEnvFileTokenSettings checks that the env files of a generated
+// project list the token store settings only when auth is enabled
+func TestGenerateEnvFileTokenSettings(t *testing.T) {
+	settings := []string{"JWT_BLACKLIST=", "JWT_BLACKLIST_CACHE_SIZE=0", "JWT_BLACKLIST_CACHE_TTL=5s", "JWT_FAIL_OPEN=false"}
+
+	tests := []struct {
+		template string
+		want     bool
+	}{
+		{template: "gin-full", want: true},
+		{template: "gin-minimal", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			dir := t.TempDir()
+			config := applyDefaults(ProjectConfig{ProjectName: "shop", ModuleName: "example.com/shop", Template: tt.template})
+			if err := generateEnvFile(dir, config); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{".env", ".env.example"} {
+				content, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				lines := strings.Split(string(content), "\n")
+				for _, setting := range settings {
+					if got := slices.Contains(lines, setting); got != tt.want {
+						t.Errorf("%s lists %s = %v, want %v", name, setting, got, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestRenderTemplateSessionName checks that the swagger specs name the
+// session cookie that the generated env file sets
+func TestRenderTemplateSessionName(t *testing.T) {
+	config := applyDefaults(ProjectConfig{ProjectName: "My Shop", ModuleName: "example.com/shop"})
+	dir := t.TempDir()
+	if err := renderTemplate(dir, config); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "SESSION_NAME=" + config.SnakeName() + "_session"
+	env, err := os.ReadFile(filepath.Join(dir, ".env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(strings.Split(string(env), "\n"), want) {
+		t.Fatalf(".env does not set %s:\n%s", want, env)
+	}
+
+	specs := map[string]string{
+		"docs/swagger.json": `"name": "my_shop_session"`,
+		"docs/swagger.yaml": "name: my_shop_session",
+	}
+	for file, want := range specs {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), want) {
+			t.Errorf("%s does not name the session cookie with %s", file, want)
+		}
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string // rendered with the labels yours, base and theirs
+		conflict           bool
+	}{
+		{
+			name: "changes to different lines",
+			base: "a\nb\nc\n", ours: "A\nb\nc\n", theirs: "a\nb\nC\n",
+			want: "A\nb\nC\n",
+		},
+		{
+			name: "the same change on both sides",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nB\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "line deleted on one side",
+			base: "a\nb\nc\n", ours: "a\nc\n", theirs: "a\nb\nc\nd\n",
+			want: "a\nc\nd\n",
+		},
+		{
+			name: "different changes to the same line",
+			base: "a\nb\nc\n", ours: "a\nX\nc\n", theirs: "a\nY\nc\n",
+			want:     "a\n<<<<<<< yours\nX\n||||||| base\nb\n=======\nY\n>>>>>>> theirs\nc\n",
+			conflict: true,
+		},
+		{
+			name: "deleted upstream, modified locally",
+			base: "a\nb\nc\n", ours: "a\nX\nc\n", theirs: "a\nc\n",
+			want:     "a\n<<<<<<< yours\nX\n||||||| base\nb\n=======\n>>>>>>> theirs\nc\n",
+			conflict: true,
+		},
+		{
+			name: "added on both sides",
+			ours: "x\n", theirs: "y\n",
+			want:     "<<<<<<< yours\nx\n||||||| base\n=======\ny\n>>>>>>> theirs\n",
+			conflict: true,
+		},
+		{
+			name: "the same addition on both sides",
+			ours: "x\n", theirs: "x\n",
+			want: "x\n",
+		},
+		{
+			name: "CRLF line endings",
+			base: "a\r\nb\r\nc\r\n", ours: "A\r\nb\r\nc\r\n", theirs: "a\r\nb\r\nC\r\n",
+			want: "A\r\nb\r\nC\r\n",
+		},
+		{
+			name: "no final newline",
+			base: "a\nb\nc", ours: "A\nb\nc", theirs: "a\nb\nC",
+			want: "A\nb\nC",
+		},
+		{
+			name: "conflict on a last line without newline",
+			base: "a\nb", ours: "a\nX", theirs: "a\nY",
+			want:     "a\n<<<<<<< yours\nX\n||||||| base\nb\n=======\nY\n>>>>>>> theirs\n",
+			conflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := merge3(splitLines(tt.base), splitLines(tt.ours), splitLines(tt.theirs))
+			if got := hasConflicts(chunks); got != tt.conflict {
+				t.Errorf("hasConflicts = %v, want %v", got, tt.conflict)
+			}
+			if got := renderWithMarkers(chunks, "yours", "base", "theirs"); got != tt.want {
+				t.Errorf("merged:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeFile(t *testing.T) {
+	const rel = "internal/app/file.go"
+	conflict := func(ours, base, theirs string) string {
+		return "<<<<<<< yours\n" + ours + "||||||| goinit v0.1.0\n" + base + "=======\n" + theirs + ">>>>>>> goinit " + version + "\n"
+	}
+	crlf := func(s string) string { return strings.ReplaceAll(s, "\n", "\r\n") }
+
+	tests := []struct {
+		name string
+		// The file when the project was generated, in the project now and
+		// in the new template; "" when it does not exist
+		base, current, next string
+		noSnapshot          bool // the .goinit/base copy of base is missing
+		rej                 bool
+		status              string // prefix of the status line
+		conflict            bool
+		want                string // the project file afterwards; "" when removed
+	}{
+		{
+			name: "unchanged in the template",
+			base: "a\n", current: "A\n", next: "a\n",
+			want: "A\n",
+		},
+		{
+			name: "unmodified locally",
+			base: "a\n", current: "a\n", next: "b\n",
+			status: "✅ updated", want: "b\n",
+		},
+		{
+			name: "clean merge",
+			base: "a\nb\nc\n", current: "A\nb\nc\n", next: "a\nb\nC\n",
+			status: "🔀 merged", want: "A\nb\nC\n",
+		},
+		{
+			name: "conflict",
+			base: "a\nb\nc\n", current: "a\nX\nc\n", next: "a\nY\nc\n",
+			status: "⚠️  conflict", conflict: true,
+			want: "a\n" + conflict("X\n", "b\n", "Y\n") + "c\n",
+		},
+		{
+			name: "conflict with a reject file",
+			base: "a\nb\nc\n", current: "a\nX\nc\n", next: "a\nY\nc\n", rej: true,
+			status: "⚠️  conflict (see " + rel + ".rej)", conflict: true,
+			want: "a\nX\nc\n",
+		},
+		{
+			name: "deleted upstream, modified locally",
+			base: "a\n", current: "A\n",
+			status: "⏭️  kept", want: "A\n",
+		},
+		{
+			name: "deleted upstream, unmodified locally",
+			base: "a\n", current: "a\n",
+			status: "🗑️  removed",
+		},
+		{
+			name: "deleted locally",
+			base: "a\n", next: "b\n",
+			status: "⏭️  skipped",
+		},
+		{
+			name:    "added on both sides",
+			current: "x\n", next: "y\n",
+			status: "⚠️  conflict", conflict: true,
+			want: conflict("x\n", "", "y\n"),
+		},
+		{
+			name:    "added identically on both sides",
+			current: "x\n", next: "x\n",
+			want: "x\n",
+		},
+		{
+			name: "CRLF checkout, unmodified",
+			base: "a\nb\n", current: crlf("a\nb\n"), next: "a\nB\n",
+			status: "✅ updated", want: crlf("a\nB\n"),
+		},
+		{
+			name: "CRLF checkout, merged",
+			base: "a\nb\nc\n", current: crlf("A\nb\nc\n"), next: "a\nb\nC\n",
+			status: "🔀 merged", want: crlf("A\nb\nC\n"),
+		},
+		{
+			name: "missing base snapshot",
+			base: "a\nb\nc\n", current: "A\nb\nc\n", next: "a\nb\nC\n", noSnapshot: true,
+			status: "⚠️  conflict", conflict: true,
+			want: conflict("A\nb\nc\n", "", "a\nb\nC\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, newDir := t.TempDir(), t.TempDir()
+			manifest := projectManifest{TemplateVersion: "v0.1.0", Files: map[string]string{}}
+			write := func(dir, content string) {
+				if content == "" {
+					return
+				}
+				if err := writeFileAll(filepath.Join(dir, filepath.FromSlash(rel)), []byte(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.base != "" {
+				manifest.Files[rel] = hashContent([]byte(tt.base))
+				if !tt.noSnapshot {
+					write(filepath.Join(project, manifestDir, manifestBase), tt.base)
+				}
+			}
+			write(project, tt.current)
+			write(newDir, tt.next)
+
+			status, conflict, err := upgradeFile(project, newDir, manifest, rel, tt.rej)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(status, tt.status) || (tt.status == "") != (status == "") {
+				t.Errorf("status = %q, want %q", status, tt.status)
+			}
+			if conflict != tt.conflict {
+				t.Errorf("conflict = %v, want %v", conflict, tt.conflict)
+			}
+
+			got, err := os.ReadFile(filepath.Join(project, filepath.FromSlash(rel)))
+			switch {
+			case tt.want == "" && !os.IsNotExist(err):
+				t.Errorf("file exists (%v), want it removed", err)
+			case tt.want != "" && err != nil:
+				t.Fatal(err)
+			case string(got) != tt.want:
+				t.Errorf("file:\n%q\nwant:\n%q", got, tt.want)
+			}
+			if tt.rej {
+				if _, err := os.Stat(filepath.Join(project, filepath.FromSlash(rel)+".rej")); err != nil {
+					t.Errorf("reject file: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMergePinnedModule(t *testing.T) {
+	project, newDir := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(project, "go.mod"): `module example.com/shop
+
+go 1.25.0
+
+require (
+	example.com/local v1.0.0
+	github.com/gorilla/websocket v1.9.0
+	golang.org/x/crypto v0.1.0
+)
+
+replace github.com/redis/go-redis/v9 => ../go-redis
+`,
+		filepath.Join(project, "go.sum"): "example.com/local v1.0.0 h1:local=\n",
+		filepath.Join(newDir, "go.mod"): `module example.com/shop
+
+go 1.25.0
+
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/redis/go-redis/v9 v9.13.0
+	golang.org/x/crypto v0.39.0
+	github.com/gin-contrib/sse v1.1.0
+)
+
+require golang.org/x/text v0.26.0 // indirect
+`,
+		filepath.Join(newDir, "go.sum"): "github.com/gin-contrib/sse v1.1.0 h1:sse=\ngolang.org/x/text v0.26.0 h1:text=\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changed, err := mergePinnedModule(project, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"golang.org/x/crypto@v0.39.0", "github.com/gin-contrib/sse@v1.1.0", "golang.org/x/text@v0.26.0"}
+	if !slices.Equal(changed, want) {
+		t.Errorf("changed = %q, want %q", changed, want)
+	}
+
+	goMod, err := os.ReadFile(filepath.Join(project, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"example.com/local v1.0.0",              // only required by the project
+		"github.com/gorilla/websocket v1.9.0",   // newer than the template's
+		"golang.org/x/crypto v0.39.0",           // older than the template's
+		"github.com/gin-contrib/sse v1.1.0",     // new in the template
+		"golang.org/x/text v0.26.0 // indirect", // new and indirect
+	} {
+		if !strings.Contains(string(goMod), line) {
+			t.Errorf("go.mod does not require %s:\n%s", line, goMod)
+		}
+	}
+	if strings.Contains(string(goMod), "go-redis/v9 v9") {
+		t.Errorf("go.mod requires the replaced go-redis:\n%s", goMod)
+	}
+
+	goSum, err := os.ReadFile(filepath.Join(project, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "example.com/local v1.0.0 h1:local=\ngithub.com/gin-contrib/sse v1.1.0 h1:sse=\ngolang.org/x/text v0.26.0 h1:text=\n"; string(goSum) != want {
+		t.Errorf("go.sum =\n%s\nwant\n%s", goSum, want)
+	}
+
+	if changed, err := mergePinnedModule(project, newDir); err != nil || len(changed) != 0 {
+		t.Errorf("second merge = %q, %v, want nothing to change", changed, err)
+	}
+}
+
+// TestUpgradeAddsDependency upgrades a project to a template that imports a
+// module the project does not require, and checks that it builds without
+// the go tool changing go.mod.
+func TestUpgradeAddsDependency(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates and builds a full project")
+	}
+
+	config := applyDefaults(ProjectConfig{ProjectName: "selftest-upgrade", ModuleName: "example.com/selftest/upgrade", Features: []string{"auth"}})
+	dir := filepath.Join(t.TempDir(), config.ProjectName)
+	if err := generateProject(dir, config, generateOptions{Offline: true, SkipVerify: true}); err != nil {
+		t.Fatal(err)
+	}
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(goMod), "github.com/redis/go-redis") {
+		t.Fatal("project without redis requires go-redis")
+	}
+
+	// The new template's code uses redis
+	manifest, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Config.Features = append(manifest.Config.Features, "redis")
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifestDir, manifestFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := runUpgrade([]string{"--dir", dir}); code != exitOK {
+		t.Fatalf("runUpgrade = %d, want %d", code, exitOK)
+	}
+
+	cmd := exec.Command("go", "build", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=readonly", "GOPROXY=off", "GOTOOLCHAIN=local")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("upgraded project does not build: %v\n%s", err, output)
+	}
+}
+
+func TestForceKeepsVCSDirs(t *testing.T) {
+	path, staging := t.TempDir(), t.TempDir()
+	for _, f := range []string{".git/HEAD", ".hg/store/data", "old.txt", "internal/old.go", "main.go"} {
+		if err := writeFileAll(filepath.Join(path, filepath.FromSlash(f)), []byte("old\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writeFileAll(filepath.Join(staging, "main.go"), []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, kept, err := previewRemovals(path, staging, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(removed, ","), "internal/old.go,old.txt"; got != want {
+		t.Errorf("previewed removals = %s, want %s", got, want)
+	}
+	if got, want := strings.Join(kept, ","), ".git,.hg"; got != want {
+		t.Errorf("previewed kept directories = %s, want %s", got, want)
+	}
+
+	if err := commitProject(staging, path, true); err != nil {
+		t.Fatal(err)
+	}
+	for f, want := range map[string]string{".git/HEAD": "old\n", ".hg/store/data": "old\n", "main.go": "new\n", "old.txt": "", "internal/old.go": ""} {
+		got, err := os.ReadFile(filepath.Join(path, filepath.FromSlash(f)))
+		switch {
+		case want == "" && !os.IsNotExist(err):
+			t.Errorf("%s exists (%v), want it removed", f, err)
+		case want != "" && string(got) != want:
+			t.Errorf("%s = %q, %v, want %q", f, got, err, want)
+		}
+	}
+}
+
+// TestTidyModuleOfflineFailure checks that a go mod tidy that cannot find a
+// module offline fails generation instead of keeping the untidied files
+func TestTidyModuleOfflineFailure(t *testing.T) {
+	path := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.21\n",
+		"main.go": "package main\n\nimport _ \"example.com/missing\"\n\nfunc main() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(path, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tidyModule(path, generateOptions{Offline: true, GoProxy: t.TempDir()}); err == nil {
+		t.Error("tidyModule succeeded without the module, want an error")
+	}
+	if err := tidyModule(path, generateOptions{Offline: true, SkipTidy: true}); err != nil {
+		t.Errorf("tidyModule with --skip-tidy = %v", err)
+	}
+}
+
+func TestFeatureSetNormalized(t *testing.T) {
+	tests := []struct {
+		features string
+		want     string
+	}{
+		{features: "", want: "auth"},
+		{features: "email, admin", want: "auth,admin,email"},
+		{features: "realtime", want: "auth,realtime:sse,realtime:ws"},
+		{features: "storage,storage:s3", want: "auth,storage:local,storage:s3"},
+		{features: "Redis,redis,auth", want: "auth,redis"},
+		{features: "bogus,email", want: "auth,email,bogus"},
+	}
+	for _, tt := range tests {
+		if got := parseFeatureList(tt.features).normalized().String(); got != tt.want {
+			t.Errorf("normalized(%q) = %s, want %s", tt.features, got, tt.want)
+		}
+	}
+}
+
+func TestFeatureSetValidate(t *testing.T) {
+	tests := []struct {
+		features string
+		want     []string // substrings of the errors, in order
+	}{
+		{features: "auth,email,pwreset,storage:s3,realtime:ws"},
+		{features: "auth,storage"}, // a bare group name is accepted once expanded
+		{features: "auth,bogus", want: []string{`unknown feature "bogus"`}},
+		{features: "auth,pwreset", want: []string{`feature "pwreset" requires "email"`}},
+		{features: "auth,storage:ftp,pwreset", want: []string{`unknown feature "storage:ftp"`, `"pwreset" requires "email"`}},
+	}
+	for _, tt := range tests {
+		errs := parseFeatureList(tt.features).normalized().validate()
+		if len(errs) != len(tt.want) {
+			t.Errorf("validate(%q) = %v, want %d errors", tt.features, errs, len(tt.want))
+			continue
+		}
+		for i, err := range errs {
+			if !strings.Contains(err.Error(), tt.want[i]) {
+				t.Errorf("validate(%q) error %d = %v, want it to mention %s", tt.features, i, err, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestApplyFeatureMarkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		features string
+		want     string
+		err      string // substring of the error; "" for none
+	}{
+		{
+			name:     "enabled feature",
+			src:      "a\n// goinit:if email\nb\n// goinit:end\nc\n",
+			features: "auth,email",
+			want:     "a\nb\nc\n",
+		},
+		{
+			name:     "disabled feature",
+			src:      "a\n// goinit:if email\nb\n// goinit:end\nc\n",
+			features: "auth",
+			want:     "a\nc\n",
+		},
+		{
+			name:     "indented markers",
+			src:      "func f() {\n\t// goinit:if redis\n\tr()\n\t// goinit:end\n}\n",
+			features: "auth",
+			want:     "func f() {\n}\n",
+		},
+		{
+			name:     "group name",
+			src:      "// goinit:if storage\ns\n// goinit:end\n",
+			features: "auth,storage:s3",
+			want:     "s\n",
+		},
+		{
+			name:     "nested blocks",
+			src:      "// goinit:if email\ne\n// goinit:if pwreset\np\n// goinit:end\ne2\n// goinit:end\n",
+			features: "auth,email",
+			want:     "e\ne2\n",
+		},
+		{
+			name:     "nested in a disabled block",
+			src:      "// goinit:if admin\na\n// goinit:if email\ne\n// goinit:end\n// goinit:end\nz\n",
+			features: "auth,email",
+			want:     "z\n",
+		},
+		{
+			name:     "unknown feature",
+			src:      "a\n// goinit:if bogus\nb\n// goinit:end\n",
+			features: "auth",
+			err:      `line 2: unknown feature "bogus"`,
+		},
+		{
+			name:     "end without if",
+			src:      "a\n// goinit:end\n",
+			features: "auth",
+			err:      "line 2: goinit:end without goinit:if",
+		},
+		{
+			name:     "if without end",
+			src:      "// goinit:if email\n// goinit:if redis\nb\n// goinit:end\n",
+			features: "auth",
+			err:      "1 goinit:if marker(s) without goinit:end",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyFeatureMarkers([]byte(tt.src), parseFeatureList(tt.features))
+			switch {
+			case tt.err != "":
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("applyFeatureMarkers = %v, want an error mentioning %q", err, tt.err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case string(got) != tt.want:
+				t.Errorf("applyFeatureMarkers =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
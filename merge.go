@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mergeChunk is one region of a three-way merge. Resolved chunks carry their
+// final lines; conflicting chunks keep all three versions.
+type mergeChunk struct {
+	conflict bool
+	lines    []string // resolved lines
+	base     []string
+	ours     []string
+	theirs   []string
+}
+
+// splitLines splits s into lines that keep their trailing newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// merge3 merges the changes from base to ours and from base to theirs.
+// Regions changed on only one side, or changed identically on both, are taken
+// as is; regions changed differently on both sides are reported as conflicts.
+func merge3(base, ours, theirs []string) []mergeChunk {
+	matchOurs := matchLines(base, ours)
+	matchTheirs := matchLines(base, theirs)
+
+	var chunks []mergeChunk
+	o, a, b := 0, 0, 0
+	for {
+		// Find the next base line that is unchanged on both sides
+		i := o
+		for i < len(base) && (matchOurs[i] < 0 || matchTheirs[i] < 0) {
+			i++
+		}
+		if i == len(base) {
+			return appendMergeChunk(chunks, base[o:], ours[a:], theirs[b:])
+		}
+		if i == o && matchOurs[i] == a && matchTheirs[i] == b {
+			chunks = appendResolved(chunks, base[o:o+1])
+			o, a, b = o+1, a+1, b+1
+			continue
+		}
+		chunks = appendMergeChunk(chunks, base[o:i], ours[a:matchOurs[i]], theirs[b:matchTheirs[i]])
+		o, a, b = i, matchOurs[i], matchTheirs[i]
+	}
+}
+
+func appendMergeChunk(chunks []mergeChunk, base, ours, theirs []string) []mergeChunk {
+	switch {
+	case len(base) == 0 && len(ours) == 0 && len(theirs) == 0:
+		return chunks
+	case equalLines(ours, base):
+		return appendResolved(chunks, theirs)
+	case equalLines(theirs, base), equalLines(ours, theirs):
+		return appendResolved(chunks, ours)
+	default:
+		return append(chunks, mergeChunk{conflict: true, base: base, ours: ours, theirs: theirs})
+	}
+}
+
+func appendResolved(chunks []mergeChunk, lines []string) []mergeChunk {
+	if n := len(chunks); n > 0 && !chunks[n-1].conflict {
+		chunks[n-1].lines = append(chunks[n-1].lines, lines...)
+		return chunks
+	}
+	return append(chunks, mergeChunk{lines: append([]string(nil), lines...)})
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasConflicts reports whether any chunk is a conflict.
+func hasConflicts(chunks []mergeChunk) bool {
+	for _, c := range chunks {
+		if c.conflict {
+			return true
+		}
+	}
+	return false
+}
+
+// renderWithMarkers writes the merge result with diff3-style conflict markers.
+func renderWithMarkers(chunks []mergeChunk, oursLabel, baseLabel, theirsLabel string) string {
+	var b strings.Builder
+	for _, c := range chunks {
+		if !c.conflict {
+			writeLines(&b, c.lines)
+			continue
+		}
+		b.WriteString("<<<<<<< " + oursLabel + "\n")
+		writeConflictLines(&b, c.ours)
+		b.WriteString("||||||| " + baseLabel + "\n")
+		writeConflictLines(&b, c.base)
+		b.WriteString("=======\n")
+		writeConflictLines(&b, c.theirs)
+		b.WriteString(">>>>>>> " + theirsLabel + "\n")
+	}
+	return b.String()
+}
+
+// renderWithRejects keeps our side of every conflict in the merged text and
+// returns the template changes that could not be applied as a reject file.
+func renderWithRejects(chunks []mergeChunk, path, baseLabel, theirsLabel string) (merged, rejects string) {
+	var out, rej strings.Builder
+	fmt.Fprintf(&rej, "--- %s (%s)\n+++ %s (%s)\n", path, baseLabel, path, theirsLabel)
+
+	line := 1 // current line in the merged output
+	for _, c := range chunks {
+		if !c.conflict {
+			writeLines(&out, c.lines)
+			line += len(c.lines)
+			continue
+		}
+		fmt.Fprintf(&rej, "@@ -%d,%d +%d,%d @@\n", line, len(c.base), line, len(c.theirs))
+		for _, l := range c.base {
+			rej.WriteString("-" + withNewline(l))
+		}
+		for _, l := range c.theirs {
+			rej.WriteString("+" + withNewline(l))
+		}
+		writeLines(&out, c.ours)
+		line += len(c.ours)
+	}
+	return out.String(), rej.String()
+}
+
+func writeLines(b *strings.Builder, lines []string) {
+	for _, l := range lines {
+		b.WriteString(l)
+	}
+}
+
+// writeConflictLines writes the lines of one side of a conflict, ending the
+// last one with a newline so the next marker starts its own line.
+func writeConflictLines(b *strings.Builder, lines []string) {
+	for _, l := range lines {
+		b.WriteString(withNewline(l))
+	}
+}
+
+// withNewline terminates a final line that has no newline, so markers start on their own line.
+func withNewline(line string) string {
+	if strings.HasSuffix(line, "\n") {
+		return line
+	}
+	return line + "\n"
+}
+
+// matchLines returns, for every line of a, the index of the line of b it is
+// matched with in a longest common subsequence, or -1 if it was changed.
+func matchLines(a, b []string) []int {
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+
+	// Common prefix and suffix are matched directly; only the middle is diffed
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		match[pre] = pre
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		match[len(a)-1-suf] = len(b) - 1 - suf
+		suf++
+	}
+
+	myersMatches(a[pre:len(a)-suf], b[pre:len(b)-suf], func(i, j int) {
+		match[pre+i] = pre + j
+	})
+	return match
+}
+
+// myersMatches runs Myers' O(ND) diff and calls onMatch for every pair of equal lines
+// on the shortest edit path.
+func myersMatches(a, b []string, onMatch func(i, j int)) {
+	n, m := len(a), len(b)
+	max := n + m
+	if n == 0 || m == 0 {
+		return
+	}
+
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				backtrackMyers(trace, offset, n, m, onMatch)
+				return
+			}
+		}
+	}
+}
+
+func backtrackMyers(trace [][]int, offset, x, y int, onMatch func(i, j int)) {
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			onMatch(x, y)
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		onMatch(x, y)
+	}
+}
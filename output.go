@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// generateProject renders the template into projectPath, initializes the Go
+// module, records the template manifest, tidies dependencies and verifies
+// that the result builds.
+func generateProject(projectPath string, config ProjectConfig, opts generateOptions) error {
+	if err := renderTemplate(projectPath, config); err != nil {
+		return err
+	}
+	if err := initializeGoModule(projectPath, config); err != nil {
+		return err
+	}
+	if err := writeManifest(projectPath, config); err != nil {
+		return fmt.Errorf("failed to write template manifest: %v", err)
+	}
+	if err := tidyModule(projectPath, opts); err != nil {
+		return err
+	}
+	if opts.SkipVerify {
+		return nil
+	}
+
+	env, err := opts.goEnv()
+	if err != nil {
+		return err
+	}
+	return verifyProject(projectPath, env)
+}
+
+// checkOutputDir refuses to generate into a non-empty directory unless the
+// caller asked to replace (force) or write into (merge) it.
+func checkOutputDir(path string, force, merge bool) error {
+	entries, err := os.ReadDir(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 && !force && !merge {
+		return fmt.Errorf("%s is not empty; use --force to replace its contents or --merge to write into it", path)
+	}
+	return nil
+}
+
+// newStagingDir creates an empty directory next to path where the project is
+// generated before it is moved into place.
+func newStagingDir(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	parent := filepath.Dir(abs)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(parent, ".goinit-"+filepath.Base(abs)+"-")
+}
+
+// seedStagingDir copies the existing go.mod and go.sum of path into staging
+// so --merge keeps the project's module file and only tidies it.
+func seedStagingDir(staging, path string) error {
+	for _, name := range []string{"go.mod", "go.sum"} {
+		content, ok, err := readOptional(filepath.Join(path, name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(staging, name), content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vcsDirs are the version control directories that --force keeps, so
+// regenerating a project never deletes its history.
+var vcsDirs = map[string]bool{".git": true, ".hg": true, ".svn": true}
+
+// commitProject moves the generated project from staging to path. With force
+// the existing contents of path are removed first, except version control
+// directories; otherwise generated files are written over the existing tree
+// and everything else is kept.
+func commitProject(staging, path string, force bool) error {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return os.Rename(staging, path)
+	}
+
+	if force {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if vcsDirs[e.Name()] {
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(path, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := moveTree(staging, path); err != nil {
+		return err
+	}
+	return os.RemoveAll(staging)
+}
+
+// moveTree moves every entry of src into dst, merging directories that exist on both sides.
+func moveTree(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		from := filepath.Join(src, e.Name())
+		to := filepath.Join(dst, e.Name())
+
+		info, err := os.Stat(to)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return err
+		case e.IsDir() && info.IsDir():
+			if err := moveTree(from, to); err != nil {
+				return err
+			}
+			continue
+		default:
+			if err := os.RemoveAll(to); err != nil {
+				return err
+			}
+		}
+
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// previewProject renders the project into a temporary directory and prints
+// the file tree and a diff of every file that would change in path.
+func previewProject(path string, config ProjectConfig, force, merge bool) error {
+	preview, err := os.MkdirTemp("", "goinit-dry-run-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(preview)
+
+	if err := renderTemplate(preview, config); err != nil {
+		return err
+	}
+	// Local templates may bring their own go.mod
+	if _, err := os.Stat(filepath.Join(preview, "go.mod")); errors.Is(err, fs.ErrNotExist) {
+		if err := writePinnedModule(preview, config); err != nil {
+			return err
+		}
+	}
+	if err := writeManifest(preview, config); err != nil {
+		return fmt.Errorf("failed to write template manifest: %v", err)
+	}
+
+	fmt.Printf("\n🔍 Dry run: nothing is written. %s would contain:\n\n", path)
+	fmt.Printf("%s/\n", filepath.Base(filepath.Clean(path)))
+
+	var added, changed, unchanged int
+	var diffs []string
+	err = filepath.WalkDir(preview, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(preview, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if filepath.ToSlash(rel) == manifestDir+"/"+manifestBase {
+			return filepath.SkipDir
+		}
+
+		indent := strings.Repeat("  ", strings.Count(filepath.ToSlash(rel), "/")+1)
+		if d.IsDir() {
+			fmt.Printf("%s%s/\n", indent, d.Name())
+			return nil
+		}
+
+		generated, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		existing, exists, err := readOptional(filepath.Join(path, rel))
+		if err != nil {
+			return err
+		}
+
+		mark := "+"
+		switch {
+		case !exists:
+			added++
+		case string(existing) == string(generated):
+			mark = " "
+			unchanged++
+		default:
+			mark = "~"
+			changed++
+			diffs = append(diffs, unifiedDiff(filepath.Join(path, rel), filepath.Join(path, rel)+" (generated)", string(existing), string(generated)))
+		}
+		fmt.Printf("%s%s %s\n", indent[:len(indent)-2], mark, d.Name())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	removed, kept, err := previewRemovals(path, preview, force)
+	if err != nil {
+		return err
+	}
+	if len(removed) > 0 {
+		fmt.Println("\nRemoved by --force:")
+		for _, rel := range removed {
+			fmt.Printf("  - %s\n", rel)
+		}
+	}
+	if len(kept) > 0 {
+		fmt.Println("\nKept by --force (version control):")
+		for _, rel := range kept {
+			fmt.Printf("  = %s/\n", rel)
+		}
+	}
+
+	for _, d := range diffs {
+		fmt.Printf("\n%s", d)
+	}
+
+	fmt.Printf("\n📋 %d new, %d changed, %d unchanged", added, changed, unchanged)
+	if len(removed) > 0 {
+		fmt.Printf(", %d removed", len(removed))
+	}
+	fmt.Println(" (go.mod and go.sum are shown pinned, before go mod tidy)")
+
+	if err := checkOutputDir(path, force, merge); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+	return nil
+}
+
+// previewRemovals lists the existing files in path that --force would
+// delete and the version control directories it would keep.
+func previewRemovals(path, preview string, force bool) (removed, kept []string, err error) {
+	if !force {
+		return nil, nil, nil
+	}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == path {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		if filepath.Dir(rel) == "." && vcsDirs[rel] {
+			kept = append(kept, rel)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(preview, rel)); errors.Is(err, fs.ErrNotExist) {
+			removed = append(removed, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(removed)
+	sort.Strings(kept)
+	return removed, kept, err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// templateSuffix marks embedded files that are rendered with the project
+// configuration; the suffix is stripped from the generated file name.
+const templateSuffix = ".tmpl"
+
+// dotfiles maps rendered template files to the hidden names they are
+// generated as. go:embed and shouldSkipEmbedded leave hidden files out of the
+// template, so they are kept under a visible name.
+var dotfiles = map[string]string{
+	"gitignore": ".gitignore",
+}
+
+// renderedPath returns the path a .tmpl file at dst is generated at.
+func renderedPath(dst string) string {
+	dst = strings.TrimSuffix(dst, templateSuffix)
+	if name, ok := dotfiles[filepath.Base(dst)]; ok {
+		return filepath.Join(filepath.Dir(dst), name)
+	}
+	return dst
+}
+
+// Slug returns the project name in lower-case kebab form, e.g. "My_Shop" -> "my-shop".
+// It is used for docker names, binaries and domains.
+func (c ProjectConfig) Slug() string {
+	return normalizeName(c.ProjectName, '-')
+}
+
+// SnakeName returns the project name in lower-case snake form, e.g. "my-shop" -> "my_shop".
+// It is used for database names, users and cookie names.
+func (c ProjectConfig) SnakeName() string {
+	return normalizeName(c.ProjectName, '_')
+}
+
+// DatabasePort returns the default port of the database server, or "" for sqlite.
+func (c ProjectConfig) DatabasePort() string {
+	switch c.DatabaseDriver {
+	case "mysql":
+		return "3306"
+	case "postgres":
+		return "5432"
+	}
+	return ""
+}
+
+func normalizeName(name string, sep rune) string {
+	var b strings.Builder
+	pendingSep := false
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingSep && b.Len() > 0 {
+				b.WriteRune(sep)
+			}
+			pendingSep = false
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		pendingSep = true
+	}
+	return b.String()
+}
+
+// renderTemplateFile executes the template file src of fsys with config and writes the result to dst.
+func renderTemplateFile(fsys fs.FS, src, dst string, config ProjectConfig) error {
+	content, err := fs.ReadFile(fsys, src)
+	if err != nil {
+		return err
+	}
+	return renderFile(src, string(content), dst, config)
+}
+
+// renderFile executes the template text with data, usually the ProjectConfig,
+// and writes the result to dst.
+func renderFile(name, text, dst string, data any) error {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse template %s: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to render template %s: %v", name, err)
+	}
+
+	return os.WriteFile(dst, buf.Bytes(), 0644)
+}
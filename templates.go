@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// defaultTemplate is generated when no --template is given.
+const defaultTemplate = "gin-full"
+
+// ginModule is the import path of the embedded gin template.
+const ginModule = "github.com/SOG-web/goinit/gin"
+
+// projectTemplate describes a project layout goinit can generate.
+type projectTemplate struct {
+	Name        string
+	Description string
+	Layers      []templateLayer // copied in order; later layers overwrite earlier ones
+	Features    bool            // whether --features applies
+	Env         bool            // whether goinit writes the .env file
+	Run         string          // command shown in the next steps
+	Docs        bool            // whether the project serves Swagger docs
+}
+
+// templateLayer is a directory tree copied into the generated project.
+type templateLayer struct {
+	FS      fs.FS
+	Root    string
+	Include []string // slash separated paths under Root; empty copies everything
+	Module  string   // import path rewritten to the project module, if any
+}
+
+// projectTemplates are the templates built into goinit, in the order they are listed.
+var projectTemplates = []projectTemplate{
+	{
+		Name:        "gin-full",
+		Description: "Gin API with users, auth and every selectable feature",
+		Layers: []templateLayer{
+			{FS: templateFS, Root: "gin", Module: ginModule},
+		},
+		Features: true,
+		Env:      true,
+		Run:      "go run ./cmd/api",
+		Docs:     true,
+	},
+	{
+		Name:        "gin-minimal",
+		Description: "Gin API with a health check, config and logger only",
+		Layers: []templateLayer{
+			{FS: templateFS, Root: "gin", Module: ginModule, Include: []string{
+				"config",
+				"internal/logger",
+				"api/protocol/http/handler/health.go",
+				"gitignore.tmpl",
+			}},
+			{FS: templateFS, Root: "templates/gin-minimal"},
+		},
+		Env: true,
+		Run: "go run ./cmd/api",
+	},
+	{
+		Name:        "worker",
+		Description: "Background job runner sharing the config, logger, database and DI container",
+		Layers: []templateLayer{
+			{FS: templateFS, Root: "gin", Module: ginModule, Include: []string{
+				"config",
+				"internal/logger",
+				"internal/db/db.go",
+				"internal/db/dsn.go",
+				"internal/di/di_container.go",
+				"internal/di/lifecycle.go",
+				"internal/di/inject.go",
+				"internal/di/graph.go",
+				"internal/di/override.go",
+				"internal/di/decorate.go",
+				"internal/di/provider.go",
+				"internal/di/di_test.go",
+				"internal/di/bench_test.go",
+				"gitignore.tmpl",
+			}},
+			{FS: templateFS, Root: "templates/worker"},
+		},
+		Env: true,
+		Run: "go run ./cmd/worker",
+	},
+}
+
+// templateNames returns the names of the built-in templates.
+func templateNames() []string {
+	names := make([]string, len(projectTemplates))
+	for i, t := range projectTemplates {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// lookupTemplate returns the built-in template called name, or treats name as
+// the path of a local directory to use as a house template.
+func lookupTemplate(name string) (projectTemplate, error) {
+	if name == "" {
+		name = defaultTemplate
+	}
+	for _, t := range projectTemplates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+
+	if !isLocalTemplate(name) {
+		return projectTemplate{}, fmt.Errorf("unknown template %q (choose one of %s, or a template directory)", name, strings.Join(templateNames(), ", "))
+	}
+	return localTemplate(name)
+}
+
+// localTemplate uses the directory dir as a template. Its module path, read
+// from dir/go.mod, is rewritten to the project module.
+func localTemplate(dir string) (projectTemplate, error) {
+	var module string
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return projectTemplate{}, err
+	default:
+		module = modfile.ModulePath(data)
+	}
+
+	return projectTemplate{
+		Name:        dir,
+		Description: "local template directory",
+		Layers:      []templateLayer{{FS: os.DirFS(dir), Root: ".", Module: module}},
+	}, nil
+}
+
+// isLocalTemplate reports whether name is an existing template directory rather than a built-in template.
+func isLocalTemplate(name string) bool {
+	for _, t := range projectTemplates {
+		if t.Name == name {
+			return false
+		}
+	}
+	info, err := os.Stat(name)
+	return err == nil && info.IsDir()
+}
+
+// includes reports whether the slash separated path rel under the layer root is copied.
+// Directories leading to an included path are walked into.
+func (l templateLayer) includes(rel string, dir bool) bool {
+	if len(l.Include) == 0 || rel == "." {
+		return true
+	}
+	for _, inc := range l.Include {
+		if rel == inc || strings.HasPrefix(rel, inc+"/") {
+			return true
+		}
+		if dir && strings.HasPrefix(inc, rel+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// copyTemplate copies every layer of tmpl into dst, rendering .tmpl files with config.
+func copyTemplate(dst string, tmpl projectTemplate, config ProjectConfig) error {
+	for _, layer := range tmpl.Layers {
+		if err := copyLayer(dst, layer, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyLayer(dst string, layer templateLayer, config ProjectConfig) error {
+	return fs.WalkDir(layer.FS, layer.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip certain directories and files
+		if path != layer.Root && shouldSkipEmbedded(path, d) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		// Get relative path from the layer root
+		relPath, err := filepath.Rel(layer.Root, path)
+		if err != nil {
+			return err
+		}
+		if !layer.includes(filepath.ToSlash(relPath), d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		dstPath := filepath.Join(dst, relPath)
+
+		if d.IsDir() {
+			return os.MkdirAll(dstPath, 0755)
+		}
+
+		// Render .tmpl files with the project configuration and drop the suffix
+		if strings.HasSuffix(path, templateSuffix) {
+			return renderTemplateFile(layer.FS, path, renderedPath(dstPath), config)
+		}
+
+		return copyTemplateFile(layer.FS, path, dstPath)
+	})
+}
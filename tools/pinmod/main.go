@@ -0,0 +1,117 @@
+// Command pinmod derives the pinned go.mod and go.sum embedded in goinit from
+// the import set of the gin template. It copies the template's Go files into a
+// scratch module that starts from goinit's own requirements, lets go mod tidy
+// drop everything the template does not import and writes the result to gomod/.
+//
+// Run it from the repository root with "go generate".
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+const templateModule = "github.com/SOG-web/goinit/gin"
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "pinmod: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	scratch, err := os.MkdirTemp("", "goinit-pinmod-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+
+	if err := copyGoFiles("gin", scratch); err != nil {
+		return err
+	}
+
+	// Start from goinit's own requirements so tidy keeps the versions goinit is tested with
+	rootMod, err := os.ReadFile("go.mod")
+	if err != nil {
+		return err
+	}
+	mod, err := modfile.Parse("go.mod", rootMod, nil)
+	if err != nil {
+		return err
+	}
+	if err := mod.AddModuleStmt(templateModule); err != nil {
+		return err
+	}
+	data, err := mod.Format()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(scratch, "go.mod"), data, 0644); err != nil {
+		return err
+	}
+	if err := copyFile("go.sum", filepath.Join(scratch, "go.sum")); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "mod", "tidy")
+	cmd.Dir = scratch
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("go mod tidy failed: %v\n%s", err, output)
+	}
+
+	tidied, err := os.ReadFile(filepath.Join(scratch, "go.mod"))
+	if err != nil {
+		return err
+	}
+	tidied = bytes.Replace(tidied, []byte("module "+templateModule), []byte("module {{.ModuleName}}"), 1)
+
+	if err := os.MkdirAll("gomod", 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join("gomod", "go.mod.tmpl"), tidied, 0644); err != nil {
+		return err
+	}
+	return copyFile(filepath.Join(scratch, "go.sum"), filepath.Join("gomod", "go.sum"))
+}
+
+// copyGoFiles copies every Go file under src to dst, skipping hidden directories
+// the same way go:embed does.
+func copyGoFiles(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != src && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
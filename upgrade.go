@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// The manifest records which template version a project was generated from,
+// the configuration used and a hash of every generated file. The pristine
+// generated files are kept next to it so upgrades can three-way merge.
+const (
+	manifestDir  = ".goinit"
+	manifestFile = "manifest.json"
+	manifestBase = "base"
+)
+
+// untrackedFiles are generated but owned by the project from then on:
+// go.mod and go.sum are maintained by the go tool, upgrades only merge in the
+// template's new requirements, and .env holds local settings.
+var untrackedFiles = map[string]bool{"go.mod": true, "go.sum": true, ".env": true}
+
+type projectManifest struct {
+	TemplateVersion string            `json:"template_version"`
+	Config          ProjectConfig     `json:"config"`
+	Files           map[string]string `json:"files"` // slash separated path -> sha256
+}
+
+// writeManifest records the generated files of projectPath in .goinit and
+// snapshots them as the base for future upgrades.
+func writeManifest(projectPath string, config ProjectConfig) error {
+	return recordManifest(projectPath, projectPath, config)
+}
+
+// recordManifest hashes the template files rendered in srcDir and stores the
+// manifest and base snapshot in projectPath.
+func recordManifest(projectPath, srcDir string, config ProjectConfig) error {
+	files, err := listTemplateFiles(srcDir)
+	if err != nil {
+		return err
+	}
+
+	baseDir := filepath.Join(projectPath, manifestDir, manifestBase)
+	if err := os.RemoveAll(baseDir); err != nil {
+		return err
+	}
+
+	manifest := projectManifest{
+		TemplateVersion: version,
+		Config:          config,
+		Files:           make(map[string]string, len(files)),
+	}
+	for _, rel := range files {
+		content, err := os.ReadFile(filepath.Join(srcDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		manifest.Files[rel] = hashContent(content)
+
+		dst := filepath.Join(baseDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, content, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(projectPath, manifestDir, manifestFile), append(data, '\n'), 0644)
+}
+
+func readManifest(projectPath string) (projectManifest, error) {
+	var manifest projectManifest
+	data, err := os.ReadFile(filepath.Join(projectPath, manifestDir, manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return manifest, fmt.Errorf("no %s/%s in %s; only projects generated with a template manifest can be upgraded", manifestDir, manifestFile, projectPath)
+		}
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse %s/%s: %v", manifestDir, manifestFile, err)
+	}
+	// Manifests written before templates could be chosen were always gin-full
+	if manifest.Config.Template == "" {
+		manifest.Config.Template = defaultTemplate
+	}
+	return manifest, nil
+}
+
+// listTemplateFiles returns the slash separated paths of the tracked files under dir.
+func listTemplateFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if rel == manifestDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !untrackedFiles[rel] {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// renderTemplate renders the template selected by config into dir without
+// touching the Go toolchain.
+func renderTemplate(dir string, config ProjectConfig) error {
+	tmpl, err := lookupTemplate(config.Template)
+	if err != nil {
+		return err
+	}
+	if err := copyTemplate(dir, tmpl, config); err != nil {
+		return fmt.Errorf("failed to copy template: %v", err)
+	}
+	if tmpl.Env {
+		if err := generateTemplatedFiles(dir, config); err != nil {
+			return fmt.Errorf("failed to generate templated files: %v", err)
+		}
+	}
+	if err := pruneFeatures(dir, config); err != nil {
+		return fmt.Errorf("failed to prune features: %v", err)
+	}
+	if err := replaceModuleReferences(dir, tmpl, config); err != nil {
+		return fmt.Errorf("failed to replace module references: %v", err)
+	}
+	return nil
+}
+
+// upgradeResult counts what an upgrade did to the project.
+type upgradeResult struct {
+	changed   int
+	conflicts []string
+}
+
+// runUpgrade implements "goinit upgrade".
+func runUpgrade(args []string) int {
+	fs := flag.NewFlagSet("goinit upgrade", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("dir", ".", "project directory")
+	rej := fs.Bool("rej", false, "write conflicting template changes to .rej files instead of conflict markers")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "❌ unexpected argument %q\n", fs.Arg(0))
+		return exitUsage
+	}
+
+	manifest, err := readManifest(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFailure
+	}
+
+	fmt.Printf("⬆️  Upgrading template %s → %s\n", manifest.TemplateVersion, version)
+
+	newDir, err := os.MkdirTemp("", "goinit-upgrade-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFailure
+	}
+	defer os.RemoveAll(newDir)
+
+	config := manifest.Config
+	config.OutputDir = newDir
+	if err := renderTemplate(newDir, config); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error rendering template: %v\n", err)
+		return exitFailure
+	}
+
+	result, err := upgradeProject(*dir, newDir, manifest, *rej)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error upgrading project: %v\n", err)
+		return exitFailure
+	}
+
+	// New template code may import modules the project does not require yet
+	if err := writePinnedModule(newDir, config); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error rendering go.mod: %v\n", err)
+		return exitFailure
+	}
+	requires, err := mergePinnedModule(*dir, newDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error updating go.mod: %v\n", err)
+		return exitFailure
+	}
+	for _, r := range requires {
+		fmt.Printf("  📦 required %s\n", r)
+	}
+
+	if err := recordManifest(*dir, newDir, manifest.Config); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error updating manifest: %v\n", err)
+		return exitFailure
+	}
+
+	if len(result.conflicts) > 0 {
+		fmt.Fprintf(os.Stderr, "\n⚠️  %d file(s) need manual resolution:\n", len(result.conflicts))
+		for _, path := range result.conflicts {
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+		return exitFailure
+	}
+
+	if result.changed == 0 && len(requires) == 0 {
+		fmt.Println("\n✅ Project is already up to date")
+	} else {
+		fmt.Printf("\n✅ Upgraded %d file(s) to template %s\n", result.changed, version)
+	}
+	if len(requires) > 0 {
+		fmt.Println("🧹 go.mod now has the template's requirements; run 'go mod tidy' to drop the ones the project does not use")
+	}
+	return exitOK
+}
+
+// upgradeProject applies the changes between the manifest's base snapshot and
+// the template rendered in newDir to the project in projectPath.
+func upgradeProject(projectPath, newDir string, manifest projectManifest, rej bool) (upgradeResult, error) {
+	var result upgradeResult
+
+	newFiles, err := listTemplateFiles(newDir)
+	if err != nil {
+		return result, err
+	}
+
+	paths := make(map[string]bool)
+	for rel := range manifest.Files {
+		paths[rel] = true
+	}
+	for _, rel := range newFiles {
+		paths[rel] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for rel := range paths {
+		sorted = append(sorted, rel)
+	}
+	sort.Strings(sorted)
+
+	for _, rel := range sorted {
+		status, conflict, err := upgradeFile(projectPath, newDir, manifest, rel, rej)
+		if err != nil {
+			return result, fmt.Errorf("%s: %v", rel, err)
+		}
+		if status == "" {
+			continue
+		}
+		fmt.Printf("  %s %s\n", status, rel)
+		result.changed++
+		if conflict {
+			result.conflicts = append(result.conflicts, rel)
+		}
+	}
+	return result, nil
+}
+
+// upgradeFile upgrades a single file and returns a status line, or "" when nothing changed.
+func upgradeFile(projectPath, newDir string, manifest projectManifest, rel string, rej bool) (string, bool, error) {
+	baseHash, inBase := manifest.Files[rel]
+	target := filepath.Join(projectPath, filepath.FromSlash(rel))
+
+	newContent, inNew, err := readOptional(filepath.Join(newDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", false, err
+	}
+	current, inCurrent, err := readOptional(target)
+	if err != nil {
+		return "", false, err
+	}
+
+	// A checkout with CRLF line endings, such as git's autocrlf makes on
+	// Windows, is compared and merged as LF and written back with CRLF
+	crlf := !isBinary(current) && bytes.Contains(current, []byte("\r\n")) && !bytes.Contains(newContent, []byte("\r\n"))
+	if crlf {
+		current = bytes.ReplaceAll(current, []byte("\r\n"), []byte("\n"))
+	}
+	write := func(content []byte) error {
+		if crlf {
+			content = bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+		}
+		return os.WriteFile(target, content, 0644)
+	}
+
+	switch {
+	case inBase && inNew && hashContent(newContent) == baseHash:
+		// The template did not change this file
+		return "", false, nil
+	case !inCurrent && inBase:
+		// Deleted locally; respect that
+		if inNew {
+			return "⏭️  skipped (deleted locally)", false, nil
+		}
+		return "", false, nil
+	case !inCurrent:
+		return "➕ added", false, writeFileAll(target, newContent)
+	case inNew && bytes.Equal(current, newContent):
+		return "", false, nil
+	case !inNew && inBase && hashContent(current) == baseHash:
+		return "🗑️  removed", false, os.Remove(target)
+	case !inNew:
+		return "⏭️  kept (modified locally, removed from template)", false, nil
+	case inBase && hashContent(current) == baseHash:
+		return "✅ updated", false, write(newContent)
+	}
+
+	// Both the template and the project changed the file: three-way merge
+	var base []byte
+	if inBase {
+		snapshot, ok, err := readOptional(filepath.Join(projectPath, manifestDir, manifestBase, filepath.FromSlash(rel)))
+		if err != nil {
+			return "", false, err
+		}
+		if ok && hashContent(snapshot) == baseHash {
+			base = snapshot
+		}
+	}
+
+	if isBinary(base) || isBinary(current) || isBinary(newContent) {
+		return "⚠️  conflict (binary, new version in " + rel + ".goinit-new)", true, os.WriteFile(target+".goinit-new", newContent, 0644)
+	}
+
+	chunks := merge3(splitLines(string(base)), splitLines(string(current)), splitLines(string(newContent)))
+	baseLabel := "goinit " + manifest.TemplateVersion
+	theirsLabel := "goinit " + version
+
+	if !hasConflicts(chunks) {
+		return "🔀 merged", false, write([]byte(renderWithMarkers(chunks, "", "", "")))
+	}
+
+	if rej {
+		merged, rejects := renderWithRejects(chunks, rel, baseLabel, theirsLabel)
+		if err := write([]byte(merged)); err != nil {
+			return "", false, err
+		}
+		return "⚠️  conflict (see " + rel + ".rej)", true, os.WriteFile(target+".rej", []byte(rejects), 0644)
+	}
+
+	merged := renderWithMarkers(chunks, "yours", baseLabel, theirsLabel)
+	return "⚠️  conflict", true, write([]byte(merged))
+}
+
+// readOptional reads path, reporting whether it exists.
+func readOptional(path string) ([]byte, bool, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return content, true, nil
+}
+
+func writeFileAll(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// verifySteps are run in order; a failing step stops verification because
+// the later ones would only repeat its errors.
+var verifySteps = [][]string{
+	{"go", "build", "./..."},
+	{"go", "vet", "./..."},
+	{"go", "test", "./..."},
+}
+
+// sourceLocationPattern matches "file.go:line[:col]: message" lines printed by build, vet and test.
+var sourceLocationPattern = regexp.MustCompile(`^\s*(\S+\.go):(\d+)(?::(\d+))?:\s*(.*)$`)
+
+// verifyProject builds, vets and tests the project in projectPath and reports
+// failures with their source locations. env is added to the current environment.
+func verifyProject(projectPath string, env []string) error {
+	fmt.Println("🔍 Verifying project...")
+
+	for _, step := range verifySteps {
+		command := strings.Join(step, " ")
+
+		cmd := exec.Command(step[0], step[1:]...)
+		cmd.Dir = projectPath
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
+
+		output, err := cmd.CombinedOutput()
+		if err == nil {
+			fmt.Printf("  ✅ %s\n", command)
+			continue
+		}
+
+		fmt.Printf("  ❌ %s\n", command)
+		locations := sourceLocations(string(output))
+		if len(locations) == 0 {
+			fmt.Printf("%s\n", indentOutput(string(output)))
+		}
+		for _, loc := range locations {
+			fmt.Printf("     📍 %s\n", loc)
+		}
+		return fmt.Errorf("%s failed: %v", command, err)
+	}
+
+	fmt.Println("✅ Project builds, vets and passes its tests")
+	return nil
+}
+
+// sourceLocations extracts "file:line:col: message" entries from go tool output.
+func sourceLocations(output string) []string {
+	var locations []string
+	for _, line := range strings.Split(output, "\n") {
+		m := sourceLocationPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		loc := filepath.ToSlash(strings.TrimPrefix(m[1], "./")) + ":" + m[2]
+		if m[3] != "" {
+			loc += ":" + m[3]
+		}
+		locations = append(locations, loc+": "+m[4])
+	}
+	return locations
+}
+
+func indentOutput(output string) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "     " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runVerify implements "goinit verify".
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("goinit verify", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("dir", ".", "project directory")
+	offline := fs.Bool("offline", false, "use only the module cache")
+	goproxy := fs.String("goproxy", "", "local GOPROXY directory or module cache for --offline")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "❌ unexpected argument %q\n", fs.Arg(0))
+		return exitUsage
+	}
+	if *goproxy != "" && !*offline {
+		fmt.Fprintln(os.Stderr, "❌ --goproxy requires --offline")
+		return exitUsage
+	}
+
+	if _, err := os.Stat(filepath.Join(*dir, "go.mod")); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ no go.mod found in %s\n", *dir)
+		return exitFailure
+	}
+
+	var env []string
+	if *offline {
+		var err error
+		if env, err = offlineGoEnv(*goproxy); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return exitFailure
+		}
+	}
+
+	if err := verifyProject(*dir, env); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Verification failed: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}